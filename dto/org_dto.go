@@ -1,6 +1,11 @@
 // Package dto contains data transfer objects for API requests and responses.
 package dto
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // CreateOrganizationRequest represents a request to create a new organization.
 type CreateOrganizationRequest struct {
 	Name string `json:"name" binding:"required"`
@@ -11,8 +16,12 @@ type UpdateOrganizationRequest struct {
 }
 
 type OrganizationResponse struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	ParentID *uint  `json:"parent_id"`
+
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
 }
 
 // PermissionType represents user permissions in an organization.
@@ -25,13 +34,17 @@ const (
 )
 
 // AddUserToOrgRequest represents a request to add a user to an organization.
+// RoleID assigns a custom role instead of a built-in permission.
 type AddUserToOrgRequest struct {
 	UserID     uint           `json:"user_id" binding:"required"`
-	Permission PermissionType `json:"permission" binding:"required"`
+	Permission PermissionType `json:"permission" binding:"required_without=RoleID"`
+	RoleID     *uint          `json:"role_id"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
 }
 
 type UpdateOrgUserPermissionRequest struct {
-	Permission PermissionType `json:"permission" binding:"required"`
+	Permission PermissionType `json:"permission" binding:"required_without=RoleID"`
+	RoleID     *uint          `json:"role_id"`
 }
 
 type OrgUserResponse struct {
@@ -40,10 +53,274 @@ type OrgUserResponse struct {
 	UserEmail  string         `json:"user_email"`
 	OrgID      uint           `json:"org_id"`
 	Permission PermissionType `json:"permission"`
+	RoleID     *uint          `json:"role_id,omitempty"`
+	Role       string         `json:"role,omitempty"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`
+}
+
+// SetMembershipExpiryRequest changes when a membership ends; a null
+// expires_at makes it permanent.
+type SetMembershipExpiryRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 type OrganizationDetailResponse struct {
-	ID    uint               `json:"id"`
-	Name  string             `json:"name"`
-	Users []OrgUserResponse  `json:"users"`
+	ID                  uint              `json:"id"`
+	Name                string            `json:"name"`
+	Slug                string            `json:"slug"`
+	ParentID            *uint             `json:"parent_id"`
+	InheritParentAccess bool              `json:"inherit_parent_access"`
+	ArchivedAt          *time.Time        `json:"archived_at,omitempty"`
+	Users               []OrgUserResponse `json:"users"`
+}
+
+// CreateInvitationRequest represents a request to invite an email address to an organization.
+type CreateInvitationRequest struct {
+	Email      string         `json:"email" binding:"required,email"`
+	Permission PermissionType `json:"permission" binding:"required"`
+}
+
+type InvitationTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type InvitationResponse struct {
+	ID         uint           `json:"id"`
+	OrgID      uint           `json:"org_id"`
+	Email      string         `json:"email"`
+	Permission PermissionType `json:"permission"`
+	Status     string         `json:"status"`
+	InvitedBy  uint           `json:"invited_by"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// RoleRequest represents a request to create or update a custom organization role.
+type RoleRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Capabilities []string `json:"capabilities" binding:"required,min=1"`
+}
+
+type RoleResponse struct {
+	ID           uint     `json:"id"`
+	OrgID        *uint    `json:"org_id"`
+	Name         string   `json:"name"`
+	Capabilities []string `json:"capabilities"`
+	System       bool     `json:"system"`
+}
+
+// UserOrgResponse represents an organization the user belongs to, with the user's permission.
+type UserOrgResponse struct {
+	ID         uint           `json:"id"`
+	Name       string         `json:"name"`
+	Slug       string         `json:"slug"`
+	Permission PermissionType `json:"permission"`
+	RoleID     *uint          `json:"role_id,omitempty"`
+	Role       string         `json:"role,omitempty"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`
+}
+
+// TeamRequest represents a request to create or update a team inside an organization.
+type TeamRequest struct {
+	Name       string         `json:"name" binding:"required"`
+	Permission PermissionType `json:"permission" binding:"required"`
+}
+
+type AddTeamMemberRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+type TeamResponse struct {
+	ID         uint           `json:"id"`
+	OrgID      uint           `json:"org_id"`
+	Name       string         `json:"name"`
+	Permission PermissionType `json:"permission"`
+	MemberIDs  []uint         `json:"member_ids"`
+}
+
+// SetParentRequest moves an organization under a parent; a null parent_id detaches it.
+// InheritParentAccess defaults to true.
+type SetParentRequest struct {
+	ParentID            *uint `json:"parent_id"`
+	InheritParentAccess *bool `json:"inherit_parent_access"`
+}
+
+// SetInheritParentAccessRequest turns on or off the ROOT access flowing from
+// the parent organization.
+type SetInheritParentAccessRequest struct {
+	InheritParentAccess *bool `json:"inherit_parent_access" binding:"required"`
+}
+
+type OrgNodeResponse struct {
+	ID                  uint   `json:"id"`
+	Name                string `json:"name"`
+	Slug                string `json:"slug"`
+	ParentID            *uint  `json:"parent_id"`
+	InheritParentAccess bool   `json:"inherit_parent_access"`
+	Depth               int    `json:"depth"`
+}
+
+// ResourceUsageResponse reports one quota; a limit of 0 means unlimited.
+type ResourceUsageResponse struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"`
+}
+
+type UsageResponse struct {
+	Plan    string                `json:"plan"`
+	Members ResourceUsageResponse `json:"members"`
+	Roots   ResourceUsageResponse `json:"roots"`
+	Teams   ResourceUsageResponse `json:"teams"`
+}
+
+// DeletionImpactResponse is the dry run of an organization deletion.
+// Subsidiaries are detached from the organization, not deleted.
+type DeletionImpactResponse struct {
+	OrgID              uint   `json:"org_id"`
+	Name               string `json:"name"`
+	Members            int64  `json:"members"`
+	Teams              int64  `json:"teams"`
+	TeamMembers        int64  `json:"team_members"`
+	Invitations        int64  `json:"invitations"`
+	PendingInvitations int64  `json:"pending_invitations"`
+	CustomRoles        int64  `json:"custom_roles"`
+	JoinRequests       int64  `json:"join_requests"`
+	Domains            int64  `json:"domains"`
+	Settings           int64  `json:"settings"`
+	SlugHistory        int64  `json:"slug_history"`
+	ExpiryRecords      int64  `json:"expiry_records"`
+	SSOConfigs         int64  `json:"sso_configs"`
+	SSOIdentities      int64  `json:"sso_identities"`
+	Subsidiaries       int64  `json:"subsidiaries"`
+}
+
+type DeletionJobResponse struct {
+	ID          uint       `json:"id"`
+	OrgID       uint       `json:"org_id"`
+	OrgName     string     `json:"org_name"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	RequestedBy uint       `json:"requested_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// AdminOrgResponse lists an organization to platform admins.
+type AdminOrgResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	ParentID    *uint      `json:"parent_id"`
+	Plan        string     `json:"plan"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+	MemberCount int64      `json:"member_count"`
+}
+
+type SetPlanRequest struct {
+	Plan string `json:"plan" binding:"required"`
+}
+
+type CreateJoinRequestRequest struct {
+	Message string `json:"message" binding:"max=500"`
+}
+
+// ApproveJoinRequestRequest picks the permission of the new member. When it
+// is empty the organization's default member permission is used.
+type ApproveJoinRequestRequest struct {
+	Permission PermissionType `json:"permission"`
+}
+
+type JoinRequestResponse struct {
+	ID         uint           `json:"id"`
+	OrgID      uint           `json:"org_id"`
+	UserID     uint           `json:"user_id"`
+	Message    string         `json:"message,omitempty"`
+	Status     string         `json:"status"`
+	Permission PermissionType `json:"permission,omitempty"`
+	DecidedBy  *uint          `json:"decided_by,omitempty"`
+	DecidedAt  *time.Time     `json:"decided_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type ClaimDomainRequest struct {
+	Domain string `json:"domain" binding:"required"`
+}
+
+// DomainClaimResponse includes the TXT record that proves control of the
+// domain.
+type DomainClaimResponse struct {
+	ID          uint       `json:"id"`
+	OrgID       uint       `json:"org_id"`
+	Domain      string     `json:"domain"`
+	RecordName  string     `json:"record_name"`
+	RecordValue string     `json:"record_value"`
+	Verified    bool       `json:"verified"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// SSOConfigRequest configures an organization's OpenID Connect identity
+// provider. An empty ClientSecret keeps the stored one.
+type SSOConfigRequest struct {
+	Issuer           string                    `json:"issuer" binding:"required"`
+	ClientID         string                    `json:"client_id" binding:"required"`
+	ClientSecret     string                    `json:"client_secret"`
+	RedirectURL      string                    `json:"redirect_url" binding:"required"`
+	GroupsClaim      string                    `json:"groups_claim"`
+	GroupPermissions map[string]PermissionType `json:"group_permissions"`
+	Enabled          *bool                     `json:"enabled"`
+}
+
+// SSOConfigResponse leaves the client secret out.
+type SSOConfigResponse struct {
+	OrgID            uint                      `json:"org_id"`
+	Issuer           string                    `json:"issuer"`
+	ClientID         string                    `json:"client_id"`
+	ClientSecretSet  bool                      `json:"client_secret_set"`
+	RedirectURL      string                    `json:"redirect_url"`
+	GroupsClaim      string                    `json:"groups_claim"`
+	GroupPermissions map[string]PermissionType `json:"group_permissions"`
+	Enabled          bool                      `json:"enabled"`
+	UpdatedAt        time.Time                 `json:"updated_at"`
+}
+
+// BulkMembershipOperation is one entry of a bulk membership request. Op is
+// "add", "update" or "remove".
+type BulkMembershipOperation struct {
+	Op         string         `json:"op" binding:"required,oneof=add update remove"`
+	UserID     uint           `json:"user_id" binding:"required"`
+	Permission PermissionType `json:"permission"`
+	RoleID     *uint          `json:"role_id"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+}
+
+type BulkMembershipRequest struct {
+	Operations []BulkMembershipOperation `json:"operations" binding:"required,min=1,max=500,dive"`
+}
+
+type BulkMembershipResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	UserID uint   `json:"user_id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AuditEntryResponse is one audit log entry. actor_id is 0 for changes made
+// by the system; on_behalf_of_id is the impersonated user, if any.
+type AuditEntryResponse struct {
+	ID           uint            `json:"id"`
+	ActorID      uint            `json:"actor_id"`
+	OnBehalfOfID *uint           `json:"on_behalf_of_id,omitempty"`
+	Action       string          `json:"action"`
+	TargetType   string          `json:"target_type"`
+	TargetID     uint            `json:"target_id"`
+	OrgID        *uint           `json:"org_id"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	IP           string          `json:"ip"`
+	UserAgent    string          `json:"user_agent"`
+	RequestID    string          `json:"request_id"`
+	CreatedAt    time.Time       `json:"created_at"`
 }
@@ -1,10 +1,14 @@
 // Package dto contains data transfer objects for API requests and responses.
 package dto
 
+import "time"
+
 // CreateUserRequest represents a request to create a new user.
 type CreateUserRequest struct {
 	Name  string `json:"name" binding:"required"`
 	Email string `json:"email" binding:"required,email"`
+	// Password is optional; users without one cannot log in with a password.
+	Password string `json:"password"`
 }
 
 type UserResponse struct {
@@ -12,3 +16,71 @@ type UserResponse struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
+
+// ImpersonationResponse carries a token that acts as SubjectID on behalf of
+// ActorID until ExpiresAt.
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ActorID   uint      `json:"actor_id"`
+	SubjectID uint      `json:"subject_id"`
+}
+
+// LoginRequest exchanges an email and password for a session token.
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginResponse carries a session token for UserID, valid until ExpiresAt.
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    uint      `json:"user_id"`
+}
+
+// ChangePasswordRequest sets the caller's password. CurrentPassword is
+// required when the caller already has one.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// TwoFactorChallengeResponse answers a correct password of a user with
+// two-factor authentication. ChallengeToken goes to the second login step.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// SecondFactorRequest completes a login with a TOTP code or a recovery code.
+type SecondFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// TwoFactorEnrollmentResponse carries the TOTP secret to add to an
+// authenticator app, directly or as a QR code of ProvisioningURI.
+type TwoFactorEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// VerifyEmailRequest carries the token mailed to confirm an email address.
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code, or a recovery code where one is
+// accepted.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse lists one-time recovery codes. They are shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
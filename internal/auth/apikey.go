@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"meu-treino-golang/users-crud/internal/common"
+)
+
+// APIKeyHeader carries the key checked by APIKeyAuthenticator.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator accepts keys sent in the X-API-Key header. Each key
+// acts as the user it is assigned to. Only SHA-256 digests of the keys are
+// kept in memory.
+type APIKeyAuthenticator struct {
+	keys map[[sha256.Size]byte]uint
+}
+
+// NewAPIKeyAuthenticator maps each key to the user it authenticates.
+func NewAPIKeyAuthenticator(keys map[string]uint) APIKeyAuthenticator {
+	digests := make(map[[sha256.Size]byte]uint, len(keys))
+	for key, userID := range keys {
+		digests[sha256.Sum256([]byte(key))] = userID
+	}
+	return APIKeyAuthenticator{keys: digests}
+}
+
+// ParseAPIKeys reads "key=userID" pairs separated by commas.
+func ParseAPIKeys(value string) (map[string]uint, error) {
+	keys := map[string]uint{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, rawID, found := strings.Cut(pair, "=")
+		userID, err := strconv.ParseUint(rawID, 10, 32)
+		if !found || key == "" || err != nil || userID == 0 {
+			return nil, fmt.Errorf("invalid API key entry %q, expected key=userID", redact(pair))
+		}
+		keys[key] = uint(userID)
+	}
+	return keys, nil
+}
+
+func (a APIKeyAuthenticator) Authenticate(r *http.Request) (*common.Identity, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, nil
+	}
+
+	userID, ok := a.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, common.ErrInvalidCredentials
+	}
+	return &common.Identity{UserID: userID, Method: ModeAPIKey}, nil
+}
+
+func (APIKeyAuthenticator) Insecure() bool {
+	return false
+}
+
+// redact keeps configuration errors from printing the key itself.
+func redact(pair string) string {
+	if _, rawID, found := strings.Cut(pair, "="); found {
+		return "***=" + rawID
+	}
+	return "***"
+}
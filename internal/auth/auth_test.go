@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func request(headers map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	return r
+}
+
+func TestJWTAuthenticator_RoundTrip(t *testing.T) {
+	authenticator := JWTAuthenticator{Secret: testSecret, Issuer: "users-crud"}
+	token, err := authenticator.IssueToken(42, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := authenticator.Authenticate(request(map[string]string{"Authorization": "Bearer " + token}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity == nil || identity.UserID != 42 || identity.Method != ModeJWT {
+		t.Fatalf("identity = %+v", identity)
+	}
+}
+
+func TestJWTAuthenticator_Rejects(t *testing.T) {
+	authenticator := JWTAuthenticator{Secret: testSecret, Issuer: "users-crud"}
+	valid, _ := authenticator.IssueToken(42, time.Hour)
+	expired, _ := authenticator.IssueToken(42, -time.Minute)
+	otherIssuer, _ := JWTAuthenticator{Secret: testSecret, Issuer: "other"}.IssueToken(42, time.Hour)
+	otherSecret, _ := JWTAuthenticator{Secret: []byte("another-secret-another-secret-xx"), Issuer: "users-crud"}.IssueToken(42, time.Hour)
+
+	parts := strings.Split(valid, ".")
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","exp":9999999999}`)) + "." + parts[2]
+
+	cases := map[string]string{
+		"expired":      "Bearer " + expired,
+		"issuer":       "Bearer " + otherIssuer,
+		"secret":       "Bearer " + otherSecret,
+		"alg none":     "Bearer " + unsigned,
+		"tampered":     "Bearer " + tampered,
+		"garbage":      "Bearer not-a-token",
+		"wrong scheme": "Basic " + valid,
+	}
+	for name, header := range cases {
+		_, err := authenticator.Authenticate(request(map[string]string{"Authorization": header}))
+		if !errors.Is(err, common.ErrInvalidCredentials) {
+			t.Fatalf("%s: err = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+}
+
+func TestAuthenticators_AnonymousWithoutCredentials(t *testing.T) {
+	authenticators := []common.Authenticator{
+		JWTAuthenticator{Secret: testSecret},
+		NewAPIKeyAuthenticator(map[string]uint{"key": 1}),
+		HeaderAuthenticator{},
+		common.NoAuthenticator{},
+	}
+	for _, authenticator := range authenticators {
+		identity, err := authenticator.Authenticate(request(nil))
+		if identity != nil || err != nil {
+			t.Fatalf("%T: identity = %+v, err = %v", authenticator, identity, err)
+		}
+	}
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	keys, err := ParseAPIKeys("alpha=1, beta=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator := NewAPIKeyAuthenticator(keys)
+
+	identity, err := authenticator.Authenticate(request(map[string]string{"X-API-Key": "beta"}))
+	if err != nil || identity == nil || identity.UserID != 2 {
+		t.Fatalf("identity = %+v, err = %v", identity, err)
+	}
+	if _, err := authenticator.Authenticate(request(map[string]string{"X-API-Key": "gamma"})); !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Fatalf("unknown key: err = %v", err)
+	}
+}
+
+func TestParseAPIKeys_RejectsMalformedWithoutLeakingKey(t *testing.T) {
+	for _, value := range []string{"secret", "secret=", "secret=abc", "=1", "secret=0"} {
+		_, err := ParseAPIKeys(value)
+		if err == nil {
+			t.Fatalf("%q: expected an error", value)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Fatalf("%q: error leaks the key: %v", value, err)
+		}
+	}
+}
+
+func TestHeaderAuthenticator(t *testing.T) {
+	identity, err := HeaderAuthenticator{}.Authenticate(request(map[string]string{"X-User-ID": "7"}))
+	if err != nil || identity == nil || identity.UserID != 7 {
+		t.Fatalf("identity = %+v, err = %v", identity, err)
+	}
+	for _, value := range []string{"0", "-1", "seven"} {
+		if _, err := (HeaderAuthenticator{}).Authenticate(request(map[string]string{"X-User-ID": value})); err == nil {
+			t.Fatalf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"jwt", Config{Mode: ModeJWT, JWTSecret: testSecret}, false},
+		{"jwt short secret", Config{Mode: ModeJWT, JWTSecret: []byte("short")}, true},
+		{"api key", Config{Mode: ModeAPIKey, APIKeys: "alpha=1"}, false},
+		{"api key without keys", Config{Mode: ModeAPIKey}, true},
+		{"header in development", Config{Mode: ModeHeader}, false},
+		{"none in development", Config{Mode: ModeNone}, false},
+		{"unknown mode", Config{Mode: "magic"}, true},
+		{"jwt in production", Config{Mode: ModeJWT, JWTSecret: testSecret, Environment: EnvProduction}, false},
+		{"header in production", Config{Mode: ModeHeader, Environment: EnvProduction}, true},
+		{"none in production", Config{Mode: ModeNone, Environment: EnvProduction}, true},
+	}
+	for _, tc := range cases {
+		_, err := New(tc.cfg)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%s: err = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestImpersonation(t *testing.T) {
+	impersonation := NewImpersonation(testSecret, HeaderAuthenticator{})
+	token, expiresAt, err := impersonation.Issue(1, 2, ImpersonationTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(expiresAt) > ImpersonationTTL {
+		t.Fatalf("expires at %s, after the TTL", expiresAt)
+	}
+
+	identity, err := impersonation.Authenticate(request(map[string]string{"Authorization": "Bearer " + token}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.Identity{UserID: 2, ActorID: 1, Method: ModeImpersonation}
+	if identity == nil || *identity != want {
+		t.Fatalf("identity = %+v, want %+v", identity, want)
+	}
+
+	// Other requests go to the configured authenticator.
+	identity, err = impersonation.Authenticate(request(map[string]string{"X-User-ID": "5"}))
+	if err != nil || identity == nil || identity.UserID != 5 || identity.Impersonated() {
+		t.Fatalf("delegated identity = %+v, err = %v", identity, err)
+	}
+}
+
+func TestImpersonation_TokensAreNotRegularTokens(t *testing.T) {
+	impersonation := NewImpersonation(testSecret, nil)
+	token, _, err := impersonation.Issue(1, 2, ImpersonationTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwt := JWTAuthenticator{Secret: testSecret}
+	if _, err := jwt.Authenticate(request(map[string]string{"Authorization": "Bearer " + token})); err == nil {
+		t.Fatalf("a JWT authenticator sharing the secret must not accept impersonation tokens")
+	}
+
+	regular, _ := jwt.IssueToken(2, time.Hour)
+	identity, err := impersonation.Authenticate(request(map[string]string{"Authorization": "Bearer " + regular}))
+	if identity != nil || err != nil {
+		t.Fatalf("regular token accepted as impersonation: %+v, %v", identity, err)
+	}
+}
+
+func TestImpersonation_ExpiredTokenIsNotTrusted(t *testing.T) {
+	impersonation := NewImpersonation(testSecret, JWTAuthenticator{Secret: testSecret})
+	token, _, err := impersonation.Issue(1, 2, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = impersonation.Authenticate(request(map[string]string{"Authorization": "Bearer " + token}))
+	if !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestSessions_RoundTrip(t *testing.T) {
+	sessions := NewSessions(testSecret, HeaderAuthenticator{})
+	token, expiresAt, err := sessions.Issue(7, SessionTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(expiresAt) <= 0 {
+		t.Fatalf("expires at %v", expiresAt)
+	}
+
+	identity, err := sessions.Authenticate(request(map[string]string{"Authorization": "Bearer " + token}))
+	if err != nil || identity == nil || identity.UserID != 7 || identity.Method != ModeSession {
+		t.Fatalf("identity = %+v, err = %v", identity, err)
+	}
+
+	// Requests without a session token are left to the configured mode.
+	identity, err = sessions.Authenticate(request(map[string]string{"X-User-ID": "5"}))
+	if err != nil || identity == nil || identity.UserID != 5 || identity.Method == ModeSession {
+		t.Fatalf("delegated identity = %+v, err = %v", identity, err)
+	}
+}
+
+func TestSessions_TokensAreNotOtherTokens(t *testing.T) {
+	sessions := NewSessions(testSecret, nil)
+	token, _, err := sessions.Issue(2, SessionTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	impersonation := NewImpersonation(testSecret, nil)
+	if identity, _ := impersonation.Authenticate(request(map[string]string{"Authorization": "Bearer " + token})); identity != nil {
+		t.Fatalf("session token accepted as impersonation: %+v", identity)
+	}
+
+	impersonating, _, _ := impersonation.Issue(1, 2, ImpersonationTTL)
+	if identity, _ := sessions.Authenticate(request(map[string]string{"Authorization": "Bearer " + impersonating})); identity != nil {
+		t.Fatalf("impersonation token accepted as session: %+v", identity)
+	}
+}
+
+func TestChallenges(t *testing.T) {
+	challenges := NewChallenges(testSecret)
+	token, _, err := challenges.Issue(9, ChallengeTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID, err := challenges.Verify(token); err != nil || userID != 9 {
+		t.Fatalf("userID = %d, err = %v", userID, err)
+	}
+
+	// A challenge is not a session.
+	sessions := NewSessions(testSecret, nil)
+	if identity, _ := sessions.Authenticate(request(map[string]string{"Authorization": "Bearer " + token})); identity != nil {
+		t.Fatalf("challenge accepted as a session: %+v", identity)
+	}
+
+	expired, _, _ := challenges.Issue(9, -time.Minute)
+	if _, err := challenges.Verify(expired); !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Fatalf("expired challenge: err = %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+)
+
+// ChallengeTTL is how long a login waits for its second factor.
+const ChallengeTTL = 5 * time.Minute
+
+const challengeIssuer = "users-crud/2fa-challenge"
+
+// Challenges issues the tokens that carry a login from the password to the
+// second factor. They are never accepted as credentials.
+type Challenges struct {
+	key []byte
+}
+
+// NewChallenges derives the signing key from secret, so challenge tokens
+// never validate as any other token signed with it.
+func NewChallenges(secret []byte) Challenges {
+	return Challenges{key: deriveKey(secret, challengeIssuer)}
+}
+
+// Issue signs a challenge for userID.
+func (c Challenges) Issue(userID uint, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	token, err := signJWT(c.key, jwtClaims{
+		Subject:   formatUserID(userID),
+		Issuer:    challengeIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	return token, expiresAt, err
+}
+
+// Verify returns the user a valid, unexpired challenge was issued for.
+func (c Challenges) Verify(token string) (uint, error) {
+	claims, err := verifyJWT(c.key, challengeIssuer, token, time.Now())
+	if err != nil || claims.Actor != nil {
+		return 0, common.ErrInvalidCredentials
+	}
+	userID, err := parseUserID(claims.Subject)
+	if err != nil {
+		return 0, common.ErrInvalidCredentials
+	}
+	return userID, nil
+}
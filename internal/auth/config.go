@@ -0,0 +1,85 @@
+// Package auth implements the authenticators selectable with AUTH_MODE.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"meu-treino-golang/users-crud/internal/common"
+)
+
+// Authentication modes accepted in AUTH_MODE.
+const (
+	ModeJWT    = "jwt"
+	ModeAPIKey = "api_key"
+	ModeHeader = "header"
+	ModeNone   = "none"
+)
+
+// EnvProduction is the APP_ENV value that refuses insecure authenticators.
+const EnvProduction = common.EnvProduction
+
+// Config selects and configures an authenticator.
+type Config struct {
+	Mode        string
+	Environment string
+	JWTSecret   []byte
+	JWTIssuer   string
+	APIKeys     string
+}
+
+// ConfigFromEnv reads AUTH_MODE, APP_ENV, JWT_SECRET, JWT_ISSUER and
+// API_KEYS. AUTH_MODE defaults to none.
+func ConfigFromEnv() Config {
+	mode := os.Getenv("AUTH_MODE")
+	if mode == "" {
+		mode = ModeNone
+	}
+	return Config{
+		Mode:        mode,
+		Environment: os.Getenv("APP_ENV"),
+		JWTSecret:   []byte(os.Getenv("JWT_SECRET")),
+		JWTIssuer:   os.Getenv("JWT_ISSUER"),
+		APIKeys:     os.Getenv("API_KEYS"),
+	}
+}
+
+// New builds the authenticator for cfg. It refuses insecure modes when the
+// environment is production, and warns about them when no environment is
+// set.
+func New(cfg Config) (common.Authenticator, error) {
+	var authenticator common.Authenticator
+	switch cfg.Mode {
+	case ModeJWT:
+		if len(cfg.JWTSecret) < 32 {
+			return nil, errors.New("JWT_SECRET must be at least 32 bytes")
+		}
+		authenticator = JWTAuthenticator{Secret: cfg.JWTSecret, Issuer: cfg.JWTIssuer}
+	case ModeAPIKey:
+		keys, err := ParseAPIKeys(cfg.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 {
+			return nil, errors.New("API_KEYS must list at least one key=userID pair")
+		}
+		authenticator = NewAPIKeyAuthenticator(keys)
+	case ModeHeader:
+		authenticator = HeaderAuthenticator{}
+	case ModeNone:
+		authenticator = common.NoAuthenticator{}
+	default:
+		return nil, fmt.Errorf("unknown AUTH_MODE %q", cfg.Mode)
+	}
+
+	if cfg.Environment == EnvProduction && authenticator.Insecure() {
+		return nil, fmt.Errorf("AUTH_MODE=%s is not allowed when APP_ENV=%s", cfg.Mode, EnvProduction)
+	}
+	if cfg.Environment == "" && authenticator.Insecure() {
+		log.Printf("WARNING: APP_ENV is not set and AUTH_MODE=%s does not verify who is calling. "+
+			"Running in development mode; set APP_ENV=%s and a secure AUTH_MODE before exposing this server.", cfg.Mode, EnvProduction)
+	}
+	return authenticator, nil
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"meu-treino-golang/users-crud/internal/common"
+)
+
+// HeaderAuthenticator trusts the user ID in the X-User-ID header. Anyone can
+// send it, so it exists for local development and tests only and must be
+// enabled explicitly.
+type HeaderAuthenticator struct{}
+
+func (HeaderAuthenticator) Authenticate(r *http.Request) (*common.Identity, error) {
+	value := r.Header.Get("X-User-ID")
+	if value == "" {
+		return nil, nil
+	}
+
+	userID, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || userID == 0 {
+		return nil, common.ErrInvalidCredentials
+	}
+	return &common.Identity{UserID: uint(userID), Method: ModeHeader}, nil
+}
+
+func (HeaderAuthenticator) Insecure() bool {
+	return true
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+)
+
+// ModeImpersonation is the method of identities established from an
+// impersonation token.
+const ModeImpersonation = "impersonation"
+
+// ImpersonationTTL is how long an impersonation token lasts.
+const ImpersonationTTL = 15 * time.Minute
+
+const impersonationIssuer = "users-crud/impersonation"
+
+// Impersonation issues short-lived tokens that let a platform admin act as
+// another user, and accepts them as "Authorization: Bearer <token>" in any
+// AUTH_MODE. Requests without such a token are passed on to Next.
+type Impersonation struct {
+	key  []byte
+	Next common.Authenticator
+}
+
+// NewImpersonation derives the signing key from secret, so impersonation
+// tokens never validate as any other token signed with it.
+func NewImpersonation(secret []byte, next common.Authenticator) Impersonation {
+	return Impersonation{key: deriveKey(secret, impersonationIssuer), Next: next}
+}
+
+// Issue signs a token that makes actorID act as subjectID until it expires.
+func (i Impersonation) Issue(actorID, subjectID uint, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	token, err := signJWT(i.key, jwtClaims{
+		Subject:   formatUserID(subjectID),
+		Issuer:    impersonationIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+		Actor:     &jwtActor{Subject: formatUserID(actorID)},
+	})
+	return token, expiresAt, err
+}
+
+func (i Impersonation) Authenticate(r *http.Request) (*common.Identity, error) {
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		claims, err := verifyJWT(i.key, impersonationIssuer, strings.TrimSpace(token), time.Now())
+		if err == nil && claims.Actor != nil {
+			subjectID, err := parseUserID(claims.Subject)
+			if err != nil {
+				return nil, common.ErrInvalidCredentials
+			}
+			actorID, err := parseUserID(claims.Actor.Subject)
+			if err != nil {
+				return nil, common.ErrInvalidCredentials
+			}
+			return &common.Identity{UserID: subjectID, ActorID: actorID, Method: ModeImpersonation}, nil
+		}
+	}
+
+	if i.Next == nil {
+		return nil, nil
+	}
+	return i.Next.Authenticate(r)
+}
+
+func (i Impersonation) Insecure() bool {
+	return i.Next != nil && i.Next.Insecure()
+}
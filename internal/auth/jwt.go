@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+)
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// JWTAuthenticator accepts HS256 JSON Web Tokens sent as
+// "Authorization: Bearer <token>". The subject claim holds the user ID and
+// the expiry claim is required.
+type JWTAuthenticator struct {
+	Secret []byte
+	// Issuer, when set, must match the iss claim.
+	Issuer string
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+	// Actor is the RFC 8693 "act" claim: who is acting as the subject.
+	Actor *jwtActor `json:"act,omitempty"`
+}
+
+type jwtActor struct {
+	Subject string `json:"sub"`
+}
+
+func (a JWTAuthenticator) Authenticate(r *http.Request) (*common.Identity, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return nil, common.ErrInvalidCredentials
+	}
+
+	claims, err := verifyJWT(a.Secret, a.Issuer, strings.TrimSpace(token), time.Now())
+	// Impersonation tokens are only accepted by Impersonation, which keeps
+	// track of the actor.
+	if err != nil || claims.Actor != nil {
+		return nil, common.ErrInvalidCredentials
+	}
+	userID, err := parseUserID(claims.Subject)
+	if err != nil {
+		return nil, common.ErrInvalidCredentials
+	}
+	return &common.Identity{UserID: userID, Method: ModeJWT}, nil
+}
+
+func (JWTAuthenticator) Insecure() bool {
+	return false
+}
+
+// IssueToken signs a token for userID that expires after ttl.
+func (a JWTAuthenticator) IssueToken(userID uint, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return signJWT(a.Secret, jwtClaims{
+		Subject:   formatUserID(userID),
+		Issuer:    a.Issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+}
+
+func signJWT(secret []byte, claims jwtClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + hs256(secret, signingInput), nil
+}
+
+// verifyJWT checks the signature and the time and issuer claims of a token.
+// Only HS256 is accepted, whatever the header claims. An empty issuer skips
+// the issuer check.
+func verifyJWT(secret []byte, issuer, token string, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, errors.New("unsupported algorithm")
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(hs256(secret, signingInput))) {
+		return nil, errors.New("invalid signature")
+	}
+
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return nil, err
+	}
+
+	if claims.ExpiresAt == 0 || now.Unix() >= claims.ExpiresAt {
+		return nil, errors.New("token expired")
+	}
+	if claims.NotBefore != 0 && now.Unix() < claims.NotBefore {
+		return nil, errors.New("token not yet valid")
+	}
+	if issuer != "" && claims.Issuer != issuer {
+		return nil, errors.New("unexpected issuer")
+	}
+	return &claims, nil
+}
+
+func hs256(secret []byte, signingInput string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// deriveKey derives a signing key for one kind of token from secret, so
+// tokens of one kind never validate as another.
+func deriveKey(secret []byte, label string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+func formatUserID(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+func parseUserID(value string) (uint, error) {
+	userID, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if userID == 0 {
+		return 0, errors.New("user ID cannot be zero")
+	}
+	return uint(userID), nil
+}
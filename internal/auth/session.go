@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+)
+
+// ModeSession is the method of identities established from a token issued
+// by password login.
+const ModeSession = "session"
+
+// SessionTTL is how long a login session token lasts.
+const SessionTTL = 12 * time.Hour
+
+const sessionIssuer = "users-crud/session"
+
+// Sessions issues the tokens returned by login and accepts them as
+// "Authorization: Bearer <token>" in any AUTH_MODE. Requests without such a
+// token are passed on to Next.
+type Sessions struct {
+	key  []byte
+	Next common.Authenticator
+}
+
+// NewSessions derives the signing key from secret, so session tokens never
+// validate as any other token signed with it.
+func NewSessions(secret []byte, next common.Authenticator) Sessions {
+	return Sessions{key: deriveKey(secret, sessionIssuer), Next: next}
+}
+
+// Issue signs a session token for userID.
+func (s Sessions) Issue(userID uint, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	token, err := signJWT(s.key, jwtClaims{
+		Subject:   formatUserID(userID),
+		Issuer:    sessionIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	return token, expiresAt, err
+}
+
+func (s Sessions) Authenticate(r *http.Request) (*common.Identity, error) {
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		claims, err := verifyJWT(s.key, sessionIssuer, strings.TrimSpace(token), time.Now())
+		if err == nil && claims.Actor == nil {
+			userID, err := parseUserID(claims.Subject)
+			if err != nil {
+				return nil, common.ErrInvalidCredentials
+			}
+			return &common.Identity{UserID: userID, Method: ModeSession}, nil
+		}
+	}
+
+	if s.Next == nil {
+		return nil, nil
+	}
+	return s.Next.Authenticate(r)
+}
+
+func (s Sessions) Insecure() bool {
+	return s.Next != nil && s.Next.Insecure()
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrInvalidCredentials is returned by an Authenticator when a request
+// carries credentials that do not check out.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	// UserID is who the request acts as; permissions are evaluated for it.
+	UserID uint
+	// ActorID is the platform admin behind an impersonated request, zero
+	// otherwise.
+	ActorID uint
+	// Method names the authenticator that established the identity.
+	Method string
+}
+
+// Impersonated reports whether someone else is acting as UserID.
+func (i Identity) Impersonated() bool {
+	return i.ActorID != 0
+}
+
+// Authenticator establishes who is calling. It returns nil and no error for
+// requests without credentials, which then proceed anonymously.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+	// Insecure reports whether the authenticator accepts callers without
+	// verifying them. Insecure authenticators are refused in production.
+	Insecure() bool
+}
+
+// NoAuthenticator treats every request as anonymous. Routes that require
+// an identity reject everything, so it is only useful for local work on
+// public routes.
+type NoAuthenticator struct{}
+
+func (NoAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	return nil, nil
+}
+
+func (NoAuthenticator) Insecure() bool {
+	return true
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context carrying the caller's identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns the identity stored by WithIdentity.
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok && identity.UserID != 0
+}
@@ -1,13 +1,78 @@
 package common
 
 import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"os"
+
+	"meu-treino-golang/users-crud/internal/oidc"
+	"meu-treino-golang/users-crud/internal/ratelimit"
+
 	"gorm.io/gorm"
 )
 
+// EnvProduction is the APP_ENV value that refuses insecure configuration.
+const EnvProduction = "production"
+
+// minTokenSecretLength is the shortest TOKEN_SECRET accepted in production.
+const minTokenSecretLength = 32
+
 type Dependencies struct {
-	DB *gorm.DB
+	DB             *gorm.DB
+	Mailer         Mailer
+	Verifier       DomainVerifier
+	Authenticator  Authenticator
+	RateLimitStore ratelimit.Store
+	OIDC           *oidc.Client
+	TokenSecret    []byte
+	// Environment is APP_ENV; Load reads it when it is empty.
+	Environment string
+	// TrustedProxies may name the client in X-Forwarded-For. None are
+	// trusted by default.
+	TrustedProxies []string
 }
 
+// Load fills in dependencies that were not provided explicitly. In
+// production it fails without a TOKEN_SECRET of at least 32 bytes.
 func (d *Dependencies) Load() error {
+	if d.Mailer == nil {
+		d.Mailer = LogMailer{}
+	}
+	if d.Verifier == nil {
+		d.Verifier = DNSVerifier{}
+	}
+	if d.Authenticator == nil {
+		d.Authenticator = NoAuthenticator{}
+	}
+	if d.RateLimitStore == nil {
+		d.RateLimitStore = ratelimit.NewMemoryStore()
+	}
+	if d.OIDC == nil {
+		d.OIDC = oidc.NewClient(nil)
+	}
+
+	if d.Environment == "" {
+		d.Environment = os.Getenv("APP_ENV")
+	}
+
+	if len(d.TokenSecret) == 0 {
+		if secret := os.Getenv("TOKEN_SECRET"); secret != "" {
+			d.TokenSecret = []byte(secret)
+		} else if d.Environment == EnvProduction {
+			return fmt.Errorf("TOKEN_SECRET must be set when APP_ENV=%s", EnvProduction)
+		} else {
+			// Tokens signed with a random secret stop validating on restart.
+			log.Println("TOKEN_SECRET not set. Using a random secret for this process.")
+			d.TokenSecret = make([]byte, 32)
+			if _, err := rand.Read(d.TokenSecret); err != nil {
+				return err
+			}
+		}
+	}
+	if d.Environment == EnvProduction && len(d.TokenSecret) < minTokenSecretLength {
+		return fmt.Errorf("TOKEN_SECRET must be at least %d bytes when APP_ENV=%s", minTokenSecretLength, EnvProduction)
+	}
+
 	return nil
 }
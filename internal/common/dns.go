@@ -0,0 +1,42 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// DomainVerifier checks DNS records that prove control of a domain.
+type DomainVerifier interface {
+	HasTXTRecord(ctx context.Context, name, value string) (bool, error)
+}
+
+// DNSVerifier looks TXT records up with a DNS resolver. A nil Resolver uses
+// the system resolver.
+type DNSVerifier struct {
+	Resolver *net.Resolver
+}
+
+func (v DNSVerifier) HasTXTRecord(ctx context.Context, name, value string) (bool, error) {
+	resolver := v.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	records, err := resolver.LookupTXT(ctx, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, record := range records {
+		if strings.TrimSpace(record) == value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -1,10 +1,44 @@
 package common
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrUserNotFound   = errors.New("user not found")
 	ErrInvalidInput   = errors.New("invalid input")
 	ErrDuplicateEmail = errors.New("email already exists")
 	ErrInternalServer = errors.New("internal server error")
+
+	ErrImpersonateSelf     = errors.New("cannot impersonate yourself")
+	ErrImpersonateAdmin    = errors.New("cannot impersonate a platform admin")
+	ErrImpersonationDenied = errors.New("operation not allowed while impersonating")
+
+	ErrLoginThrottled = errors.New("too many failed login attempts")
+
+	ErrInvalidEmailVerification = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotPending = errors.New("no two-factor enrollment to confirm")
+	ErrInvalidTwoFactor    = errors.New("invalid two-factor code")
 )
+
+// LoginThrottledError is returned when logins for an account or from a
+// client are held back after repeated failures. It says the same whether
+// the account exists or not.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrLoginThrottled, e.RetryAfter.Round(time.Second))
+}
+
+// Is lets callers match the error with errors.Is(err, ErrLoginThrottled).
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrLoginThrottled
+}
@@ -1,7 +1,30 @@
 package common
 
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
 type Pagination struct {
 	Page  int   `json:"page"`
 	Limit int   `json:"limit"`
 	Total int64 `json:"total"`
 }
+
+// Normalize applies the default page and limit and caps the limit.
+func (p *Pagination) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+}
+
+// Offset returns the number of rows to skip for the current page.
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
@@ -0,0 +1,25 @@
+package common
+
+import "context"
+
+// RequestInfo describes where a request came from. It is recorded alongside
+// the changes the request makes.
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context carrying the request's metadata.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the metadata stored by WithRequestInfo, or the
+// zero value for work that did not start from a request.
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
@@ -0,0 +1,243 @@
+// Package oidc implements the relying party side of OpenID Connect: the
+// authorization code flow with PKCE and ID token verification. Only RS256
+// signed ID tokens are accepted.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrProvider wraps failures to reach the identity provider or to
+	// understand its answers.
+	ErrProvider = errors.New("identity provider request failed")
+	// ErrInvalidIDToken is returned for ID tokens that fail verification.
+	ErrInvalidIDToken = errors.New("invalid ID token")
+)
+
+const (
+	// discoveryTTL is how long a discovery document is reused.
+	discoveryTTL = time.Hour
+	// maxResponseBytes caps what is read from the identity provider.
+	maxResponseBytes = 1 << 20
+)
+
+// Config identifies a relying party registered with an identity provider.
+// An empty ClientSecret makes a public client, which relies on PKCE alone.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// Provider holds the endpoints of an identity provider, as published in its
+// discovery document.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type cachedProvider struct {
+	provider  *Provider
+	fetchedAt time.Time
+}
+
+// Client talks to identity providers. It caches their discovery documents
+// and signing keys, and is safe for concurrent use.
+type Client struct {
+	http *http.Client
+	now  func() time.Time
+
+	mu        sync.Mutex
+	providers map[string]cachedProvider
+	keys      map[string]*keySet
+}
+
+// NewClient returns a client that makes its requests with httpClient, or
+// with a client with a 10 second timeout when httpClient is nil.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{
+		http:      httpClient,
+		now:       time.Now,
+		providers: map[string]cachedProvider{},
+		keys:      map[string]*keySet{},
+	}
+}
+
+// Discover returns the endpoints of issuer. The discovery document must
+// name issuer exactly, as OpenID Connect Discovery requires.
+func (c *Client) Discover(ctx context.Context, issuer string) (*Provider, error) {
+	c.mu.Lock()
+	cached, ok := c.providers[issuer]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetchedAt) < discoveryTTL {
+		return cached.provider, nil
+	}
+
+	var provider Provider
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := c.getJSON(ctx, wellKnown, &provider); err != nil {
+		return nil, err
+	}
+	if provider.Issuer != issuer {
+		return nil, fmt.Errorf("%w: discovery document names issuer %q", ErrProvider, provider.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document of %s lacks an endpoint", ErrProvider, issuer)
+	}
+
+	c.mu.Lock()
+	c.providers[issuer] = cachedProvider{provider: &provider, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return &provider, nil
+}
+
+// AuthCodeURL returns the authorization endpoint URL to send the user to.
+// state and nonce are echoed back in the redirect and the ID token;
+// challenge is the PKCE code challenge of the verifier later passed to
+// Exchange.
+func (c *Client) AuthCodeURL(ctx context.Context, cfg Config, state, nonce, challenge string) (string, error) {
+	provider, err := c.Discover(ctx, cfg.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, err := url.Parse(provider.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	query := endpoint.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", cfg.ClientID)
+	query.Set("redirect_uri", cfg.RedirectURL)
+	query.Set("scope", "openid email profile")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", "S256")
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified claims
+// of the ID token that came with it. nonce must be the one passed to
+// AuthCodeURL.
+func (c *Client) Exchange(ctx context.Context, cfg Config, code, verifier, nonce string) (*Claims, error) {
+	provider, err := c.Discover(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	if cfg.ClientSecret == "" {
+		form.Set("client_id", cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := c.do(req, &token)
+	if err != nil {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("%w: token endpoint answered %s: %s", ErrProvider, token.Error, token.ErrorDescription)
+	}
+	if status != http.StatusOK || token.IDToken == "" {
+		return nil, fmt.Errorf("%w: token endpoint answered %d without an ID token", ErrProvider, status)
+	}
+
+	return c.Verify(ctx, cfg, token.IDToken, nonce)
+}
+
+// NewPKCE returns a fresh PKCE code verifier and its S256 code challenge.
+func NewPKCE() (verifier, challenge string, err error) {
+	verifier, err = RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// RandomString returns 32 random bytes encoded for use in URLs, suitable
+// for states, nonces and PKCE verifiers.
+func RandomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (c *Client) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	status, err := c.do(req, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("%w: %s answered %d", ErrProvider, endpoint, status)
+	}
+	return nil
+}
+
+// do sends req and decodes the JSON body into v, whatever the status.
+func (c *Client) do(req *http.Request, v interface{}) (int, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, fmt.Errorf("%w: %s answered %d", ErrProvider, req.URL.Redacted(), resp.StatusCode)
+		}
+		return resp.StatusCode, fmt.Errorf("%w: %s answered with invalid JSON", ErrProvider, req.URL.Redacted())
+	}
+	return resp.StatusCode, nil
+}
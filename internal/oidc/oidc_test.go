@@ -0,0 +1,196 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/oidc"
+	"meu-treino-golang/users-crud/internal/oidc/oidctest"
+)
+
+const redirectURL = "https://app.example.com/api/auth/sso/acme/callback"
+
+func newTestProvider(t *testing.T, secret string) (*oidctest.Provider, oidc.Config) {
+	t.Helper()
+	provider := oidctest.NewProvider(t, "users-crud", secret)
+	provider.User = oidctest.User{
+		Subject:       "alice-1",
+		Email:         "alice@acme.com",
+		EmailVerified: true,
+		Name:          "Alice",
+		Groups:        []string{"engineering", "admins"},
+	}
+	cfg := oidc.Config{Issuer: provider.Issuer, ClientID: "users-crud", ClientSecret: secret, RedirectURL: redirectURL}
+	return provider, cfg
+}
+
+// signIn runs the authorization code flow up to the redirect back and
+// returns the code and the state.
+func signIn(t *testing.T, client *oidc.Client, provider *oidctest.Provider, cfg oidc.Config, state, nonce, challenge string) string {
+	t.Helper()
+	authURL, err := client.AuthCodeURL(context.Background(), cfg, state, nonce, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := provider.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(back.String(), redirectURL+"?") || back.Query().Get("state") != state {
+		t.Fatalf("redirected back to %s", back)
+	}
+	return back.Query().Get("code")
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	for name, secret := range map[string]string{"confidential": "s3cret/+=", "public": ""} {
+		t.Run(name, func(t *testing.T) {
+			provider, cfg := newTestProvider(t, secret)
+			client := oidc.NewClient(nil)
+
+			verifier, challenge, err := oidc.NewPKCE()
+			if err != nil {
+				t.Fatal(err)
+			}
+			code := signIn(t, client, provider, cfg, "state-1", "nonce-1", challenge)
+
+			claims, err := client.Exchange(context.Background(), cfg, code, verifier, "nonce-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != "alice-1" || claims.Email != "alice@acme.com" || !claims.EmailVerified || claims.Name != "Alice" {
+				t.Fatalf("claims = %+v", claims)
+			}
+			if groups := claims.Strings("groups"); !slices.Equal(groups, []string{"engineering", "admins"}) {
+				t.Fatalf("groups = %v", groups)
+			}
+
+			// Codes are single use.
+			if _, err := client.Exchange(context.Background(), cfg, code, verifier, "nonce-1"); !errors.Is(err, oidc.ErrProvider) {
+				t.Fatalf("reused code: err = %v", err)
+			}
+		})
+	}
+}
+
+func TestExchange_RequiresTheVerifier(t *testing.T) {
+	provider, cfg := newTestProvider(t, "secret")
+	client := oidc.NewClient(nil)
+
+	_, challenge, _ := oidc.NewPKCE()
+	otherVerifier, _, _ := oidc.NewPKCE()
+	code := signIn(t, client, provider, cfg, "state", "nonce", challenge)
+
+	if _, err := client.Exchange(context.Background(), cfg, code, otherVerifier, "nonce"); !errors.Is(err, oidc.ErrProvider) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	provider, cfg := newTestProvider(t, "secret")
+	authURL, err := oidc.NewClient(nil).AuthCodeURL(context.Background(), cfg, "the-state", "the-nonce", "the-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL, provider.Issuer+"/authorize?") {
+		t.Fatalf("url = %s", authURL)
+	}
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "users-crud",
+		"redirect_uri":          redirectURL,
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        "the-challenge",
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := parsed.Query().Get(name); got != value {
+			t.Fatalf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestDiscover_RejectsAnotherIssuer(t *testing.T) {
+	provider, _ := newTestProvider(t, "secret")
+	if _, err := oidc.NewClient(nil).Discover(context.Background(), provider.Issuer+"/"); !errors.Is(err, oidc.ErrProvider) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	provider, cfg := newTestProvider(t, "secret")
+	other := oidctest.NewProvider(t, "users-crud", "secret")
+	client := oidc.NewClient(nil)
+
+	valid := func() map[string]interface{} {
+		return provider.Claims(provider.User, "nonce")
+	}
+	with := func(name string, value interface{}) string {
+		claims := valid()
+		claims[name] = value
+		return provider.SignToken(claims)
+	}
+
+	token := provider.SignToken(valid())
+	parts := strings.Split(token, ".")
+	cases := map[string]string{
+		"wrong issuer":     with("iss", "https://evil.example.com"),
+		"wrong audience":   with("aud", "another-client"),
+		"foreign azp":      with("azp", "another-client"),
+		"expired":          with("exp", time.Now().Add(-time.Hour).Unix()),
+		"issued later":     with("iat", time.Now().Add(time.Hour).Unix()),
+		"wrong nonce":      with("nonce", "replayed"),
+		"no subject":       with("sub", ""),
+		"other signer":     other.SignToken(valid()),
+		"alg none":         "eyJhbGciOiJub25lIn0." + parts[1] + ".",
+		"tampered payload": parts[0] + "." + strings.Split(with("sub", "mallory"), ".")[1] + "." + parts[2],
+		"garbage":          "not-a-token",
+	}
+	// Several audiences need an authorized party naming the client.
+	multiple := valid()
+	multiple["aud"] = []string{"users-crud", "another-client"}
+	cases["multiple audiences without azp"] = provider.SignToken(multiple)
+
+	for name, token := range cases {
+		if _, err := client.Verify(context.Background(), cfg, token, "nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+			t.Fatalf("%s: err = %v", name, err)
+		}
+	}
+
+	if _, err := client.Verify(context.Background(), cfg, token, "nonce"); err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+	multiple["azp"] = "users-crud"
+	if _, err := client.Verify(context.Background(), cfg, provider.SignToken(multiple), "nonce"); err != nil {
+		t.Fatalf("several audiences with azp: %v", err)
+	}
+}
+
+func TestVerify_StringClaims(t *testing.T) {
+	provider, cfg := newTestProvider(t, "secret")
+	claims := provider.Claims(provider.User, "nonce")
+	claims["email_verified"] = "true"
+	claims["groups"] = "admins"
+
+	verified, err := oidc.NewClient(nil).Verify(context.Background(), cfg, provider.SignToken(claims), "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verified.EmailVerified || !slices.Equal(verified.Strings("groups"), []string{"admins"}) {
+		t.Fatalf("claims = %+v, groups = %v", verified, verified.Strings("groups"))
+	}
+	if verified.Strings("roles") != nil {
+		t.Fatal("missing claim is not empty")
+	}
+}
@@ -0,0 +1,266 @@
+// Package oidctest runs an in-process OpenID Connect identity provider for
+// tests. It implements discovery, the authorization endpoint, the token
+// endpoint with PKCE and a JWKS endpoint, and signs ID tokens with RS256.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// KeyID names the provider's signing key.
+const KeyID = "oidctest"
+
+// User is who the provider signs in at its authorization endpoint.
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+}
+
+type authorization struct {
+	user        User
+	clientID    string
+	redirectURI string
+	nonce       string
+	challenge   string
+}
+
+// Provider is a running identity provider. Its Issuer is the URL of an
+// httptest server that is closed when the test ends.
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+
+	// User is signed in by the authorization endpoint.
+	User User
+	// ModifyClaims, when set, may change the claims of each ID token
+	// before it is signed.
+	ModifyClaims func(claims map[string]interface{})
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+// NewProvider starts a provider with one registered client. An empty
+// clientSecret registers a public client.
+func NewProvider(t testing.TB, clientID, clientSecret string) *Provider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Provider{ClientID: clientID, ClientSecret: clientSecret, key: key, codes: map[string]authorization{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+	mux.HandleFunc("GET /jwks", p.jwks)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	p.Issuer = server.URL
+	return p
+}
+
+// Authorize follows an authorization URL as the user's browser would and
+// returns where the provider redirects back to.
+func (p *Provider) Authorize(authURL string) (*url.URL, error) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("authorization endpoint answered %d", resp.StatusCode)
+	}
+	return resp.Location()
+}
+
+// SignToken signs claims as an ID token with the provider's key.
+func (p *Provider) SignToken(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": KeyID})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// Claims returns the ID token claims the provider issues for user.
+func (p *Provider) Claims(user User, nonce string) map[string]interface{} {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":            p.Issuer,
+		"sub":            user.Subject,
+		"aud":            p.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+	}
+	if user.Groups != nil {
+		claims["groups"] = user.Groups
+	}
+	return claims
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	switch {
+	case query.Get("client_id") != p.ClientID:
+		http.Error(w, "unknown client", http.StatusBadRequest)
+		return
+	case err != nil || !redirectURI.IsAbs():
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	case query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" || !strings.Contains(query.Get("scope"), "openid"):
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = authorization{
+		user:        p.User,
+		clientID:    p.ClientID,
+		redirectURI: redirectURI.String(),
+		nonce:       query.Get("nonce"),
+		challenge:   query.Get("code_challenge"),
+	}
+	p.mu.Unlock()
+
+	back := redirectURI.Query()
+	back.Set("code", code)
+	back.Set("state", query.Get("state"))
+	redirectURI.RawQuery = back.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	if !p.authenticateClient(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	auth, ok := p.codes[r.PostForm.Get("code")]
+	// Codes are single use, even when the exchange fails.
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code":
+		tokenError(w, "unsupported_grant_type")
+		return
+	case !ok || r.PostForm.Get("redirect_uri") != auth.redirectURI:
+		tokenError(w, "invalid_grant")
+		return
+	case subtle.ConstantTimeCompare([]byte(challenge), []byte(auth.challenge)) != 1:
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	claims := p.Claims(auth.user, auth.nonce)
+	if p.ModifyClaims != nil {
+		p.ModifyClaims(claims)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     p.SignToken(claims),
+	})
+}
+
+// authenticateClient accepts HTTP Basic client credentials, or a client_id
+// alone for public clients.
+func (p *Provider) authenticateClient(r *http.Request) bool {
+	if id, secret, ok := r.BasicAuth(); ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+		return id == p.ClientID && p.ClientSecret != "" && secret == p.ClientSecret
+	}
+	return p.ClientSecret == "" && r.PostForm.Get("client_id") == p.ClientID
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	public := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": KeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
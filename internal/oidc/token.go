@@ -0,0 +1,275 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// clockSkew is how far the identity provider's clock may be off.
+	clockSkew = time.Minute
+	// keysRefreshInterval is the least time between two fetches of a key
+	// set, so tokens with unknown key IDs cannot make us hammer the
+	// identity provider.
+	keysRefreshInterval = time.Minute
+	// keysTTL is how long a key set is trusted before it is fetched again.
+	keysTTL = 24 * time.Hour
+)
+
+// Claims are the verified claims of an ID token that a relying party needs.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+
+	raw map[string]json.RawMessage
+}
+
+// Strings returns a claim holding a string or a list of strings, such as
+// a groups claim. Claims that are missing or of another type are empty.
+func (c *Claims) Strings(name string) []string {
+	value, ok := c.raw[name]
+	if !ok {
+		return nil
+	}
+	var list []string
+	if json.Unmarshal(value, &list) == nil {
+		return list
+	}
+	var single string
+	if json.Unmarshal(value, &single) == nil && single != "" {
+		return []string{single}
+	}
+	return nil
+}
+
+// audience is the aud claim, which is a string or a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// flexibleBool is a boolean claim that some identity providers send as a
+// string.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if json.Unmarshal(data, &value) == nil {
+		*b = flexibleBool(value)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*b = flexibleBool(text == "true")
+	return nil
+}
+
+type idTokenClaims struct {
+	Issuer          string       `json:"iss"`
+	Subject         string       `json:"sub"`
+	Audience        audience     `json:"aud"`
+	AuthorizedParty string       `json:"azp"`
+	ExpiresAt       float64      `json:"exp"`
+	IssuedAt        float64      `json:"iat"`
+	Nonce           string       `json:"nonce"`
+	Email           string       `json:"email"`
+	EmailVerified   flexibleBool `json:"email_verified"`
+	Name            string       `json:"name"`
+}
+
+// Verify checks the signature and the claims of a raw ID token issued to
+// cfg.ClientID, and that it carries nonce.
+func (c *Client) Verify(ctx context.Context, cfg Config, rawIDToken, nonce string) (*Claims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidIDToken)
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Algorithm != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidIDToken, header.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidIDToken)
+	}
+	provider, err := c.Discover(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	key, err := c.signingKey(ctx, provider.JWKSURI, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidIDToken)
+	}
+
+	var claims idTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, err
+	}
+	if err := checkClaims(claims, cfg, nonce, c.now()); err != nil {
+		return nil, err
+	}
+
+	return &Claims{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		raw:           raw,
+	}, nil
+}
+
+// checkClaims applies the ID token validation rules of OpenID Connect Core
+// 3.1.3.7 that do not concern the signature.
+func checkClaims(claims idTokenClaims, cfg Config, nonce string, now time.Time) error {
+	switch {
+	case claims.Issuer != cfg.Issuer:
+		return fmt.Errorf("%w: issued by %q", ErrInvalidIDToken, claims.Issuer)
+	case claims.Subject == "":
+		return fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	case !slices.Contains(claims.Audience, cfg.ClientID):
+		return fmt.Errorf("%w: issued to another client", ErrInvalidIDToken)
+	case (len(claims.Audience) > 1 || claims.AuthorizedParty != "") && claims.AuthorizedParty != cfg.ClientID:
+		return fmt.Errorf("%w: authorized party is %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	case claims.ExpiresAt == 0 || now.After(time.Unix(int64(claims.ExpiresAt), 0).Add(clockSkew)):
+		return fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	case time.Unix(int64(claims.IssuedAt), 0).After(now.Add(clockSkew)):
+		return fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	case nonce == "" || claims.Nonce != nonce:
+		return fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidIDToken)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidIDToken)
+	}
+	return nil
+}
+
+// keySet is the RSA signing keys of a JWKS URI, by key ID.
+type keySet struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// signingKey returns the key kid of the key set at jwksURI. Unknown key
+// IDs refetch the key set, since providers rotate keys. A token without a
+// key ID is accepted when the set has a single key.
+func (c *Client) signingKey(ctx context.Context, jwksURI, kid string) (*rsa.PublicKey, error) {
+	now := c.now()
+	c.mu.Lock()
+	set := c.keys[jwksURI]
+	c.mu.Unlock()
+
+	if set == nil || now.Sub(set.fetchedAt) >= keysTTL {
+		fetched, err := c.fetchKeys(ctx, jwksURI)
+		if err != nil {
+			return nil, err
+		}
+		set = fetched
+	}
+	if key := set.lookup(kid); key != nil {
+		return key, nil
+	}
+
+	if now.Sub(set.fetchedAt) >= keysRefreshInterval {
+		fetched, err := c.fetchKeys(ctx, jwksURI)
+		if err != nil {
+			return nil, err
+		}
+		if key := fetched.lookup(kid); key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, kid)
+}
+
+func (s *keySet) lookup(kid string) *rsa.PublicKey {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key
+		}
+	}
+	return s.keys[kid]
+}
+
+func (c *Client) fetchKeys(ctx context.Context, jwksURI string) (*keySet, error) {
+	var document struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			Use     string `json:"use"`
+			KeyID   string `json:"kid"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := c.getJSON(ctx, jwksURI, &document); err != nil {
+		return nil, err
+	}
+
+	set := &keySet{keys: map[string]*rsa.PublicKey{}, fetchedAt: c.now()}
+	for _, jwk := range document.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		set.keys[jwk.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	c.mu.Lock()
+	c.keys[jwksURI] = set
+	c.mu.Unlock()
+	return set, nil
+}
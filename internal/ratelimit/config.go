@@ -0,0 +1,7 @@
+package ratelimit
+
+// Stores accepted in RATE_LIMIT_STORE.
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval spaces out the removal of full buckets.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Limits are per instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]memoryBucket
+	nextSweep time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]memoryBucket{}}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.nextSweep) {
+		s.sweep(now)
+		s.nextSweep = now.Add(sweepInterval)
+	}
+
+	var current *Bucket
+	if stored, ok := s.buckets[key]; ok {
+		current = &stored.Bucket
+	}
+	bucket, result := limit.Take(current, now)
+	s.buckets[key] = memoryBucket{Bucket: bucket, expiresAt: now.Add(result.Reset)}
+	return result, nil
+}
+
+// sweep drops the buckets that are full again, which behave like missing
+// ones.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, bucket := range s.buckets {
+		if bucket.expiresAt.Before(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// BucketRepository stores buckets in a database shared by every instance.
+type BucketRepository interface {
+	// UpdateBucket locks the bucket named key and stores the state fn
+	// returns for it, with the time the bucket is full again. fn receives
+	// nil when the bucket does not exist yet and may be called more than
+	// once; only the state of the last call is stored. Concurrent updates
+	// of the same bucket, from any instance, are serialized.
+	UpdateBucket(key string, fn func(bucket *Bucket) (Bucket, time.Time)) error
+	// DeleteExpiredBuckets removes the buckets that are full again at now.
+	DeleteExpiredBuckets(now time.Time) error
+}
+
+// PostgresStore keeps buckets in the database, so every instance sharing it
+// applies the same limits.
+type PostgresStore struct {
+	repo BucketRepository
+
+	mu        sync.Mutex
+	nextSweep time.Time
+}
+
+func NewPostgresStore(repo BucketRepository) *PostgresStore {
+	return &PostgresStore{repo: repo}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.maybeSweep(now)
+
+	var result Result
+	err := s.repo.UpdateBucket(key, func(current *Bucket) (Bucket, time.Time) {
+		var bucket Bucket
+		bucket, result = limit.Take(current, now)
+		return bucket, now.Add(result.Reset)
+	})
+	return result, err
+}
+
+// maybeSweep deletes full buckets in the background, at most once per
+// sweepInterval per instance.
+func (s *PostgresStore) maybeSweep(now time.Time) {
+	s.mu.Lock()
+	due := now.After(s.nextSweep)
+	if due {
+		s.nextSweep = now.Add(sweepInterval)
+	}
+	s.mu.Unlock()
+
+	if due {
+		go func() {
+			if err := s.repo.DeleteExpiredBuckets(now); err != nil {
+				log.Printf("rate limit: sweeping buckets failed: %v", err)
+			}
+		}()
+	}
+}
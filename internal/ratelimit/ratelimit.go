@@ -0,0 +1,83 @@
+// Package ratelimit implements token bucket rate limiting with pluggable
+// storage for the buckets.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Burst requests at once and refills the bucket at Burst
+// requests per Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Burst > 0 && l.Period > 0
+}
+
+// rate returns the number of tokens added per second.
+func (l Limit) rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// Bucket is the stored state of a token bucket.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is the number of whole tokens left.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until a token is available, when Allowed is
+	// false.
+	RetryAfter time.Duration
+}
+
+// Take refills bucket for the time elapsed since its last update and takes
+// one token from it when available. A nil bucket starts full. It returns the
+// new state of the bucket.
+func (l Limit) Take(bucket *Bucket, now time.Time) (Bucket, Result) {
+	rate := l.rate()
+	burst := float64(l.Burst)
+
+	tokens := burst
+	if bucket != nil {
+		elapsed := now.Sub(bucket.UpdatedAt).Seconds()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		tokens = math.Min(burst, bucket.Tokens+elapsed*rate)
+	}
+
+	result := Result{Limit: l.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((burst - tokens) / rate)
+	return Bucket{Tokens: tokens, UpdatedAt: now}, result
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
+
+// Store keeps token buckets by key.
+type Store interface {
+	// Take takes a token from the bucket named key under limit.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
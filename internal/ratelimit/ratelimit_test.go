@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimitTake(t *testing.T) {
+	limit := Limit{Burst: 2, Period: 2 * time.Second}
+	now := time.Unix(1000, 0)
+
+	bucket, result := limit.Take(nil, now)
+	if !result.Allowed || result.Remaining != 1 || result.Limit != 2 {
+		t.Fatalf("first request: %+v", result)
+	}
+	if result.Reset != time.Second {
+		t.Fatalf("reset = %v, want 1s", result.Reset)
+	}
+
+	bucket, result = limit.Take(&bucket, now)
+	if !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("second request: %+v", result)
+	}
+
+	bucket, result = limit.Take(&bucket, now)
+	if result.Allowed {
+		t.Fatal("third request within the burst window was allowed")
+	}
+	if result.RetryAfter != time.Second {
+		t.Fatalf("retry after = %v, want 1s", result.RetryAfter)
+	}
+
+	// One token per second comes back.
+	_, result = limit.Take(&bucket, now.Add(time.Second))
+	if !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("after refill: %+v", result)
+	}
+}
+
+func TestLimitTake_CapsAtBurst(t *testing.T) {
+	limit := Limit{Burst: 3, Period: time.Minute}
+	now := time.Unix(1000, 0)
+
+	bucket := Bucket{Tokens: 0, UpdatedAt: now.Add(-time.Hour)}
+	bucket, result := limit.Take(&bucket, now)
+	if !result.Allowed || result.Remaining != 2 || bucket.Tokens != 2 {
+		t.Fatalf("idle bucket should refill to the burst only: %+v, %+v", result, bucket)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Burst: 1, Period: time.Minute}
+	now := time.Unix(1000, 0)
+	ctx := context.Background()
+
+	if result, _ := store.Take(ctx, "a", limit, now); !result.Allowed {
+		t.Fatal("first request for a was rejected")
+	}
+	if result, _ := store.Take(ctx, "a", limit, now); result.Allowed {
+		t.Fatal("second request for a was allowed")
+	}
+	if result, _ := store.Take(ctx, "b", limit, now); !result.Allowed {
+		t.Fatal("buckets are not separated by key")
+	}
+
+	// Full buckets are swept and behave as new ones.
+	later := now.Add(2 * time.Minute)
+	if result, _ := store.Take(ctx, "b", limit, later); !result.Allowed {
+		t.Fatal("refilled bucket was rejected")
+	}
+	if _, ok := store.buckets["a"]; ok {
+		t.Fatal("full bucket was not swept")
+	}
+}
@@ -0,0 +1,73 @@
+package organizations
+
+import (
+	"context"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+)
+
+// stubUsers is an in-memory user repository.
+type stubUsers map[uint]service.UserDTO
+
+func (u stubUsers) Create(ctx context.Context, name, email, passwordHash string) (uint, error) {
+	return 0, nil
+}
+
+func (u stubUsers) List(ctx context.Context) ([]service.UserDTO, error) {
+	return nil, nil
+}
+
+func (u stubUsers) GetByID(ctx context.Context, id uint) (*service.UserDTO, error) {
+	user, ok := u[id]
+	if !ok {
+		return nil, common.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (u stubUsers) GetByEmail(ctx context.Context, email string) (*service.UserDTO, error) {
+	return nil, common.ErrUserNotFound
+}
+
+func TestPlatformAdminCapabilities_ExcludeOwnerOperations(t *testing.T) {
+	for _, capability := range platformAdminCapabilities {
+		if !IsKnownCapability(capability) {
+			t.Fatalf("unknown capability %s in the allowlist", capability)
+		}
+	}
+	for _, capability := range []Capability{CapOrgDelete, CapOrgHierarchy, CapDomainsManage, CapSSOManage, CapRolesManage, CapTeamsManage} {
+		if Evaluate(platformAdminCapabilities, capability) {
+			t.Fatalf("platform admins must not hold %s", capability)
+		}
+	}
+}
+
+func TestAdminBypass(t *testing.T) {
+	svc := &Service{users: stubUsers{
+		1: {ID: 1, PlatformAdmin: true},
+		2: {ID: 2},
+	}}
+
+	cases := []struct {
+		name       string
+		userID     uint
+		capability Capability
+		want       bool
+	}{
+		{"admin, allowlisted", 1, CapMembersRemove, true},
+		{"admin, not allowlisted", 1, CapOrgDelete, false},
+		{"regular user", 2, CapMembersRemove, false},
+		{"unknown user", 3, CapMembersRemove, false},
+	}
+	for _, tc := range cases {
+		got, err := svc.adminBypass(context.Background(), 10, tc.userID, tc.capability)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: bypass = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ArchiveOrg makes an organization read-only. Archived organizations keep
+// their members and data but reject every change until unarchived.
+func (s *Service) ArchiveOrg(ctx context.Context, orgID uint) error {
+	return s.audited(ctx, orgEvent(AuditOrgArchived, orgID), func(tx *Service, _ *auditEvent) error {
+		return tx.archiveOrg(orgID)
+	})
+}
+
+func (s *Service) archiveOrg(orgID uint) error {
+	org, err := s.repo.GetOrg(orgID)
+	if err != nil {
+		return err
+	}
+	if org.ArchivedAt != nil {
+		return ErrOrgArchived
+	}
+
+	now := time.Now().UTC()
+	return s.repo.SetArchivedAt(orgID, &now)
+}
+
+func (s *Service) UnarchiveOrg(ctx context.Context, orgID uint) error {
+	return s.audited(ctx, orgEvent(AuditOrgUnarchived, orgID), func(tx *Service, _ *auditEvent) error {
+		return tx.unarchiveOrg(orgID)
+	})
+}
+
+func (s *Service) unarchiveOrg(orgID uint) error {
+	org, err := s.repo.GetOrg(orgID)
+	if err != nil {
+		return err
+	}
+	if org.ArchivedAt == nil {
+		return ErrOrgNotArchived
+	}
+	// An organization queued for deletion stays archived until it is gone.
+	if _, err := s.repo.FindActiveDeletionJob(orgID); err == nil {
+		return ErrDeletionInProgress
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.repo.SetArchivedAt(orgID, nil)
+}
+
+// ensureWritable returns ErrOrgArchived when the organization is archived.
+// Every mutating operation on an organization calls it first.
+func (s *Service) ensureWritable(orgID uint) error {
+	org, err := s.repo.GetOrg(orgID)
+	if err != nil {
+		return err
+	}
+	if org.ArchivedAt != nil {
+		return ErrOrgArchived
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package organizations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func TestArchivedOrg_RejectsWrites(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+	parentID := mustCreateOrg(t, svc, "parent", 1)
+
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 2, dto.PermissionRead, nil, nil); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	team, err := svc.CreateTeam(ctx, orgID, 1, "devs", dto.PermissionRead)
+	if err != nil {
+		t.Fatalf("create team: %v", err)
+	}
+	if err := svc.AddTeamMember(ctx, orgID, 1, team.ID, 2); err != nil {
+		t.Fatalf("add team member: %v", err)
+	}
+	role, err := svc.CreateRole(ctx, orgID, 1, "auditor", []Capability{CapMembersRead, CapAuditRead})
+	if err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+
+	if err := svc.ArchiveOrg(ctx, orgID); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	cases := []struct {
+		name  string
+		write func() error
+	}{
+		{"update org", func() error { return svc.UpdateOrg(ctx, orgID, "renamed") }},
+		{"set parent", func() error { return svc.SetParent(ctx, orgID, &parentID, true) }},
+		{"set inheritance", func() error { return svc.SetInheritParentAccess(ctx, orgID, false) }},
+		{"update settings", func() error {
+			_, err := svc.UpdateSettings(ctx, orgID, map[string]json.RawMessage{string(SettingJoinRequestsEnabled): json.RawMessage("true")})
+			return err
+		}},
+		{"add member", func() error { return svc.AddUserToOrg(ctx, orgID, 1, 3, dto.PermissionRead, nil, nil) }},
+		{"update member", func() error { return svc.UpdateUserPermission(ctx, orgID, 1, 2, dto.PermissionWrite, nil) }},
+		{"remove member", func() error { return svc.RemoveUserFromOrg(ctx, orgID, 1, 2) }},
+		{"set expiry", func() error { return svc.SetMembershipExpiry(ctx, orgID, 1, 2, &expiresAt) }},
+		{"bulk", func() error {
+			_, err := svc.BulkUpdateMembers(ctx, orgID, 1, []BulkOperation{{Op: BulkRemove, UserID: 2}})
+			return err
+		}},
+		{"create role", func() error {
+			_, err := svc.CreateRole(ctx, orgID, 1, "viewer", []Capability{CapMembersRead})
+			return err
+		}},
+		{"update role", func() error { return svc.UpdateRole(ctx, orgID, 1, role.ID, "auditor", []Capability{CapAuditRead}) }},
+		{"delete role", func() error { return svc.DeleteRole(ctx, orgID, role.ID) }},
+		{"create team", func() error {
+			_, err := svc.CreateTeam(ctx, orgID, 1, "ops", dto.PermissionRead)
+			return err
+		}},
+		{"update team", func() error { return svc.UpdateTeam(ctx, orgID, 1, team.ID, "devs", dto.PermissionWrite) }},
+		{"delete team", func() error { return svc.DeleteTeam(ctx, orgID, team.ID) }},
+		{"add team member", func() error { return svc.AddTeamMember(ctx, orgID, 1, team.ID, 1) }},
+		{"remove team member", func() error { return svc.RemoveTeamMember(ctx, orgID, team.ID, 2) }},
+		{"invite", func() error {
+			_, err := svc.InviteUser(ctx, orgID, 1, "new@example.com", dto.PermissionRead)
+			return err
+		}},
+		{"request to join", func() error {
+			_, err := svc.RequestToJoin(ctx, orgID, 3, "")
+			return err
+		}},
+		{"claim domain", func() error {
+			_, err := svc.ClaimDomain(ctx, orgID, "acme.example")
+			return err
+		}},
+		{"configure SSO", func() error {
+			_, err := svc.SetSSOConfig(ctx, orgID, SSOConfigInput{Issuer: "https://idp.example", ClientID: "app"})
+			return err
+		}},
+	}
+
+	for _, tc := range cases {
+		if err := tc.write(); !errors.Is(err, ErrOrgArchived) {
+			t.Errorf("%s: err = %v, want ErrOrgArchived", tc.name, err)
+		}
+	}
+}
+
+func TestUnarchiveOrg(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}})
+
+	archived := mustCreateOrg(t, svc, "archived", 1)
+	if err := svc.ArchiveOrg(ctx, archived); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	active := mustCreateOrg(t, svc, "active", 1)
+	deleting := mustCreateOrg(t, svc, "deleting", 1)
+	if _, err := svc.RequestOrgDeletion(ctx, deleting, 1); err != nil {
+		t.Fatalf("request deletion: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		orgID uint
+		want  error
+	}{
+		{"archived", archived, nil},
+		{"not archived", active, ErrOrgNotArchived},
+		{"deletion pending", deleting, ErrDeletionInProgress},
+	}
+
+	for _, tc := range cases {
+		if err := svc.UnarchiveOrg(ctx, tc.orgID); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
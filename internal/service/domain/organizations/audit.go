@@ -0,0 +1,459 @@
+package organizations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+// Audited actions.
+const (
+	AuditOrgCreated            = "org.created"
+	AuditOrgUpdated            = "org.updated"
+	AuditOrgParentChanged      = "org.parent_changed"
+	AuditOrgInheritanceChanged = "org.inheritance_changed"
+	AuditOrgArchived           = "org.archived"
+	AuditOrgUnarchived         = "org.unarchived"
+	AuditOrgPlanChanged        = "org.plan_changed"
+	AuditOrgDeletionRequested  = "org.deletion_requested"
+	AuditOrgDeleted            = "org.deleted"
+	AuditSettingsUpdated       = "settings.updated"
+
+	AuditMemberAdded         = "member.added"
+	AuditMemberUpdated       = "member.updated"
+	AuditMemberRemoved       = "member.removed"
+	AuditMemberExpiryChanged = "member.expiry_changed"
+	AuditMemberExpired       = "member.expired"
+
+	AuditRoleCreated = "role.created"
+	AuditRoleUpdated = "role.updated"
+	AuditRoleDeleted = "role.deleted"
+
+	AuditTeamCreated       = "team.created"
+	AuditTeamUpdated       = "team.updated"
+	AuditTeamDeleted       = "team.deleted"
+	AuditTeamMemberAdded   = "team.member_added"
+	AuditTeamMemberRemoved = "team.member_removed"
+
+	AuditInvitationCreated  = "invitation.created"
+	AuditInvitationResent   = "invitation.resent"
+	AuditInvitationRevoked  = "invitation.revoked"
+	AuditInvitationAccepted = "invitation.accepted"
+	AuditInvitationDeclined = "invitation.declined"
+
+	AuditJoinRequestCreated  = "join_request.created"
+	AuditJoinRequestApproved = "join_request.approved"
+	AuditJoinRequestRejected = "join_request.rejected"
+
+	AuditDomainClaimed  = "domain.claimed"
+	AuditDomainVerified = "domain.verified"
+	AuditDomainRemoved  = "domain.removed"
+
+	AuditSSOConfigured     = "sso.configured"
+	AuditSSORemoved        = "sso.removed"
+	AuditSSOIdentityLinked = "sso.identity_linked"
+)
+
+// maxAuditUserAgent is the size of the user agent column.
+const maxAuditUserAgent = 512
+
+// Kinds of audited targets. The target ID of a member is the user ID and
+// the target ID of settings and SSO is the organization ID.
+const (
+	AuditTargetOrg         = "organization"
+	AuditTargetSettings    = "settings"
+	AuditTargetMember      = "member"
+	AuditTargetRole        = "role"
+	AuditTargetTeam        = "team"
+	AuditTargetInvitation  = "invitation"
+	AuditTargetJoinRequest = "join_request"
+	AuditTargetDomain      = "domain"
+	AuditTargetSSO         = "sso"
+)
+
+// AuditEntryDTO is one recorded change. ActorID is zero for changes made by
+// the system; OnBehalfOfID is set when a platform admin impersonated a user.
+type AuditEntryDTO struct {
+	ID           uint
+	ActorID      uint
+	OnBehalfOfID *uint
+	Action       string
+	TargetType   string
+	TargetID     uint
+	OrgID        *uint
+	Before       json.RawMessage
+	After        json.RawMessage
+	IP           string
+	UserAgent    string
+	RequestID    string
+	CreatedAt    time.Time
+}
+
+// AuditFilter narrows an audit log query. Zero fields do not filter; Until
+// is exclusive.
+type AuditFilter struct {
+	OrgID      *uint
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   *uint
+	Since      *time.Time
+	Until      *time.Time
+}
+
+// ListAuditLog returns a page of the audit entries matching filter, newest
+// first.
+func (s *Service) ListAuditLog(ctx context.Context, filter AuditFilter, page common.Pagination) ([]AuditEntryDTO, common.Pagination, error) {
+	page.Normalize()
+	entries, total, err := s.repo.ListAuditLogs(organizations.AuditFilter(filter), page.Offset(), page.Limit)
+	if err != nil {
+		return nil, page, err
+	}
+	page.Total = total
+
+	dtos := make([]AuditEntryDTO, 0, len(entries))
+	for _, entry := range entries {
+		dtos = append(dtos, AuditEntryDTO{
+			ID:           entry.ID,
+			ActorID:      entry.ActorID,
+			OnBehalfOfID: entry.OnBehalfOfID,
+			Action:       entry.Action,
+			TargetType:   entry.TargetType,
+			TargetID:     entry.TargetID,
+			OrgID:        entry.OrgID,
+			Before:       json.RawMessage(entry.Before),
+			After:        json.RawMessage(entry.After),
+			IP:           entry.IP,
+			UserAgent:    entry.UserAgent,
+			RequestID:    entry.RequestID,
+			CreatedAt:    entry.CreatedAt,
+		})
+	}
+	return dtos, page, nil
+}
+
+// auditEvent describes a change while it is being made. load, when set,
+// reads the state of the target; audited calls it before and after the
+// change to fill in before and after.
+type auditEvent struct {
+	action     string
+	orgID      uint
+	targetType string
+	targetID   uint
+	before     interface{}
+	after      interface{}
+	load       func(repo *organizations.Repository, targetID uint) (interface{}, error)
+}
+
+// audited runs fn in a transaction, on a copy of the service bound to it,
+// and appends the audit entry for the change in the same transaction: either
+// both are stored or neither is. fn may fill in the target ID of something
+// it creates and the organization it turns out to act on.
+func (s *Service) audited(ctx context.Context, event auditEvent, fn func(tx *Service, event *auditEvent) error) error {
+	return s.repo.Transaction(func(repo *organizations.Repository) error {
+		tx := *s
+		tx.repo = repo
+
+		if event.load != nil && event.targetID != 0 {
+			before, err := event.load(repo, event.targetID)
+			if err != nil {
+				return err
+			}
+			event.before = before
+		}
+
+		if err := fn(&tx, &event); err != nil {
+			return err
+		}
+
+		if event.load != nil && event.targetID != 0 {
+			after, err := event.load(repo, event.targetID)
+			if err != nil {
+				return err
+			}
+			event.after = after
+		}
+		return appendAudit(ctx, repo, event)
+	})
+}
+
+// appendAudit records event with the actor and request found in ctx.
+func appendAudit(ctx context.Context, repo *organizations.Repository, event auditEvent) error {
+	entry, err := newAuditEntry(ctx, event)
+	if err != nil {
+		return err
+	}
+	return repo.AppendAuditLog(entry)
+}
+
+func newAuditEntry(ctx context.Context, event auditEvent) (*organizations.AuditLogModel, error) {
+	before, err := auditSnapshot(event.before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := auditSnapshot(event.after)
+	if err != nil {
+		return nil, err
+	}
+
+	actorID, onBehalfOf := auditActor(ctx)
+	request := common.RequestInfoFrom(ctx)
+	entry := &organizations.AuditLogModel{
+		ActorID:      actorID,
+		OnBehalfOfID: onBehalfOf,
+		Action:       event.action,
+		TargetType:   event.targetType,
+		TargetID:     event.targetID,
+		Before:       before,
+		After:        after,
+		IP:           request.IP,
+		UserAgent:    strings.ToValidUTF8(truncate(request.UserAgent, maxAuditUserAgent), ""),
+		RequestID:    request.RequestID,
+	}
+	if event.orgID != 0 {
+		orgID := event.orgID
+		entry.OrgID = &orgID
+	}
+	return entry, nil
+}
+
+// auditActor returns who is making a change. Under impersonation the actor
+// is the platform admin and the impersonated user is recorded alongside.
+// Work that did not come from a request is attributed to the system, 0.
+func auditActor(ctx context.Context) (uint, *uint) {
+	identity, ok := common.IdentityFrom(ctx)
+	if !ok {
+		return 0, nil
+	}
+	if identity.Impersonated() {
+		subject := identity.UserID
+		return identity.ActorID, &subject
+	}
+	return identity.UserID, nil
+}
+
+func auditSnapshot(state interface{}) (organizations.AuditSnapshot, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// snapshotOf converts a loaded model into its audit snapshot, treating a
+// missing record as no state.
+func snapshotOf[T any](model *T, err error, convert func(T) map[string]interface{}) (interface{}, error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return convert(*model), nil
+}
+
+func orgEvent(action string, orgID uint) auditEvent {
+	return auditEvent{
+		action:     action,
+		orgID:      orgID,
+		targetType: AuditTargetOrg,
+		targetID:   orgID,
+		load: func(repo *organizations.Repository, orgID uint) (interface{}, error) {
+			org, err := repo.GetOrg(orgID)
+			return snapshotOf(org, err, orgSnapshot)
+		},
+	}
+}
+
+func settingsEvent(orgID uint) auditEvent {
+	return auditEvent{
+		action:     AuditSettingsUpdated,
+		orgID:      orgID,
+		targetType: AuditTargetSettings,
+		targetID:   orgID,
+		load: func(repo *organizations.Repository, orgID uint) (interface{}, error) {
+			return repo.GetOrgSettings(orgID)
+		},
+	}
+}
+
+func memberEvent(action string, orgID, userID uint) auditEvent {
+	return auditEvent{
+		action:     action,
+		orgID:      orgID,
+		targetType: AuditTargetMember,
+		targetID:   userID,
+		load: func(repo *organizations.Repository, userID uint) (interface{}, error) {
+			membership, err := repo.GetMembership(orgID, userID)
+			return snapshotOf(membership, err, memberSnapshot)
+		},
+	}
+}
+
+func roleEvent(action string, orgID, roleID uint) auditEvent {
+	return auditEvent{
+		action:     action,
+		orgID:      orgID,
+		targetType: AuditTargetRole,
+		targetID:   roleID,
+		load: func(repo *organizations.Repository, roleID uint) (interface{}, error) {
+			role, err := repo.GetRole(roleID)
+			return snapshotOf(role, err, roleSnapshot)
+		},
+	}
+}
+
+func teamEvent(action string, orgID, teamID uint) auditEvent {
+	return auditEvent{
+		action:     action,
+		orgID:      orgID,
+		targetType: AuditTargetTeam,
+		targetID:   teamID,
+		load: func(repo *organizations.Repository, teamID uint) (interface{}, error) {
+			team, err := repo.GetTeam(orgID, teamID)
+			return snapshotOf(team, err, teamSnapshot)
+		},
+	}
+}
+
+func invitationEvent(action string, orgID, invitationID uint) auditEvent {
+	return auditEvent{
+		action:     action,
+		orgID:      orgID,
+		targetType: AuditTargetInvitation,
+		targetID:   invitationID,
+		load: func(repo *organizations.Repository, invitationID uint) (interface{}, error) {
+			invitation, err := repo.GetInvitation(invitationID)
+			return snapshotOf(invitation, err, invitationSnapshot)
+		},
+	}
+}
+
+func joinRequestEvent(action string, orgID, requestID uint) auditEvent {
+	return auditEvent{
+		action:     action,
+		orgID:      orgID,
+		targetType: AuditTargetJoinRequest,
+		targetID:   requestID,
+		load: func(repo *organizations.Repository, requestID uint) (interface{}, error) {
+			request, err := repo.GetJoinRequest(orgID, requestID)
+			return snapshotOf(request, err, joinRequestSnapshot)
+		},
+	}
+}
+
+func domainEvent(action string, orgID, claimID uint) auditEvent {
+	return auditEvent{
+		action:     action,
+		orgID:      orgID,
+		targetType: AuditTargetDomain,
+		targetID:   claimID,
+		load: func(repo *organizations.Repository, claimID uint) (interface{}, error) {
+			claim, err := repo.GetDomainClaim(orgID, claimID)
+			return snapshotOf(claim, err, domainSnapshot)
+		},
+	}
+}
+
+func ssoEvent(action string, orgID uint) auditEvent {
+	return auditEvent{
+		action:     action,
+		orgID:      orgID,
+		targetType: AuditTargetSSO,
+		targetID:   orgID,
+		load: func(repo *organizations.Repository, orgID uint) (interface{}, error) {
+			config, err := repo.GetSSOConfig(orgID)
+			return snapshotOf(config, err, ssoSnapshot)
+		},
+	}
+}
+
+func orgSnapshot(org organizations.OrganizationModel) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                  org.Name,
+		"slug":                  org.Slug,
+		"parent_id":             org.ParentID,
+		"inherit_parent_access": org.InheritParentAccess,
+		"archived_at":           org.ArchivedAt,
+		"plan":                  org.Plan,
+	}
+}
+
+func memberSnapshot(membership organizations.OrgUserModel) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":    membership.UserID,
+		"permission": membership.Permission,
+		"role_id":    membership.RoleID,
+		"expires_at": membership.ExpiresAt,
+	}
+}
+
+func roleSnapshot(role organizations.RoleModel) map[string]interface{} {
+	return map[string]interface{}{
+		"name":         role.Name,
+		"capabilities": role.CapabilityList(),
+	}
+}
+
+func teamSnapshot(team organizations.TeamModel) map[string]interface{} {
+	result := toTeamDTO(team)
+	return map[string]interface{}{
+		"name":       result.Name,
+		"permission": result.Permission,
+		"member_ids": result.MemberIDs,
+	}
+}
+
+// invitationSnapshot leaves out the nonce, which would let anyone reading the
+// audit log rebuild a valid token.
+func invitationSnapshot(invitation organizations.InvitationModel) map[string]interface{} {
+	return map[string]interface{}{
+		"email":      invitation.Email,
+		"permission": invitation.Permission,
+		"status":     invitation.Status,
+		"invited_by": invitation.InvitedBy,
+		"expires_at": invitation.ExpiresAt,
+		"send_count": invitation.SendCount,
+	}
+}
+
+func joinRequestSnapshot(request organizations.JoinRequestModel) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":    request.UserID,
+		"status":     request.Status,
+		"permission": request.Permission,
+		"decided_by": request.DecidedBy,
+	}
+}
+
+func domainSnapshot(claim organizations.OrgDomainModel) map[string]interface{} {
+	return map[string]interface{}{
+		"domain":      claim.Domain,
+		"verified_at": claim.VerifiedAt,
+	}
+}
+
+// ssoSnapshot leaves out the client secret.
+func ssoSnapshot(config organizations.OrgSSOConfigModel) map[string]interface{} {
+	return map[string]interface{}{
+		"issuer":            config.Issuer,
+		"client_id":         config.ClientID,
+		"redirect_url":      config.RedirectURL,
+		"groups_claim":      config.GroupsClaim,
+		"group_permissions": config.GroupPermissions,
+		"enabled":           config.Enabled,
+	}
+}
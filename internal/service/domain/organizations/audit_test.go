@@ -0,0 +1,107 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+func TestAuditActor(t *testing.T) {
+	cases := []struct {
+		name       string
+		ctx        context.Context
+		actor      uint
+		onBehalfOf uint
+	}{
+		{"system", context.Background(), 0, 0},
+		{"user", common.WithIdentity(context.Background(), common.Identity{UserID: 5}), 5, 0},
+		{"impersonated", common.WithIdentity(context.Background(), common.Identity{UserID: 5, ActorID: 9}), 9, 5},
+	}
+	for _, tc := range cases {
+		actor, onBehalfOf := auditActor(tc.ctx)
+		if actor != tc.actor {
+			t.Fatalf("%s: actor = %d, want %d", tc.name, actor, tc.actor)
+		}
+		if (onBehalfOf == nil) != (tc.onBehalfOf == 0) || (onBehalfOf != nil && *onBehalfOf != tc.onBehalfOf) {
+			t.Fatalf("%s: on behalf of = %v, want %d", tc.name, onBehalfOf, tc.onBehalfOf)
+		}
+	}
+}
+
+func TestNewAuditEntry(t *testing.T) {
+	ctx := common.WithIdentity(context.Background(), common.Identity{UserID: 3})
+	ctx = common.WithRequestInfo(ctx, common.RequestInfo{IP: "10.0.0.1", UserAgent: "curl", RequestID: "req-1"})
+
+	event := memberEvent(AuditMemberRemoved, 7, 4)
+	event.before = memberSnapshot(organizations.OrgUserModel{UserID: 4, Permission: "ROOT"})
+
+	entry, err := newAuditEntry(ctx, event)
+	if err != nil {
+		t.Fatalf("newAuditEntry: %v", err)
+	}
+	if entry.ActorID != 3 || entry.Action != AuditMemberRemoved || entry.TargetType != AuditTargetMember || entry.TargetID != 4 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if entry.OrgID == nil || *entry.OrgID != 7 {
+		t.Fatalf("org = %v, want 7", entry.OrgID)
+	}
+	if entry.IP != "10.0.0.1" || entry.UserAgent != "curl" || entry.RequestID != "req-1" {
+		t.Fatalf("request metadata not recorded: %+v", entry)
+	}
+	want := `{"expires_at":null,"permission":"ROOT","role_id":null,"user_id":4}`
+	if string(entry.Before) != want {
+		t.Fatalf("before = %s, want %s", entry.Before, want)
+	}
+	if entry.After != nil {
+		t.Fatalf("after = %s, want none", entry.After)
+	}
+}
+
+func TestNewAuditEntry_WithoutOrg(t *testing.T) {
+	entry, err := newAuditEntry(context.Background(), auditEvent{action: AuditInvitationAccepted})
+	if err != nil {
+		t.Fatalf("newAuditEntry: %v", err)
+	}
+	if entry.OrgID != nil || entry.ActorID != 0 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+}
+
+func TestSnapshotOf(t *testing.T) {
+	state, err := snapshotOf[organizations.RoleModel](nil, gorm.ErrRecordNotFound, roleSnapshot)
+	if err != nil || state != nil {
+		t.Fatalf("missing record: got %v, %v", state, err)
+	}
+
+	failure := errors.New("connection reset")
+	if _, err := snapshotOf[organizations.RoleModel](nil, failure, roleSnapshot); !errors.Is(err, failure) {
+		t.Fatalf("expected the load error, got %v", err)
+	}
+
+	role := &organizations.RoleModel{Name: "billing", Capabilities: "members.read,audit.read"}
+	state, err = snapshotOf(role, nil, roleSnapshot)
+	if err != nil {
+		t.Fatalf("snapshotOf: %v", err)
+	}
+	snapshot, err := auditSnapshot(state)
+	if err != nil {
+		t.Fatalf("auditSnapshot: %v", err)
+	}
+	if want := `{"capabilities":["members.read","audit.read"],"name":"billing"}`; string(snapshot) != want {
+		t.Fatalf("snapshot = %s, want %s", snapshot, want)
+	}
+}
+
+func TestInvitationSnapshot_OmitsNonce(t *testing.T) {
+	snapshot := invitationSnapshot(organizations.InvitationModel{Email: "a@example.com", Nonce: "secret"})
+	for key, value := range snapshot {
+		if value == "secret" {
+			t.Fatalf("nonce leaked under %q", key)
+		}
+	}
+}
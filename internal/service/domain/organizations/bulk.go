@@ -0,0 +1,247 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+// MaxBulkOperations caps the size of a bulk membership request.
+const MaxBulkOperations = 500
+
+// BulkOp is the kind of a bulk membership operation.
+type BulkOp string
+
+const (
+	BulkAdd    BulkOp = "add"
+	BulkUpdate BulkOp = "update"
+	BulkRemove BulkOp = "remove"
+)
+
+// BulkOperation adds, updates or removes one membership. Permission, RoleID
+// and ExpiresAt follow the rules of AddUserToOrg and UpdateUserPermission,
+// and so do the limits on what the caller may grant or change.
+type BulkOperation struct {
+	Op         BulkOp
+	UserID     uint
+	Permission dto.PermissionType
+	RoleID     *uint
+	ExpiresAt  *time.Time
+}
+
+// BulkResult is the outcome of one operation. Error is nil for operations
+// that were applied, or that were valid in a rejected batch.
+type BulkResult struct {
+	Index  int
+	Op     BulkOp
+	UserID uint
+	Error  error
+}
+
+// BulkUpdateMembers applies a batch of membership operations atomically.
+// Every operation is validated first, against the state left by the
+// operations before it; the batch is applied only when all are valid, the
+// organization keeps at least one ROOT member and the plan's quotas hold.
+// Otherwise nothing changes and ErrBulkRejected (or the quota error) is
+// returned along with the per-operation results.
+func (s *Service) BulkUpdateMembers(ctx context.Context, orgID, actorID uint, ops []BulkOperation) ([]BulkResult, error) {
+	if len(ops) == 0 || len(ops) > MaxBulkOperations {
+		return nil, fmt.Errorf("%w: between 1 and %d operations are allowed", ErrBulkRejected, MaxBulkOperations)
+	}
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+
+	held, err := s.heldCapabilities(ctx, orgID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(ops))
+	for i := range ops {
+		results[i] = BulkResult{Index: i, Op: ops[i].Op, UserID: ops[i].UserID}
+		results[i].Error = s.prepareBulkOperation(ctx, orgID, held, &ops[i])
+	}
+
+	err = s.withinQuota(orgID, func(tx *organizations.Repository) error {
+		current, err := loadMemberStates(tx, orgID)
+		if err != nil {
+			return err
+		}
+
+		final := planBulk(current, ops, results)
+		if hasBulkErrors(results) {
+			return ErrBulkRejected
+		}
+		if checkLastRoot(current, final, ops, results) {
+			return ErrBulkRejected
+		}
+
+		for _, op := range ops {
+			if err := applyAuditedBulkOperation(ctx, tx, orgID, op); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return results, err
+}
+
+// prepareBulkOperation checks an operation on its own, including that the
+// caller holding held may make it, and resolves its role into the
+// permission to store.
+func (s *Service) prepareBulkOperation(ctx context.Context, orgID uint, held []Capability, op *BulkOperation) error {
+	switch op.Op {
+	case BulkAdd:
+		user, err := s.users.GetByID(ctx, op.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return common.ErrUserNotFound
+		}
+		if err := validateExpiry(op.ExpiresAt); err != nil {
+			return err
+		}
+		if err := s.ensureEmailAllowed(ctx, orgID, user.Email); err != nil {
+			return err
+		}
+	case BulkUpdate:
+		if err := s.checkChange(held, orgID, op.UserID); err != nil {
+			return err
+		}
+	case BulkRemove:
+		return s.checkChange(held, orgID, op.UserID)
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	permission, roleID, err := s.resolveMemberRole(orgID, op.Permission, op.RoleID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkGrant(held, permission, roleID); err != nil {
+		return err
+	}
+	op.Permission = permission
+	op.RoleID = roleID
+	return nil
+}
+
+// planBulk replays ops over the current memberships and returns the final
+// state. Operations that do not fit the state at their turn, or that touch a
+// user already touched by the batch, get an error in results.
+func planBulk(current map[uint]memberState, ops []BulkOperation, results []BulkResult) map[uint]memberState {
+	final := maps.Clone(current)
+
+	seen := map[uint]bool{}
+	for i, op := range ops {
+		if results[i].Error != nil {
+			continue
+		}
+		if seen[op.UserID] {
+			results[i].Error = ErrBulkDuplicateUser
+			continue
+		}
+		seen[op.UserID] = true
+
+		_, member := final[op.UserID]
+		switch op.Op {
+		case BulkAdd:
+			if member {
+				results[i].Error = ErrAlreadyMember
+				continue
+			}
+			final[op.UserID] = memberState{
+				permission: op.Permission,
+				roleID:     op.RoleID,
+				active:     true,
+				permanent:  op.ExpiresAt == nil,
+			}
+		case BulkUpdate:
+			if !member {
+				results[i].Error = ErrNotMember
+				continue
+			}
+			state := final[op.UserID]
+			state.permission, state.roleID = op.Permission, op.RoleID
+			final[op.UserID] = state
+		case BulkRemove:
+			if !member {
+				results[i].Error = ErrNotMember
+				continue
+			}
+			delete(final, op.UserID)
+		}
+	}
+	return final
+}
+
+// checkLastRoot reports whether the batch leaves the organization without a
+// ROOT member, as keepsRoot defines it, and blames the operations that
+// demoted or removed ROOT members.
+func checkLastRoot(current, final map[uint]memberState, ops []BulkOperation, results []BulkResult) bool {
+	if keepsRoot(current, final) {
+		return false
+	}
+	for i, op := range ops {
+		if current[op.UserID].root() {
+			results[i].Error = ErrLastRoot
+		}
+	}
+	return true
+}
+
+func hasBulkErrors(results []BulkResult) bool {
+	for _, result := range results {
+		if result.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// bulkAuditActions maps bulk operations to the audit action of their
+// single-member counterpart.
+var bulkAuditActions = map[BulkOp]string{
+	BulkAdd:    AuditMemberAdded,
+	BulkUpdate: AuditMemberUpdated,
+	BulkRemove: AuditMemberRemoved,
+}
+
+// applyAuditedBulkOperation applies one operation and records it in the
+// audit log like the equivalent single-member change.
+func applyAuditedBulkOperation(ctx context.Context, tx *organizations.Repository, orgID uint, op BulkOperation) error {
+	event := memberEvent(bulkAuditActions[op.Op], orgID, op.UserID)
+
+	var err error
+	if event.before, err = event.load(tx, op.UserID); err != nil {
+		return err
+	}
+	if err := applyBulkOperation(tx, orgID, op); err != nil {
+		return err
+	}
+	if event.after, err = event.load(tx, op.UserID); err != nil {
+		return err
+	}
+	return appendAudit(ctx, tx, event)
+}
+
+func applyBulkOperation(tx *organizations.Repository, orgID uint, op BulkOperation) error {
+	switch op.Op {
+	case BulkAdd:
+		return tx.AddUserToOrg(orgID, op.UserID, op.Permission, op.RoleID, op.ExpiresAt)
+	case BulkUpdate:
+		return tx.UpdateUserPermission(orgID, op.UserID, op.Permission, op.RoleID)
+	case BulkRemove:
+		return tx.RemoveUserFromOrg(orgID, op.UserID)
+	default:
+		return errors.New("unknown operation")
+	}
+}
@@ -0,0 +1,92 @@
+package organizations
+
+import (
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func newResults(ops []BulkOperation) []BulkResult {
+	results := make([]BulkResult, len(ops))
+	for i, op := range ops {
+		results[i] = BulkResult{Index: i, Op: op.Op, UserID: op.UserID}
+	}
+	return results
+}
+
+func TestPlanBulk_ReplaysOperations(t *testing.T) {
+	current := map[uint]memberState{
+		1: {permission: dto.PermissionRoot, active: true},
+		2: {permission: dto.PermissionRead, active: true},
+		3: {permission: dto.PermissionWrite, active: true},
+	}
+	ops := []BulkOperation{
+		{Op: BulkUpdate, UserID: 2, Permission: dto.PermissionRoot},
+		{Op: BulkRemove, UserID: 3},
+		{Op: BulkAdd, UserID: 4, Permission: dto.PermissionRead},
+	}
+	results := newResults(ops)
+
+	final := planBulk(current, ops, results)
+	if hasBulkErrors(results) {
+		t.Fatalf("unexpected errors: %+v", results)
+	}
+	if len(final) != 3 || final[2].permission != dto.PermissionRoot || final[4].permission != dto.PermissionRead {
+		t.Fatalf("unexpected final state: %+v", final)
+	}
+	if _, ok := final[3]; ok {
+		t.Fatal("removed member still present")
+	}
+	if len(current) != 3 || current[2].permission != dto.PermissionRead {
+		t.Fatal("planning must not modify the current state")
+	}
+}
+
+func TestPlanBulk_ReportsInvalidOperations(t *testing.T) {
+	current := map[uint]memberState{1: {permission: dto.PermissionRoot, active: true}}
+	ops := []BulkOperation{
+		{Op: BulkAdd, UserID: 1, Permission: dto.PermissionRead},
+		{Op: BulkUpdate, UserID: 9, Permission: dto.PermissionRead},
+		{Op: BulkAdd, UserID: 5, Permission: dto.PermissionRead},
+		{Op: BulkRemove, UserID: 5},
+	}
+	results := newResults(ops)
+
+	planBulk(current, ops, results)
+	want := []error{ErrAlreadyMember, ErrNotMember, nil, ErrBulkDuplicateUser}
+	for i, err := range want {
+		if !errors.Is(results[i].Error, err) {
+			t.Fatalf("operation %d: got %v, want %v", i, results[i].Error, err)
+		}
+	}
+}
+
+func TestCheckLastRoot_EvaluatesFinalState(t *testing.T) {
+	current := map[uint]memberState{
+		1: {permission: dto.PermissionRoot, active: true},
+		2: {permission: dto.PermissionRead, active: true},
+	}
+
+	// Demoting the only ROOT is fine when another member is promoted.
+	swap := []BulkOperation{
+		{Op: BulkUpdate, UserID: 1, Permission: dto.PermissionRead},
+		{Op: BulkUpdate, UserID: 2, Permission: dto.PermissionRoot},
+	}
+	results := newResults(swap)
+	if checkLastRoot(current, planBulk(current, swap, results), swap, results) {
+		t.Fatalf("swap keeps a ROOT and must be accepted: %+v", results)
+	}
+
+	removal := []BulkOperation{
+		{Op: BulkRemove, UserID: 2},
+		{Op: BulkRemove, UserID: 1},
+	}
+	results = newResults(removal)
+	if !checkLastRoot(current, planBulk(current, removal, results), removal, results) {
+		t.Fatal("removing the last ROOT must be rejected")
+	}
+	if results[0].Error != nil || !errors.Is(results[1].Error, ErrLastRoot) {
+		t.Fatalf("only the ROOT removal should be blamed: %+v", results)
+	}
+}
@@ -0,0 +1,53 @@
+package organizations
+
+import (
+	"context"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/service"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+	"meu-treino-golang/users-crud/internal/storage/postgres/pgtest"
+)
+
+// openService returns a service over a fresh, migrated schema. The test is
+// skipped when TEST_DATABASE_URL is not set.
+func openService(t *testing.T, users service.IUserRepository) *Service {
+	t.Helper()
+	repo := organizations.NewRepository(pgtest.Open(t, organizations.Models()...))
+	if err := Migrate(context.Background(), repo); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewService(repo, users, &recordingMailer{}, nil, nil, []byte("test-token-secret"), "")
+}
+
+// recordingMailer keeps the recipients and subjects of the mails it is asked
+// to send, or fails with err when it is set.
+type recordingMailer struct {
+	to       []string
+	subjects []string
+	err      error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, to, subject, body string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.to = append(m.to, to)
+	m.subjects = append(m.subjects, subject)
+	return nil
+}
+
+// sentMail returns the mailer of a service built by openService.
+func sentMail(s *Service) *recordingMailer {
+	return s.mailer.(*recordingMailer)
+}
+
+// mustCreateOrg creates an organization with creatorID as its ROOT member.
+func mustCreateOrg(t *testing.T, s *Service, name string, creatorID uint) uint {
+	t.Helper()
+	orgID, err := s.CreateOrg(context.Background(), name, creatorID)
+	if err != nil {
+		t.Fatalf("create org %s: %v", name, err)
+	}
+	return orgID
+}
@@ -0,0 +1,253 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+// DeletionImpact reports what deleting an organization removes.
+// Subsidiaries are detached, not deleted.
+type DeletionImpact struct {
+	OrgID              uint
+	Name               string
+	Members            int64
+	Teams              int64
+	TeamMembers        int64
+	Invitations        int64
+	PendingInvitations int64
+	CustomRoles        int64
+	JoinRequests       int64
+	Domains            int64
+	Settings           int64
+	SlugHistory        int64
+	ExpiryRecords      int64
+	SSOConfigs         int64
+	SSOIdentities      int64
+	Subsidiaries       int64
+}
+
+type DeletionJobDTO struct {
+	ID          uint
+	OrgID       uint
+	OrgName     string
+	Status      string
+	Error       string
+	RequestedBy uint
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+}
+
+// GetDeletionImpact is the dry run of RequestOrgDeletion: it counts what the
+// deletion would remove without changing anything.
+func (s *Service) GetDeletionImpact(ctx context.Context, orgID uint) (*DeletionImpact, error) {
+	org, err := s.repo.GetOrg(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.GetDeletionImpact(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeletionImpact{
+		OrgID:              org.ID,
+		Name:               org.Name,
+		Members:            counts.Members,
+		Teams:              counts.Teams,
+		TeamMembers:        counts.TeamMembers,
+		Invitations:        counts.Invitations,
+		PendingInvitations: counts.PendingInvitations,
+		CustomRoles:        counts.CustomRoles,
+		JoinRequests:       counts.JoinRequests,
+		Domains:            counts.Domains,
+		Settings:           counts.Settings,
+		SlugHistory:        counts.SlugHistory,
+		ExpiryRecords:      counts.ExpiryRecords,
+		SSOConfigs:         counts.SSOConfigs,
+		SSOIdentities:      counts.SSOIdentities,
+		Subsidiaries:       counts.Subsidiaries,
+	}, nil
+}
+
+// RequestOrgDeletion queues the deletion of an organization and returns the
+// job tracking it. The organization is archived right away, so it stays
+// read-only until the deletion worker removes it.
+func (s *Service) RequestOrgDeletion(ctx context.Context, orgID, requestedBy uint) (*DeletionJobDTO, error) {
+	var job *DeletionJobDTO
+	err := s.audited(ctx, orgEvent(AuditOrgDeletionRequested, orgID), func(tx *Service, _ *auditEvent) (err error) {
+		job, err = tx.requestOrgDeletion(orgID, requestedBy)
+		return err
+	})
+	return job, err
+}
+
+func (s *Service) requestOrgDeletion(orgID, requestedBy uint) (*DeletionJobDTO, error) {
+	var job organizations.OrgDeletionJobModel
+	err := s.repo.LockOrg(orgID, func(tx *organizations.Repository, org *organizations.OrganizationModel) error {
+		if _, err := tx.FindActiveDeletionJob(orgID); err == nil {
+			return ErrDeletionInProgress
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if org.ArchivedAt == nil {
+			now := time.Now().UTC()
+			if err := tx.SetArchivedAt(orgID, &now); err != nil {
+				return err
+			}
+		}
+
+		job = organizations.OrgDeletionJobModel{
+			OrgID:       orgID,
+			OrgName:     org.Name,
+			Status:      organizations.DeletionPending,
+			RequestedBy: requestedBy,
+		}
+		return tx.CreateDeletionJob(&job)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := toDeletionJobDTO(job)
+	return &result, nil
+}
+
+func (s *Service) GetDeletionJob(ctx context.Context, jobID uint) (*DeletionJobDTO, error) {
+	job, err := s.repo.GetDeletionJob(jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeletionJobNotFound
+		}
+		return nil, err
+	}
+
+	result := toDeletionJobDTO(*job)
+	return &result, nil
+}
+
+// RunDeletionJobs runs pending deletion jobs one at a time until none is
+// left and returns how many it ran.
+func (s *Service) RunDeletionJobs(ctx context.Context) (int, error) {
+	return runDeletionJobs(ctx, repoDeletionStore{s.repo})
+}
+
+// deletionStore is what the deletion worker needs from storage.
+type deletionStore interface {
+	// ClaimDeletionJob returns gorm.ErrRecordNotFound when no job is pending.
+	ClaimDeletionJob(now time.Time) (*organizations.OrgDeletionJobModel, error)
+	// SnapshotOrg returns the audit snapshot of the organization.
+	SnapshotOrg(orgID uint) (interface{}, error)
+	DeleteOrgInBatches(orgID uint) error
+	// FinishDeletionJob records the outcome of a job and, when event is not
+	// nil, appends it to the audit log in the same transaction.
+	FinishDeletionJob(ctx context.Context, jobID uint, status, message string, event *auditEvent) error
+}
+
+func runDeletionJobs(ctx context.Context, store deletionStore) (int, error) {
+	ran := 0
+	for ctx.Err() == nil {
+		job, err := store.ClaimDeletionJob(time.Now().UTC())
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ran, nil
+			}
+			return ran, err
+		}
+
+		event := orgEvent(AuditOrgDeleted, job.OrgID)
+		if event.before, err = store.SnapshotOrg(job.OrgID); err != nil {
+			return ran, err
+		}
+
+		status, message, audit := organizations.DeletionSucceeded, "", &event
+		if err := store.DeleteOrgInBatches(job.OrgID); err != nil {
+			status, message, audit = organizations.DeletionFailed, err.Error(), nil
+			log.Printf("organization deletion: job %d for organization %d failed: %v", job.ID, job.OrgID, err)
+		} else {
+			log.Printf("organization deletion: job %d deleted organization %d", job.ID, job.OrgID)
+		}
+
+		if err := store.FinishDeletionJob(ctx, job.ID, status, message, audit); err != nil {
+			return ran, err
+		}
+		ran++
+	}
+	return ran, ctx.Err()
+}
+
+// repoDeletionStore runs the deletion worker against the repository.
+type repoDeletionStore struct {
+	repo *organizations.Repository
+}
+
+func (s repoDeletionStore) ClaimDeletionJob(now time.Time) (*organizations.OrgDeletionJobModel, error) {
+	return s.repo.ClaimDeletionJob(now)
+}
+
+func (s repoDeletionStore) SnapshotOrg(orgID uint) (interface{}, error) {
+	return orgEvent(AuditOrgDeleted, orgID).load(s.repo, orgID)
+}
+
+func (s repoDeletionStore) DeleteOrgInBatches(orgID uint) error {
+	return s.repo.DeleteOrgInBatches(orgID)
+}
+
+func (s repoDeletionStore) FinishDeletionJob(ctx context.Context, jobID uint, status, message string, event *auditEvent) error {
+	return s.repo.Transaction(func(tx *organizations.Repository) error {
+		if err := tx.FinishDeletionJob(jobID, status, message, time.Now().UTC()); err != nil {
+			return err
+		}
+		if event == nil {
+			return nil
+		}
+		// The worker runs without an identity, so the system is the actor.
+		return appendAudit(ctx, tx, *event)
+	})
+}
+
+// StartDeletionWorker runs RunDeletionJobs every interval until ctx is
+// cancelled. Jobs left running by a previous process are queued again first.
+func (s *Service) StartDeletionWorker(ctx context.Context, interval time.Duration) {
+	go func() {
+		if err := s.repo.RequeueRunningDeletionJobs(); err != nil {
+			log.Printf("organization deletion: requeue failed: %v", err)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.RunDeletionJobs(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("organization deletion worker failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func toDeletionJobDTO(job organizations.OrgDeletionJobModel) DeletionJobDTO {
+	return DeletionJobDTO{
+		ID:          job.ID,
+		OrgID:       job.OrgID,
+		OrgName:     job.OrgName,
+		Status:      job.Status,
+		Error:       job.Error,
+		RequestedBy: job.RequestedBy,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+}
@@ -0,0 +1,114 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+// fakeDeletionStore serves queued jobs and records how each one finished.
+type fakeDeletionStore struct {
+	pending  []organizations.OrgDeletionJobModel
+	claimErr error
+	failOrgs map[uint]error
+	deleted  []uint
+	finished map[uint]finishedJob
+}
+
+type finishedJob struct {
+	status, message string
+	audited         bool
+}
+
+func (f *fakeDeletionStore) ClaimDeletionJob(now time.Time) (*organizations.OrgDeletionJobModel, error) {
+	if f.claimErr != nil {
+		return nil, f.claimErr
+	}
+	if len(f.pending) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	job := f.pending[0]
+	f.pending = f.pending[1:]
+	job.Status, job.StartedAt = organizations.DeletionRunning, &now
+	return &job, nil
+}
+
+func (f *fakeDeletionStore) SnapshotOrg(orgID uint) (interface{}, error) {
+	return orgSnapshot(organizations.OrganizationModel{ID: orgID}), nil
+}
+
+func (f *fakeDeletionStore) DeleteOrgInBatches(orgID uint) error {
+	if err := f.failOrgs[orgID]; err != nil {
+		return err
+	}
+	f.deleted = append(f.deleted, orgID)
+	return nil
+}
+
+func (f *fakeDeletionStore) FinishDeletionJob(_ context.Context, jobID uint, status, message string, event *auditEvent) error {
+	if f.finished == nil {
+		f.finished = map[uint]finishedJob{}
+	}
+	f.finished[jobID] = finishedJob{status: status, message: message, audited: event != nil}
+	return nil
+}
+
+func TestRunDeletionJobs(t *testing.T) {
+	store := &fakeDeletionStore{
+		pending: []organizations.OrgDeletionJobModel{
+			{ID: 1, OrgID: 10},
+			{ID: 2, OrgID: 20},
+			{ID: 3, OrgID: 30},
+		},
+		failOrgs: map[uint]error{20: errors.New("connection reset")},
+	}
+
+	ran, err := runDeletionJobs(context.Background(), store)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if ran != 3 {
+		t.Fatalf("ran = %d, want 3", ran)
+	}
+	if len(store.deleted) != 2 || store.deleted[0] != 10 || store.deleted[1] != 30 {
+		t.Fatalf("deleted = %v, want [10 30]", store.deleted)
+	}
+
+	want := map[uint]finishedJob{
+		1: {status: organizations.DeletionSucceeded, audited: true},
+		2: {status: organizations.DeletionFailed, message: "connection reset"},
+		3: {status: organizations.DeletionSucceeded, audited: true},
+	}
+	for jobID, job := range want {
+		if got := store.finished[jobID]; got != job {
+			t.Errorf("job %d finished as %+v, want %+v", jobID, got, job)
+		}
+	}
+}
+
+func TestRunDeletionJobs_ClaimError(t *testing.T) {
+	claimErr := errors.New("database is down")
+	store := &fakeDeletionStore{claimErr: claimErr}
+
+	if ran, err := runDeletionJobs(context.Background(), store); !errors.Is(err, claimErr) || ran != 0 {
+		t.Fatalf("run = %d, %v, want 0, %v", ran, err, claimErr)
+	}
+}
+
+func TestRunDeletionJobs_StopsWhenCancelled(t *testing.T) {
+	store := &fakeDeletionStore{pending: []organizations.OrgDeletionJobModel{{ID: 1, OrgID: 10}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if ran, err := runDeletionJobs(ctx, store); !errors.Is(err, context.Canceled) || ran != 0 {
+		t.Fatalf("run = %d, %v, want 0, context.Canceled", ran, err)
+	}
+	if len(store.pending) != 1 {
+		t.Fatalf("pending = %v, want the job left queued", store.pending)
+	}
+}
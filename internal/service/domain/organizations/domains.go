@@ -0,0 +1,272 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+// Domain ownership is proven by publishing the claim token in a TXT record
+// named "_users-crud-verification.<domain>".
+const (
+	domainRecordPrefix = "_users-crud-verification."
+	domainValuePrefix  = "users-crud-verification="
+)
+
+// publicEmailDomains are shared mailbox providers. No organization can own
+// them, since their users have nothing in common.
+var publicEmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"outlook.com":    true,
+	"hotmail.com":    true,
+	"live.com":       true,
+	"yahoo.com":      true,
+	"icloud.com":     true,
+	"me.com":         true,
+	"aol.com":        true,
+	"proton.me":      true,
+	"protonmail.com": true,
+	"uol.com.br":     true,
+	"bol.com.br":     true,
+}
+
+// DomainClaimDTO is an organization's claim on an email domain, with the DNS
+// record that verifies it.
+type DomainClaimDTO struct {
+	ID          uint
+	OrgID       uint
+	Domain      string
+	RecordName  string
+	RecordValue string
+	VerifiedAt  *time.Time
+	CreatedAt   time.Time
+}
+
+// ClaimDomain starts the claim of an email domain. The claim has no effect
+// until VerifyDomain finds its TXT record.
+func (s *Service) ClaimDomain(ctx context.Context, orgID uint, domain string) (*DomainClaimDTO, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if !isValidDomain(domain) {
+		return nil, ErrInvalidDomain
+	}
+	if publicEmailDomains[domain] {
+		return nil, ErrPublicEmailDomain
+	}
+
+	var claim *DomainClaimDTO
+	err := s.audited(ctx, domainEvent(AuditDomainClaimed, orgID, 0), func(tx *Service, event *auditEvent) (err error) {
+		claim, err = tx.claimDomain(orgID, domain)
+		if err == nil {
+			event.targetID = claim.ID
+		}
+		return err
+	})
+	return claim, err
+}
+
+func (s *Service) claimDomain(orgID uint, domain string) (*DomainClaimDTO, error) {
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+	if err := s.ensureDomainAvailable(domain, orgID); err != nil {
+		return nil, err
+	}
+
+	token, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	claim := &organizations.OrgDomainModel{OrgID: orgID, Domain: domain, Token: token}
+	if err := s.repo.CreateDomainClaim(claim); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDomainAlreadyClaimed
+		}
+		return nil, err
+	}
+
+	result := toDomainClaimDTO(*claim)
+	return &result, nil
+}
+
+func (s *Service) ListDomainClaims(ctx context.Context, orgID uint) ([]DomainClaimDTO, error) {
+	claims, err := s.repo.ListDomainClaims(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]DomainClaimDTO, 0, len(claims))
+	for _, claim := range claims {
+		dtos = append(dtos, toDomainClaimDTO(claim))
+	}
+	return dtos, nil
+}
+
+// VerifyDomain checks the TXT record of a claim and marks it verified. Only
+// one organization can own a domain; the first to verify it wins.
+func (s *Service) VerifyDomain(ctx context.Context, orgID, claimID uint) (*DomainClaimDTO, error) {
+	var claim *DomainClaimDTO
+	err := s.audited(ctx, domainEvent(AuditDomainVerified, orgID, claimID), func(tx *Service, _ *auditEvent) (err error) {
+		claim, err = tx.verifyDomain(ctx, orgID, claimID)
+		return err
+	})
+	return claim, err
+}
+
+func (s *Service) verifyDomain(ctx context.Context, orgID, claimID uint) (*DomainClaimDTO, error) {
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+
+	claim, err := s.repo.GetDomainClaim(orgID, claimID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDomainClaimNotFound
+		}
+		return nil, err
+	}
+	if claim.VerifiedAt != nil {
+		result := toDomainClaimDTO(*claim)
+		return &result, nil
+	}
+
+	if err := s.ensureDomainAvailable(claim.Domain, orgID); err != nil {
+		return nil, err
+	}
+	if err := verifyDomainRecord(ctx, s.verifier, claim); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := s.repo.MarkDomainVerified(claim.ID, now); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDomainTaken
+		}
+		return nil, err
+	}
+
+	claim.VerifiedAt = &now
+	result := toDomainClaimDTO(*claim)
+	return &result, nil
+}
+
+func (s *Service) RemoveDomainClaim(ctx context.Context, orgID, claimID uint) error {
+	return s.audited(ctx, domainEvent(AuditDomainRemoved, orgID, claimID), func(tx *Service, _ *auditEvent) error {
+		return tx.removeDomainClaim(orgID, claimID)
+	})
+}
+
+func (s *Service) removeDomainClaim(orgID, claimID uint) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteDomainClaim(orgID, claimID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDomainClaimNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// EmailVerified adds a user who just confirmed their email to the
+// organization that owns its domain, with the organization's default member
+// permission. It must not run before the email is verified: anyone can
+// create a user with any address. Failures are logged and never block the
+// verification.
+func (s *Service) EmailVerified(ctx context.Context, user service.UserDTO) {
+	if err := s.autoJoin(ctx, user); err != nil {
+		log.Printf("domain auto-join for user %d failed: %v", user.ID, err)
+	}
+}
+
+func (s *Service) autoJoin(ctx context.Context, user service.UserDTO) error {
+	domain := emailDomain(user.Email)
+	if domain == "" {
+		return nil
+	}
+
+	claim, err := s.repo.FindVerifiedDomain(domain)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	member, err := s.repo.IsOrgMember(claim.OrgID, user.ID)
+	if err != nil || member {
+		return err
+	}
+
+	settings, err := s.GetSettings(ctx, claim.OrgID)
+	if err != nil {
+		return err
+	}
+	permission := dto.PermissionType(settings.String(SettingDefaultMemberPermission))
+
+	if err := s.addMember(ctx, claim.OrgID, user.ID, permission); err != nil {
+		return err
+	}
+	log.Printf("domain auto-join: user %d added to organization %d as %s", user.ID, claim.OrgID, permission)
+	return nil
+}
+
+// ensureDomainAvailable fails when another organization owns the domain.
+func (s *Service) ensureDomainAvailable(domain string, orgID uint) error {
+	owner, err := s.repo.FindVerifiedDomain(domain)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if owner.OrgID != orgID {
+		return ErrDomainTaken
+	}
+	return nil
+}
+
+// verifyDomainRecord checks that the claim's TXT record is published.
+func verifyDomainRecord(ctx context.Context, verifier common.DomainVerifier, claim *organizations.OrgDomainModel) error {
+	found, err := verifier.HasTXTRecord(ctx, domainRecordPrefix+claim.Domain, domainValuePrefix+claim.Token)
+	if err != nil {
+		return fmt.Errorf("checking DNS for %s: %w", claim.Domain, err)
+	}
+	if !found {
+		return ErrDomainNotVerified
+	}
+	return nil
+}
+
+// emailDomain returns the lowercase domain of an email address, or "".
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+func toDomainClaimDTO(claim organizations.OrgDomainModel) DomainClaimDTO {
+	return DomainClaimDTO{
+		ID:          claim.ID,
+		OrgID:       claim.OrgID,
+		Domain:      claim.Domain,
+		RecordName:  domainRecordPrefix + claim.Domain,
+		RecordValue: domainValuePrefix + claim.Token,
+		VerifiedAt:  claim.VerifiedAt,
+		CreatedAt:   claim.CreatedAt,
+	}
+}
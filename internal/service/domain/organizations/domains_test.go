@@ -0,0 +1,77 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+type stubVerifier struct {
+	records map[string][]string
+	err     error
+}
+
+func (v stubVerifier) HasTXTRecord(ctx context.Context, name, value string) (bool, error) {
+	if v.err != nil {
+		return false, v.err
+	}
+	for _, record := range v.records[name] {
+		if record == value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestVerifyDomainRecord(t *testing.T) {
+	claim := &organizations.OrgDomainModel{Domain: "acme.com", Token: "abc123"}
+
+	published := stubVerifier{records: map[string][]string{
+		"_users-crud-verification.acme.com": {"v=spf1 -all", "users-crud-verification=abc123"},
+	}}
+	if err := verifyDomainRecord(context.Background(), published, claim); err != nil {
+		t.Fatalf("expected verification to succeed, got %v", err)
+	}
+
+	wrongToken := stubVerifier{records: map[string][]string{
+		"_users-crud-verification.acme.com": {"users-crud-verification=other"},
+	}}
+	if err := verifyDomainRecord(context.Background(), wrongToken, claim); !errors.Is(err, ErrDomainNotVerified) {
+		t.Fatalf("expected ErrDomainNotVerified, got %v", err)
+	}
+
+	dnsDown := errors.New("timeout")
+	if err := verifyDomainRecord(context.Background(), stubVerifier{err: dnsDown}, claim); !errors.Is(err, dnsDown) {
+		t.Fatalf("expected DNS error to be wrapped, got %v", err)
+	}
+}
+
+func TestClaimDomain_RejectsBeforeStorage(t *testing.T) {
+	s := &Service{}
+	cases := map[string]error{
+		"not a domain": ErrInvalidDomain,
+		"localhost":    ErrInvalidDomain,
+		"Gmail.com":    ErrPublicEmailDomain,
+	}
+	for domain, want := range cases {
+		if _, err := s.ClaimDomain(context.Background(), 1, domain); !errors.Is(err, want) {
+			t.Fatalf("ClaimDomain(%q) = %v, want %v", domain, err, want)
+		}
+	}
+}
+
+func TestEmailDomain(t *testing.T) {
+	cases := map[string]string{
+		"alice@Acme.com":    "acme.com",
+		"a@b@mail.acme.com": "mail.acme.com",
+		"no-at-sign":        "",
+		"trailing@":         "",
+	}
+	for email, want := range cases {
+		if got := emailDomain(email); got != want {
+			t.Fatalf("emailDomain(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package organizations
+
+import "errors"
+
+var (
+	ErrOrgArchived             = errors.New("organization is archived and read-only")
+	ErrOrgNotArchived          = errors.New("organization is not archived")
+	ErrHierarchyCycle          = errors.New("organization cannot be placed below itself or its descendants")
+	ErrHierarchyTooDeep        = errors.New("organization hierarchy would be too deep")
+	ErrParentNotFound          = errors.New("parent organization not found")
+	ErrTeamNotFound            = errors.New("team not found")
+	ErrInvalidPermission       = errors.New("invalid permission type")
+	ErrRoleNotFound            = errors.New("role not found")
+	ErrRoleInUse               = errors.New("role is assigned to members")
+	ErrSystemRole              = errors.New("system roles cannot be modified")
+	ErrUnknownSetting          = errors.New("unknown setting")
+	ErrInvalidSetting          = errors.New("invalid setting value")
+	ErrQuotaExceeded           = errors.New("organization quota exceeded")
+	ErrUnknownPlan             = errors.New("unknown plan")
+	ErrJoinRequestsDisabled    = errors.New("organization does not accept join requests")
+	ErrEmailDomainNotAllowed   = errors.New("email domain is not allowed by the organization")
+	ErrJoinRequestPending      = errors.New("a pending join request already exists")
+	ErrJoinRequestNotFound     = errors.New("join request not found")
+	ErrInvalidExpiry           = errors.New("expiry must be in the future")
+	ErrInvalidExpiringWindow   = errors.New("expiring window must be between 1 and 90 days")
+	ErrInvalidDomain           = errors.New("invalid domain")
+	ErrPublicEmailDomain       = errors.New("public email domains cannot be claimed")
+	ErrDomainAlreadyClaimed    = errors.New("organization already claimed this domain")
+	ErrDomainTaken             = errors.New("domain is owned by another organization")
+	ErrDomainClaimNotFound     = errors.New("domain claim not found")
+	ErrDomainNotVerified       = errors.New("verification TXT record not found")
+	ErrSSONotConfigured        = errors.New("single sign-on is not configured for this organization")
+	ErrInvalidSSOConfig        = errors.New("invalid single sign-on configuration")
+	ErrSSOLoginExpired         = errors.New("sign-in request is invalid or expired")
+	ErrSSOStateMismatch        = errors.New("sign-in was not started in this browser")
+	ErrSSOEmailNotVerified     = errors.New("identity provider did not verify the email address")
+	ErrSSODomainNotOwned       = errors.New("email domain is not verified by the organization")
+	ErrSSOAccessDenied         = errors.New("identity provider groups grant no access to the organization")
+	ErrBulkRejected            = errors.New("bulk operation rejected")
+	ErrBulkDuplicateUser       = errors.New("user appears in more than one operation")
+	ErrNotMember               = errors.New("user is not a member of the organization")
+	ErrLastRoot                = errors.New("organization must keep at least one ROOT member")
+	ErrDeletionInProgress      = errors.New("organization deletion is already in progress")
+	ErrDeletionJobNotFound     = errors.New("deletion job not found")
+	ErrForbidden               = errors.New("insufficient permissions")
+	ErrCapabilityNotHeld       = errors.New("cannot grant or change capabilities you do not hold")
+	ErrTwoFactorRequired       = errors.New("organization requires two-factor authentication for this operation")
+	ErrAlreadyMember           = errors.New("user is already a member of the organization")
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvitationInvalid       = errors.New("invalid invitation token")
+	ErrInvitationExpired       = errors.New("invitation has expired")
+	ErrInvitationPending       = errors.New("a pending invitation already exists for this email")
+	ErrInvitationEmailMismatch = errors.New("invitation was issued to a different email")
+	ErrInvitationResendLimit   = errors.New("invitation resend limit reached")
+	ErrInvitationResendTooSoon = errors.New("invitation was sent too recently")
+)
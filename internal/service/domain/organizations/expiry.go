@@ -0,0 +1,180 @@
+package organizations
+
+import (
+	"context"
+	"log"
+	"maps"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxExpiringWindow = 90 * 24 * time.Hour
+	expirySweepBatch  = 100
+)
+
+// SetMembershipExpiry extends, shortens or, with a nil expiresAt, removes the
+// expiry of a membership. actorID cannot change a member who holds
+// capabilities it does not hold, so only ROOT members set the expiry of
+// another ROOT member, and a ROOT membership may only expire while the
+// organization keeps a permanent ROOT member.
+func (s *Service) SetMembershipExpiry(ctx context.Context, orgID, actorID, userID uint, expiresAt *time.Time) error {
+	if err := validateExpiry(expiresAt); err != nil {
+		return err
+	}
+	return s.audited(ctx, memberEvent(AuditMemberExpiryChanged, orgID, userID), func(tx *Service, _ *auditEvent) error {
+		if err := tx.ensureWritable(orgID); err != nil {
+			return err
+		}
+		if err := tx.ensureCanChange(ctx, orgID, actorID, userID); err != nil {
+			return err
+		}
+		return tx.repo.LockOrg(orgID, func(repo *organizations.Repository, _ *organizations.OrganizationModel) error {
+			// Expired memberships the sweeper has not processed yet may be
+			// extended too.
+			current, err := loadMemberStates(repo, orgID)
+			if err != nil {
+				return err
+			}
+			state, ok := current[userID]
+			if !ok {
+				return gorm.ErrRecordNotFound
+			}
+
+			final := maps.Clone(current)
+			state.active, state.permanent = true, expiresAt == nil
+			final[userID] = state
+			if !keepsRoot(current, final) {
+				return ErrLastRoot
+			}
+			return repo.SetMembershipExpiry(orgID, userID, expiresAt)
+		})
+	})
+}
+
+// ListExpiringMembers returns the members whose access ends within the given
+// window, soonest first.
+func (s *Service) ListExpiringMembers(ctx context.Context, orgID uint, within time.Duration) ([]OrgUserDTO, error) {
+	if within <= 0 || within > maxExpiringWindow {
+		return nil, ErrInvalidExpiringWindow
+	}
+
+	memberships, err := s.repo.ListExpiringMemberships(orgID, time.Now().Add(within))
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]OrgUserDTO, 0, len(memberships))
+	for _, membership := range memberships {
+		dtos = append(dtos, toOrgUserDTO(membership))
+	}
+	return dtos, nil
+}
+
+// SweepExpiredMemberships applies each organization's expiry action to the
+// memberships that have expired and returns how many it processed.
+// Permission checks already ignore expired memberships, so the sweep only
+// cleans up; it does not need to run promptly.
+func (s *Service) SweepExpiredMemberships(ctx context.Context) (int, error) {
+	now := time.Now()
+	processed := 0
+	for {
+		memberships, err := s.repo.ListExpiredMemberships(now, expirySweepBatch)
+		if err != nil {
+			return processed, err
+		}
+
+		batchProcessed := 0
+		actions := map[uint]string{}
+		for _, membership := range memberships {
+			action, ok := actions[membership.OrgID]
+			if !ok {
+				settings, err := s.GetSettings(ctx, membership.OrgID)
+				if err != nil {
+					return processed, err
+				}
+				action = settings.String(SettingMembershipExpiryAction)
+				actions[membership.OrgID] = action
+			}
+
+			expired, err := s.expireMembership(ctx, membership, action, now)
+			if err != nil {
+				return processed, err
+			}
+			if expired {
+				batchProcessed++
+				log.Printf("membership expiry: %s user %d in organization %d", action, membership.UserID, membership.OrgID)
+			}
+		}
+		processed += batchProcessed
+
+		// Skipped memberships stay in the list, so a batch made only of them
+		// would be fetched again forever.
+		if len(memberships) < expirySweepBatch || batchProcessed == 0 {
+			return processed, nil
+		}
+	}
+}
+
+// expireMembership applies action to an expired membership and records the
+// change, made by the system, in the audit log. The membership of the last
+// ROOT member is kept, and reported, so the organization can be recovered by
+// extending it.
+func (s *Service) expireMembership(ctx context.Context, membership organizations.OrgUserModel, action string, now time.Time) (bool, error) {
+	expired := false
+	err := s.repo.LockOrg(membership.OrgID, func(tx *organizations.Repository, _ *organizations.OrganizationModel) error {
+		states, err := loadMemberStates(tx, membership.OrgID)
+		if err != nil {
+			return err
+		}
+		if isLastRoot(states, membership.UserID) {
+			log.Printf("membership expiry: WARNING keeping expired membership of user %d, the last ROOT member of organization %d; extend it to restore access", membership.UserID, membership.OrgID)
+			return nil
+		}
+
+		expired, err = tx.ExpireMembership(membership, action, now)
+		if err != nil || !expired {
+			return err
+		}
+
+		event := memberEvent(AuditMemberExpired, membership.OrgID, membership.UserID)
+		event.before = memberSnapshot(membership)
+		if action == organizations.ExpiryActionDowngrade {
+			if event.after, err = event.load(tx, membership.UserID); err != nil {
+				return err
+			}
+		}
+		return appendAudit(ctx, tx, event)
+	})
+	return expired, err
+}
+
+// StartExpirySweeper runs SweepExpiredMemberships every interval until ctx is
+// cancelled.
+func (s *Service) StartExpirySweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.SweepExpiredMemberships(ctx); err != nil {
+				log.Printf("membership expiry sweep failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func validateExpiry(expiresAt *time.Time) error {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return ErrInvalidExpiry
+	}
+	return nil
+}
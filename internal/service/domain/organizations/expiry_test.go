@@ -0,0 +1,128 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func TestValidateExpiry(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	if err := validateExpiry(nil); err != nil {
+		t.Fatalf("permanent membership should be valid, got %v", err)
+	}
+	if err := validateExpiry(&future); err != nil {
+		t.Fatalf("future expiry should be valid, got %v", err)
+	}
+	if err := validateExpiry(&past); !errors.Is(err, ErrInvalidExpiry) {
+		t.Fatalf("past expiry should be rejected, got %v", err)
+	}
+}
+
+func TestSetMembershipExpiry_KeepsPermanentRoot(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+	later := time.Now().Add(time.Hour)
+
+	if err := svc.SetMembershipExpiry(ctx, orgID, 1, 1, &later); !errors.Is(err, ErrLastRoot) {
+		t.Fatalf("expire the only ROOT = %v, want ErrLastRoot", err)
+	}
+
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 2, dto.PermissionRoot, nil, nil); err != nil {
+		t.Fatalf("add second ROOT: %v", err)
+	}
+	if err := svc.SetMembershipExpiry(ctx, orgID, 1, 2, &later); err != nil {
+		t.Fatalf("expire a ROOT while another stays: %v", err)
+	}
+	if err := svc.SetMembershipExpiry(ctx, orgID, 2, 1, &later); !errors.Is(err, ErrLastRoot) {
+		t.Fatalf("expire the last permanent ROOT = %v, want ErrLastRoot", err)
+	}
+	if err := svc.SetMembershipExpiry(ctx, orgID, 1, 2, nil); err != nil {
+		t.Fatalf("make a ROOT permanent again: %v", err)
+	}
+}
+
+func TestSetMembershipExpiry_RequiresCapabilitiesOfTheMember(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+	later := time.Now().Add(time.Hour)
+
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 2, dto.PermissionRoot, nil, nil); err != nil {
+		t.Fatalf("add ROOT: %v", err)
+	}
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 3, dto.PermissionWrite, nil, nil); err != nil {
+		t.Fatalf("add writer: %v", err)
+	}
+
+	if err := svc.SetMembershipExpiry(ctx, orgID, 3, 2, &later); !errors.Is(err, ErrCapabilityNotHeld) {
+		t.Fatalf("writer sets a ROOT expiry = %v, want ErrCapabilityNotHeld", err)
+	}
+}
+
+func TestSweepExpiredMemberships(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	later := time.Now().Add(time.Hour)
+	for _, member := range []struct {
+		userID     uint
+		permission dto.PermissionType
+	}{{2, dto.PermissionRoot}, {3, dto.PermissionRead}} {
+		if err := svc.AddUserToOrg(ctx, orgID, 1, member.userID, member.permission, nil, &later); err != nil {
+			t.Fatalf("add %d: %v", member.userID, err)
+		}
+	}
+	// Expiries in the past cannot be set through the service.
+	past := time.Now().Add(-time.Minute)
+	for _, userID := range []uint{2, 3} {
+		if err := svc.repo.SetMembershipExpiry(orgID, userID, &past); err != nil {
+			t.Fatalf("expire %d: %v", userID, err)
+		}
+	}
+
+	processed, err := svc.SweepExpiredMemberships(ctx)
+	if err != nil || processed != 2 {
+		t.Fatalf("sweep = %d, %v, want 2", processed, err)
+	}
+	for _, userID := range []uint{2, 3} {
+		if member, _ := svc.repo.IsOrgMember(orgID, userID); member {
+			t.Fatalf("expired member %d was kept", userID)
+		}
+	}
+}
+
+func TestSweepExpiredMemberships_KeepsLastRoot(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	// An organization left with a single expiring ROOT, as allowed before
+	// permanent ROOT members were required.
+	past := time.Now().Add(-time.Minute)
+	if err := svc.repo.SetMembershipExpiry(orgID, 1, &past); err != nil {
+		t.Fatalf("expire ROOT: %v", err)
+	}
+
+	processed, err := svc.SweepExpiredMemberships(ctx)
+	if err != nil || processed != 0 {
+		t.Fatalf("sweep = %d, %v, want 0", processed, err)
+	}
+	if member, _ := svc.repo.IsOrgMember(orgID, 1); !member {
+		t.Fatal("the last ROOT membership was removed")
+	}
+
+	if err := svc.SetMembershipExpiry(ctx, orgID, 1, 1, nil); err != nil {
+		t.Fatalf("restore the last ROOT: %v", err)
+	}
+	if permission, err := svc.repo.GetUserPermissionInOrg(orgID, 1); err != nil || permission != dto.PermissionRoot {
+		t.Fatalf("restored membership = %v, %v", permission, err)
+	}
+}
@@ -0,0 +1,126 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"meu-treino-golang/users-crud/dto"
+
+	"gorm.io/gorm"
+)
+
+// heldCapabilities returns every capability actorID may use in the
+// organization: its own, those of ROOT when it inherits ROOT from a parent
+// organization and, for platform admins, the admin allowlist.
+func (s *Service) heldCapabilities(ctx context.Context, orgID, actorID uint) ([]Capability, error) {
+	held, err := s.memberCapabilities(orgID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	inherited, err := s.inheritsRoot(orgID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if inherited {
+		held = append(held, CapabilitiesFor(dto.PermissionRoot)...)
+	}
+
+	admin, err := s.IsPlatformAdmin(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if admin {
+		held = append(held, platformAdminCapabilities...)
+	}
+	return held, nil
+}
+
+// grantedCapabilities returns what a membership with permission and roleID
+// allows. The pair must have gone through resolveMemberRole.
+func (s *Service) grantedCapabilities(permission dto.PermissionType, roleID *uint) ([]Capability, error) {
+	if roleID == nil {
+		return CapabilitiesFor(permission), nil
+	}
+
+	role, err := s.repo.GetRole(*roleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return parseCapabilities(role.CapabilityList()), nil
+}
+
+// holdsAll reports whether held contains every capability in wanted.
+func holdsAll(held, wanted []Capability) bool {
+	for _, capability := range wanted {
+		if !slices.Contains(held, capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureCanGrant refuses to let actorID give a membership with permission
+// and roleID when that membership would allow something actorID cannot do
+// itself.
+func (s *Service) ensureCanGrant(ctx context.Context, orgID, actorID uint, permission dto.PermissionType, roleID *uint) error {
+	held, err := s.heldCapabilities(ctx, orgID, actorID)
+	if err != nil {
+		return err
+	}
+	return s.checkGrantRequest(held, orgID, permission, roleID)
+}
+
+// ensureCanChange refuses to let actorID change or remove the membership of
+// userID when it allows something actorID cannot do itself. Users who are
+// not members pass.
+func (s *Service) ensureCanChange(ctx context.Context, orgID, actorID, userID uint) error {
+	held, err := s.heldCapabilities(ctx, orgID, actorID)
+	if err != nil {
+		return err
+	}
+	return s.checkChange(held, orgID, userID)
+}
+
+// checkGrantRequest resolves the requested permission and role before
+// checking them with checkGrant.
+func (s *Service) checkGrantRequest(held []Capability, orgID uint, permission dto.PermissionType, roleID *uint) error {
+	permission, roleID, err := s.resolveMemberRole(orgID, permission, roleID)
+	if err != nil {
+		return err
+	}
+	return s.checkGrant(held, permission, roleID)
+}
+
+// checkGrant fails with ErrCapabilityNotHeld when a membership with the
+// resolved permission and roleID allows more than held.
+func (s *Service) checkGrant(held []Capability, permission dto.PermissionType, roleID *uint) error {
+	granted, err := s.grantedCapabilities(permission, roleID)
+	if err != nil {
+		return err
+	}
+	if !holdsAll(held, granted) {
+		return ErrCapabilityNotHeld
+	}
+	return nil
+}
+
+// checkChange fails with ErrCapabilityNotHeld when the membership of userID
+// allows more than held.
+func (s *Service) checkChange(held []Capability, orgID, userID uint) error {
+	membership, err := s.repo.GetMembership(orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !holdsAll(held, membershipCapabilities(*membership)) {
+		return ErrCapabilityNotHeld
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package organizations
+
+import (
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func TestHoldsAll(t *testing.T) {
+	memberManager := []Capability{CapMembersRead, CapMembersAdd, CapMembersUpdateRole}
+
+	cases := []struct {
+		name    string
+		held    []Capability
+		granted []Capability
+		want    bool
+	}{
+		{"ROOT grants ROOT", CapabilitiesFor(dto.PermissionRoot), CapabilitiesFor(dto.PermissionRoot), true},
+		{"ROOT grants READ", CapabilitiesFor(dto.PermissionRoot), CapabilitiesFor(dto.PermissionRead), true},
+		{"WRITE grants ROOT", CapabilitiesFor(dto.PermissionWrite), CapabilitiesFor(dto.PermissionRoot), false},
+		{"member manager grants READ", memberManager, CapabilitiesFor(dto.PermissionRead), true},
+		{"member manager grants WRITE", memberManager, CapabilitiesFor(dto.PermissionWrite), false},
+		{"member manager grants its own role", memberManager, memberManager, true},
+		{"platform admin grants WRITE", platformAdminCapabilities, CapabilitiesFor(dto.PermissionWrite), true},
+		{"platform admin grants ROOT", platformAdminCapabilities, CapabilitiesFor(dto.PermissionRoot), false},
+		{"nothing held", nil, CapabilitiesFor(dto.PermissionRead), false},
+	}
+
+	for _, tc := range cases {
+		if got := holdsAll(tc.held, tc.granted); got != tc.want {
+			t.Errorf("%s: holdsAll = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
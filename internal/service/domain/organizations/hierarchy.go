@@ -0,0 +1,177 @@
+package organizations
+
+import (
+	"context"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+// OrgNodeDTO is an organization found while walking the hierarchy.
+type OrgNodeDTO struct {
+	ID                  uint
+	Name                string
+	Slug                string
+	ParentID            *uint
+	InheritParentAccess bool
+	Depth               int
+}
+
+// MaxHierarchyDepth is how many levels an organization may sit below a
+// top-level organization.
+const MaxHierarchyDepth = 16
+
+// SetParent places an organization under parentID, or detaches it when
+// parentID is nil. Moving an organization below one of its own descendants,
+// or so deep that its subtree passes MaxHierarchyDepth, is rejected.
+func (s *Service) SetParent(ctx context.Context, orgID uint, parentID *uint, inheritParentAccess bool) error {
+	return s.audited(ctx, orgEvent(AuditOrgParentChanged, orgID), func(tx *Service, _ *auditEvent) error {
+		return tx.setParent(orgID, parentID, inheritParentAccess)
+	})
+}
+
+func (s *Service) setParent(orgID uint, parentID *uint, inheritParentAccess bool) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		if *parentID == orgID {
+			return ErrHierarchyCycle
+		}
+		if err := s.repo.LockHierarchy(orgID, *parentID); err != nil {
+			return err
+		}
+
+		parentChain, err := s.repo.GetOrgChain(*parentID)
+		if err != nil {
+			return err
+		}
+		if len(parentChain) == 0 {
+			return ErrParentNotFound
+		}
+
+		descendants, err := s.repo.GetSubtree(orgID)
+		if err != nil {
+			return err
+		}
+		if err := checkPlacement(orgID, parentChain, subtreeHeight(descendants)); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.SetParent(orgID, parentID, inheritParentAccess)
+}
+
+// SetInheritParentAccess turns on or off the flow of ROOT access from the
+// parent organization without moving the organization.
+func (s *Service) SetInheritParentAccess(ctx context.Context, orgID uint, inheritParentAccess bool) error {
+	return s.audited(ctx, orgEvent(AuditOrgInheritanceChanged, orgID), func(tx *Service, _ *auditEvent) error {
+		if err := tx.ensureWritable(orgID); err != nil {
+			return err
+		}
+		return tx.repo.SetInheritParentAccess(orgID, inheritParentAccess)
+	})
+}
+
+// checkPlacement validates moving orgID, whose descendants reach height
+// levels below it, under the parent whose chain (the parent first, then its
+// ancestors) is parentChain.
+func checkPlacement(orgID uint, parentChain []organizations.OrgTreeNode, height int) error {
+	for _, node := range parentChain {
+		if node.ID == orgID {
+			return ErrHierarchyCycle
+		}
+	}
+	if len(parentChain)+height > MaxHierarchyDepth {
+		return ErrHierarchyTooDeep
+	}
+	return nil
+}
+
+// subtreeHeight returns how many levels the descendants reach below their
+// root.
+func subtreeHeight(descendants []organizations.OrgTreeNode) int {
+	height := 0
+	for _, node := range descendants {
+		height = max(height, node.Depth)
+	}
+	return height
+}
+
+// ListAncestors returns the parents of an organization, nearest first.
+func (s *Service) ListAncestors(ctx context.Context, orgID uint) ([]OrgNodeDTO, error) {
+	chain, err := s.repo.GetOrgChain(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if len(chain) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return toOrgNodeDTOs(chain[1:]), nil
+}
+
+// ListSubtree returns every descendant of an organization.
+func (s *Service) ListSubtree(ctx context.Context, orgID uint) ([]OrgNodeDTO, error) {
+	if _, err := s.repo.GetOrg(orgID); err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.repo.GetSubtree(orgID)
+	if err != nil {
+		return nil, err
+	}
+	return toOrgNodeDTOs(nodes), nil
+}
+
+// inheritsRoot reports whether a user is ROOT in an ancestor whose access
+// flows down to orgID. Access only flows through organizations that have
+// InheritParentAccess set, so a child can cut itself off from its parents.
+func (s *Service) inheritsRoot(orgID, userID uint) (bool, error) {
+	chain, err := s.repo.GetOrgChain(orgID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, ancestorID := range inheritedAncestors(chain) {
+		permission, err := s.localPermission(ancestorID, userID)
+		if err != nil {
+			return false, err
+		}
+		if permission == dto.PermissionRoot {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inheritedAncestors returns the ancestors in chain, nearest first, whose
+// ROOT access flows down to chain[0]: the walk stops at the first
+// organization that does not inherit from its parent.
+func inheritedAncestors(chain []organizations.OrgTreeNode) []uint {
+	var ancestors []uint
+	for i := 1; i < len(chain); i++ {
+		if !chain[i-1].InheritParentAccess {
+			break
+		}
+		ancestors = append(ancestors, chain[i].ID)
+	}
+	return ancestors
+}
+
+func toOrgNodeDTOs(nodes []organizations.OrgTreeNode) []OrgNodeDTO {
+	dtos := make([]OrgNodeDTO, 0, len(nodes))
+	for _, node := range nodes {
+		dtos = append(dtos, OrgNodeDTO{
+			ID:                  node.ID,
+			Name:                node.Name,
+			Slug:                node.Slug,
+			ParentID:            node.ParentID,
+			InheritParentAccess: node.InheritParentAccess,
+			Depth:               node.Depth,
+		})
+	}
+	return dtos
+}
@@ -0,0 +1,134 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+// chainOf builds an organization chain, the organization first, where
+// inherits[i] is the InheritParentAccess flag of ids[i].
+func chainOf(ids []uint, inherits []bool) []organizations.OrgTreeNode {
+	chain := make([]organizations.OrgTreeNode, len(ids))
+	for i, id := range ids {
+		chain[i] = organizations.OrgTreeNode{ID: id, InheritParentAccess: inherits[i], Depth: i}
+	}
+	return chain
+}
+
+func TestCheckPlacement(t *testing.T) {
+	deepChain := make([]organizations.OrgTreeNode, MaxHierarchyDepth+1)
+	for i := range deepChain {
+		deepChain[i] = organizations.OrgTreeNode{ID: uint(100 + i), Depth: i}
+	}
+
+	cases := []struct {
+		name        string
+		parentChain []organizations.OrgTreeNode
+		height      int
+		want        error
+	}{
+		{"top-level parent", chainOf([]uint{2}, []bool{false}), 0, nil},
+		{"under its own child", chainOf([]uint{2, 1}, []bool{true, false}), 1, ErrHierarchyCycle},
+		{"under a deeper descendant", chainOf([]uint{4, 3, 2, 1}, []bool{true, true, true, false}), 3, ErrHierarchyCycle},
+		{"deepest allowed leaf", deepChain[1:], 0, nil},
+		{"leaf one level too deep", deepChain, 0, ErrHierarchyTooDeep},
+		{"subtree pushed too deep", deepChain[2:], 2, ErrHierarchyTooDeep},
+		{"subtree that still fits", deepChain[2:], 1, nil},
+	}
+
+	for _, tc := range cases {
+		if err := checkPlacement(1, tc.parentChain, tc.height); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestSubtreeHeight(t *testing.T) {
+	if got := subtreeHeight(nil); got != 0 {
+		t.Fatalf("leaf height = %d, want 0", got)
+	}
+
+	descendants := []organizations.OrgTreeNode{{ID: 2, Depth: 1}, {ID: 3, Depth: 1}, {ID: 4, Depth: 2}}
+	if got := subtreeHeight(descendants); got != 2 {
+		t.Fatalf("height = %d, want 2", got)
+	}
+}
+
+func TestInheritedAncestors(t *testing.T) {
+	cases := []struct {
+		name  string
+		chain []organizations.OrgTreeNode
+		want  []uint
+	}{
+		{"top-level", chainOf([]uint{1}, []bool{true}), nil},
+		{"inherits from every ancestor", chainOf([]uint{1, 2, 3}, []bool{true, true, false}), []uint{2, 3}},
+		{"cut off from its parent", chainOf([]uint{1, 2, 3}, []bool{false, true, false}), nil},
+		{"parent cut off from grandparent", chainOf([]uint{1, 2, 3}, []bool{true, false, false}), []uint{2}},
+	}
+
+	for _, tc := range cases {
+		got := inheritedAncestors(tc.chain)
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: ancestors = %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: ancestors = %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSetParent_RejectsCycles(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}})
+	top := mustCreateOrg(t, svc, "top", 1)
+	middle := mustCreateOrg(t, svc, "middle", 1)
+	bottom := mustCreateOrg(t, svc, "bottom", 1)
+
+	if err := svc.SetParent(ctx, middle, &top, true); err != nil {
+		t.Fatalf("middle under top: %v", err)
+	}
+	if err := svc.SetParent(ctx, bottom, &middle, true); err != nil {
+		t.Fatalf("bottom under middle: %v", err)
+	}
+
+	for _, parent := range []uint{top, middle, bottom} {
+		if err := svc.SetParent(ctx, top, &parent, true); !errors.Is(err, ErrHierarchyCycle) {
+			t.Errorf("top under %d: err = %v, want ErrHierarchyCycle", parent, err)
+		}
+	}
+}
+
+func TestSetInheritParentAccess_KeepsParent(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}})
+	parent := mustCreateOrg(t, svc, "parent", 2)
+	child := mustCreateOrg(t, svc, "child", 1)
+
+	if err := svc.SetParent(ctx, child, &parent, true); err != nil {
+		t.Fatalf("set parent: %v", err)
+	}
+	if inherited, err := svc.inheritsRoot(child, 2); err != nil || !inherited {
+		t.Fatalf("inheritsRoot = %v, %v; want true", inherited, err)
+	}
+
+	if err := svc.SetInheritParentAccess(ctx, child, false); err != nil {
+		t.Fatalf("turn inheritance off: %v", err)
+	}
+	org, err := svc.GetOrg(ctx, child)
+	if err != nil {
+		t.Fatalf("get org: %v", err)
+	}
+	if org.ParentID == nil || *org.ParentID != parent || org.InheritParentAccess {
+		t.Fatalf("org = %+v, want parent %d without inheritance", org, parent)
+	}
+	if inherited, err := svc.inheritsRoot(child, 2); err != nil || inherited {
+		t.Fatalf("inheritsRoot = %v, %v; want false", inherited, err)
+	}
+}
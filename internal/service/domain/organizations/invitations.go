@@ -0,0 +1,399 @@
+package organizations
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+const (
+	invitationTTL          = 7 * 24 * time.Hour
+	invitationResendWindow = time.Minute
+	invitationMaxSends     = 5
+)
+
+type InvitationDTO struct {
+	ID         uint
+	OrgID      uint
+	Email      string
+	Permission dto.PermissionType
+	Status     string
+	InvitedBy  uint
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
+
+// InviteUser creates a pending invitation for an email address and mails a
+// signed token to it. The email does not need to belong to an existing user.
+// The mail goes out once the invitation is committed; if sending fails, the
+// invitation stays pending and can be resent.
+func (s *Service) InviteUser(ctx context.Context, orgID, inviterID uint, email string, permission dto.PermissionType) (*InvitationDTO, error) {
+	var invitation *organizations.InvitationModel
+	err := s.audited(ctx, invitationEvent(AuditInvitationCreated, orgID, 0), func(tx *Service, event *auditEvent) (err error) {
+		invitation, err = tx.inviteUser(ctx, orgID, inviterID, email, permission)
+		if err == nil {
+			event.targetID = invitation.ID
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sendInvitation(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("invitation %d was created but not sent: %w", invitation.ID, err)
+	}
+	result := toInvitationDTO(*invitation)
+	return &result, nil
+}
+
+func (s *Service) inviteUser(ctx context.Context, orgID, inviterID uint, email string, permission dto.PermissionType) (*organizations.InvitationModel, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, errors.New("email cannot be empty")
+	}
+	if !isValidPermission(permission) {
+		return nil, ErrInvalidPermission
+	}
+
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+	if err := s.ensureCanGrant(ctx, orgID, inviterID, permission, nil); err != nil {
+		return nil, err
+	}
+	if err := s.ensureEmailAllowed(ctx, orgID, email); err != nil {
+		return nil, err
+	}
+
+	if user, err := s.users.GetByEmail(ctx, email); err == nil && user != nil {
+		member, err := s.repo.IsOrgMember(orgID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			return nil, ErrAlreadyMember
+		}
+	} else if err != nil && !errors.Is(err, common.ErrUserNotFound) {
+		return nil, err
+	}
+
+	if _, err := s.repo.FindPendingInvitation(orgID, email); err == nil {
+		return nil, ErrInvitationPending
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	invitation := &organizations.InvitationModel{
+		OrgID:      orgID,
+		Email:      email,
+		Permission: string(permission),
+		Status:     organizations.InvitationPending,
+		Nonce:      nonce,
+		InvitedBy:  inviterID,
+		ExpiresAt:  now.Add(invitationTTL),
+		LastSentAt: now,
+		SendCount:  1,
+	}
+	if err := s.repo.CreateInvitation(invitation); err != nil {
+		// A concurrent invitation for the same address won the race.
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrInvitationPending
+		}
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (s *Service) ListPendingInvitations(ctx context.Context, orgID uint) ([]InvitationDTO, error) {
+	invitations, err := s.repo.ListPendingInvitations(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]InvitationDTO, 0, len(invitations))
+	for _, invitation := range invitations {
+		dtos = append(dtos, toInvitationDTO(invitation))
+	}
+	return dtos, nil
+}
+
+// RevokeInvitation cancels a pending invitation so its token can no longer
+// be used.
+func (s *Service) RevokeInvitation(ctx context.Context, orgID, invitationID uint) error {
+	return s.audited(ctx, invitationEvent(AuditInvitationRevoked, orgID, invitationID), func(tx *Service, _ *auditEvent) error {
+		return tx.revokeInvitation(orgID, invitationID)
+	})
+}
+
+func (s *Service) revokeInvitation(orgID, invitationID uint) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	if _, err := s.pendingInvitation(orgID, invitationID); err != nil {
+		return err
+	}
+	return s.repo.UpdateInvitationStatus(invitationID, organizations.InvitationRevoked)
+}
+
+// ResendInvitation mails a fresh token for a pending invitation and extends
+// its expiry. Resends are limited per invitation and spaced out in time.
+// The mail goes out once the new token is committed.
+func (s *Service) ResendInvitation(ctx context.Context, orgID, invitationID uint) error {
+	var invitation *organizations.InvitationModel
+	err := s.audited(ctx, invitationEvent(AuditInvitationResent, orgID, invitationID), func(tx *Service, _ *auditEvent) (err error) {
+		invitation, err = tx.resendInvitation(orgID, invitationID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return s.sendInvitation(ctx, invitation)
+}
+
+func (s *Service) resendInvitation(orgID, invitationID uint) (*organizations.InvitationModel, error) {
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.pendingInvitation(orgID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if invitation.SendCount >= invitationMaxSends {
+		return nil, ErrInvitationResendLimit
+	}
+	if now.Sub(invitation.LastSentAt) < invitationResendWindow {
+		return nil, ErrInvitationResendTooSoon
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := now.Add(invitationTTL)
+	if err := s.repo.RecordInvitationResend(invitation.ID, nonce, expiresAt, now); err != nil {
+		return nil, err
+	}
+
+	invitation.Nonce = nonce
+	invitation.ExpiresAt = expiresAt
+	return invitation, nil
+}
+
+// AcceptInvitation redeems a token on behalf of userID, whose email must
+// match the invited address, and creates the membership.
+func (s *Service) AcceptInvitation(ctx context.Context, token string, userID uint) (*InvitationDTO, error) {
+	var invitation *InvitationDTO
+	err := s.audited(ctx, invitationEvent(AuditInvitationAccepted, 0, 0), func(tx *Service, event *auditEvent) (err error) {
+		invitation, err = tx.acceptInvitation(ctx, token, userID)
+		if err == nil {
+			event.orgID, event.targetID = invitation.OrgID, invitation.ID
+		}
+		return err
+	})
+	return invitation, err
+}
+
+func (s *Service) acceptInvitation(ctx context.Context, token string, userID uint) (*InvitationDTO, error) {
+	invitation, err := s.invitationFromToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+	if err := s.ensureWritable(invitation.OrgID); err != nil {
+		return nil, err
+	}
+	// The allowed domains may have changed since the invitation was sent.
+	if err := s.ensureEmailAllowed(ctx, invitation.OrgID, user.Email); err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.IsOrgMember(invitation.OrgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member {
+		return nil, ErrAlreadyMember
+	}
+
+	err = s.withinQuota(invitation.OrgID, func(tx *organizations.Repository) error {
+		return tx.AcceptInvitation(invitation.ID, userID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrInvitationNotFound
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			return nil, ErrAlreadyMember
+		}
+		return nil, err
+	}
+
+	invitation.Status = organizations.InvitationAccepted
+	result := toInvitationDTO(*invitation)
+	return &result, nil
+}
+
+// DeclineInvitation lets the holder of a token turn the invitation down.
+func (s *Service) DeclineInvitation(ctx context.Context, token string) error {
+	invitation, err := s.invitationFromToken(token)
+	if err != nil {
+		return err
+	}
+
+	event := invitationEvent(AuditInvitationDeclined, invitation.OrgID, invitation.ID)
+	return s.audited(ctx, event, func(tx *Service, _ *auditEvent) error {
+		if err := tx.repo.UpdateInvitationStatus(invitation.ID, organizations.InvitationDeclined); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationNotFound
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *Service) pendingInvitation(orgID, invitationID uint) (*organizations.InvitationModel, error) {
+	invitation, err := s.repo.GetInvitation(invitationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	if invitation.OrgID != orgID || invitation.Status != organizations.InvitationPending {
+		return nil, ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+func (s *Service) sendInvitation(ctx context.Context, invitation *organizations.InvitationModel) error {
+	org, err := s.repo.GetOrg(invitation.OrgID)
+	if err != nil {
+		return err
+	}
+
+	token := s.signInvitationToken(invitation)
+	subject := fmt.Sprintf("You have been invited to %s", org.Name)
+	body := fmt.Sprintf(
+		"You have been invited to join %s with %s permission.\n\n"+
+			"Accept with POST /api/invitations/accept or decline with POST /api/invitations/decline "+
+			"using the token below. It expires at %s.\n\n%s\n",
+		org.Name, invitation.Permission, invitation.ExpiresAt.Format(time.RFC3339), token,
+	)
+	return s.mailer.Send(ctx, invitation.Email, subject, body)
+}
+
+// signInvitationToken builds "<payload>.<signature>", where the payload
+// carries the invitation ID, its nonce and expiry.
+func (s *Service) signInvitationToken(invitation *organizations.InvitationModel) string {
+	payload := fmt.Sprintf("%d:%s:%d", invitation.ID, invitation.Nonce, invitation.ExpiresAt.Unix())
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + s.sign(encoded)
+}
+
+// invitationFromToken verifies a token and returns the pending invitation
+// it refers to.
+func (s *Service) invitationFromToken(token string) (*organizations.InvitationModel, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return nil, ErrInvitationInvalid
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvitationInvalid
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return nil, ErrInvitationInvalid
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return nil, ErrInvitationInvalid
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvitationInvalid
+	}
+	if time.Now().Unix() > expiresAt {
+		return nil, ErrInvitationExpired
+	}
+
+	invitation, err := s.repo.GetInvitation(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	// A resend rotates the nonce, so older tokens stop working.
+	if !hmac.Equal([]byte(invitation.Nonce), []byte(parts[1])) {
+		return nil, ErrInvitationInvalid
+	}
+	if invitation.Status != organizations.InvitationPending {
+		return nil, ErrInvitationNotFound
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvitationExpired
+	}
+	return invitation, nil
+}
+
+func (s *Service) sign(value string) string {
+	mac := hmac.New(sha256.New, s.tokenSecret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func toInvitationDTO(invitation organizations.InvitationModel) InvitationDTO {
+	return InvitationDTO{
+		ID:         invitation.ID,
+		OrgID:      invitation.OrgID,
+		Email:      invitation.Email,
+		Permission: dto.PermissionType(invitation.Permission),
+		Status:     invitation.Status,
+		InvitedBy:  invitation.InvitedBy,
+		ExpiresAt:  invitation.ExpiresAt,
+		CreatedAt:  invitation.CreatedAt,
+	}
+}
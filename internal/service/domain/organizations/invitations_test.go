@@ -0,0 +1,196 @@
+package organizations
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+func TestInvitationToken_RejectsForgeries(t *testing.T) {
+	svc := &Service{tokenSecret: []byte("test-token-secret")}
+	invitation := &organizations.InvitationModel{ID: 7, Nonce: "abc", ExpiresAt: time.Now().Add(time.Hour)}
+	token := svc.signInvitationToken(invitation)
+
+	payload, signature, _ := strings.Cut(token, ".")
+	otherSecret := &Service{tokenSecret: []byte("another-secret")}
+	forgedPayload := base64.RawURLEncoding.EncodeToString([]byte("8:abc:9999999999"))
+	unsignedGarbage := base64.RawURLEncoding.EncodeToString([]byte("not-a-payload"))
+
+	cases := map[string]string{
+		"empty":             "",
+		"no signature":      payload,
+		"bad signature":     payload + ".AAAA",
+		"other secret":      otherSecret.signInvitationToken(invitation),
+		"swapped payload":   forgedPayload + "." + signature,
+		"malformed payload": unsignedGarbage + "." + svc.sign(unsignedGarbage),
+	}
+	for name, candidate := range cases {
+		if _, err := svc.invitationFromToken(candidate); !errors.Is(err, ErrInvitationInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvitationInvalid", name, err)
+		}
+	}
+}
+
+func TestInvitationToken_Expired(t *testing.T) {
+	svc := &Service{tokenSecret: []byte("test-token-secret")}
+	token := svc.signInvitationToken(&organizations.InvitationModel{ID: 7, Nonce: "abc", ExpiresAt: time.Now().Add(-time.Second)})
+
+	if _, err := svc.invitationFromToken(token); !errors.Is(err, ErrInvitationExpired) {
+		t.Fatalf("err = %v, want ErrInvitationExpired", err)
+	}
+}
+
+// openInvitations returns a service with an organization owned by user 1 and
+// a pending READ invitation for invitee@example.com, which user 3 owns.
+func openInvitations(t *testing.T) (*Service, *organizations.InvitationModel) {
+	t.Helper()
+	svc := openService(t, stubUsers{
+		1: {ID: 1, Email: "owner@example.com"},
+		2: {ID: 2, Email: "someone@example.com"},
+		3: {ID: 3, Email: "Invitee@Example.com"},
+	})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	created, err := svc.InviteUser(context.Background(), orgID, 1, "invitee@example.com", dto.PermissionRead)
+	if err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	invitation, err := svc.repo.GetInvitation(created.ID)
+	if err != nil {
+		t.Fatalf("load invitation: %v", err)
+	}
+	return svc, invitation
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	ctx := context.Background()
+	svc, invitation := openInvitations(t)
+	token := svc.signInvitationToken(invitation)
+
+	if mail := sentMail(svc); len(mail.to) != 1 || mail.to[0] != "invitee@example.com" {
+		t.Fatalf("mailed %v, want the invitee", mail.to)
+	}
+
+	if _, err := svc.AcceptInvitation(ctx, token, 2); !errors.Is(err, ErrInvitationEmailMismatch) {
+		t.Fatalf("accept by another user = %v, want ErrInvitationEmailMismatch", err)
+	}
+
+	accepted, err := svc.AcceptInvitation(ctx, token, 3)
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if accepted.Status != organizations.InvitationAccepted {
+		t.Fatalf("status = %s", accepted.Status)
+	}
+	if permission, err := svc.repo.GetUserPermissionInOrg(invitation.OrgID, 3); err != nil || permission != dto.PermissionRead {
+		t.Fatalf("membership = %v, %v", permission, err)
+	}
+
+	if _, err := svc.AcceptInvitation(ctx, token, 3); !errors.Is(err, ErrInvitationNotFound) {
+		t.Fatalf("second accept = %v, want ErrInvitationNotFound", err)
+	}
+}
+
+func TestAcceptInvitation_AlreadyMember(t *testing.T) {
+	ctx := context.Background()
+	svc, invitation := openInvitations(t)
+
+	if err := svc.AddUserToOrg(ctx, invitation.OrgID, 1, 3, dto.PermissionWrite, nil, nil); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if _, err := svc.AcceptInvitation(ctx, svc.signInvitationToken(invitation), 3); !errors.Is(err, ErrAlreadyMember) {
+		t.Fatalf("accept = %v, want ErrAlreadyMember", err)
+	}
+}
+
+func TestAcceptInvitation_Expired(t *testing.T) {
+	svc, invitation := openInvitations(t)
+
+	// The stored expiry wins over the one signed into the token.
+	past := time.Now().Add(-time.Minute)
+	if err := svc.repo.RecordInvitationResend(invitation.ID, invitation.Nonce, past, past); err != nil {
+		t.Fatalf("expire invitation: %v", err)
+	}
+	if _, err := svc.AcceptInvitation(context.Background(), svc.signInvitationToken(invitation), 3); !errors.Is(err, ErrInvitationExpired) {
+		t.Fatalf("accept = %v, want ErrInvitationExpired", err)
+	}
+}
+
+func TestResendInvitation(t *testing.T) {
+	ctx := context.Background()
+	svc, invitation := openInvitations(t)
+	oldToken := svc.signInvitationToken(invitation)
+
+	if err := svc.ResendInvitation(ctx, invitation.OrgID, invitation.ID); !errors.Is(err, ErrInvitationResendTooSoon) {
+		t.Fatalf("immediate resend = %v, want ErrInvitationResendTooSoon", err)
+	}
+
+	// Pretend the last mail went out before the resend window.
+	earlier := time.Now().Add(-2 * invitationResendWindow)
+	if err := svc.repo.RecordInvitationResend(invitation.ID, invitation.Nonce, invitation.ExpiresAt, earlier); err != nil {
+		t.Fatalf("backdate invitation: %v", err)
+	}
+	if err := svc.ResendInvitation(ctx, invitation.OrgID, invitation.ID); err != nil {
+		t.Fatalf("resend: %v", err)
+	}
+	if mail := sentMail(svc); len(mail.to) != 2 {
+		t.Fatalf("mailed %d times, want 2", len(mail.to))
+	}
+	if _, err := svc.AcceptInvitation(ctx, oldToken, 3); !errors.Is(err, ErrInvitationInvalid) {
+		t.Fatalf("accept with the old token = %v, want ErrInvitationInvalid", err)
+	}
+
+	for {
+		current, err := svc.repo.GetInvitation(invitation.ID)
+		if err != nil {
+			t.Fatalf("load invitation: %v", err)
+		}
+		if current.SendCount >= invitationMaxSends {
+			break
+		}
+		if err := svc.repo.RecordInvitationResend(invitation.ID, current.Nonce, current.ExpiresAt, earlier); err != nil {
+			t.Fatalf("record resend: %v", err)
+		}
+	}
+	if err := svc.ResendInvitation(ctx, invitation.OrgID, invitation.ID); !errors.Is(err, ErrInvitationResendLimit) {
+		t.Fatalf("resend over the limit = %v, want ErrInvitationResendLimit", err)
+	}
+}
+
+func TestInviteUser_RejectsDuplicatesWithoutMail(t *testing.T) {
+	svc, invitation := openInvitations(t)
+
+	_, err := svc.InviteUser(context.Background(), invitation.OrgID, 1, "INVITEE@example.com", dto.PermissionWrite)
+	if !errors.Is(err, ErrInvitationPending) {
+		t.Fatalf("second invite = %v, want ErrInvitationPending", err)
+	}
+	if mail := sentMail(svc); len(mail.to) != 1 {
+		t.Fatalf("mailed %d times, want only the first invitation", len(mail.to))
+	}
+}
+
+func TestInviteUser_MailsAfterCommit(t *testing.T) {
+	svc := openService(t, stubUsers{1: {ID: 1, Email: "owner@example.com"}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+	mailErr := errors.New("smtp unavailable")
+	sentMail(svc).err = mailErr
+
+	if _, err := svc.InviteUser(context.Background(), orgID, 1, "invitee@example.com", dto.PermissionRead); !errors.Is(err, mailErr) {
+		t.Fatalf("invite = %v, want the mail error", err)
+	}
+
+	// The invitation was committed before mailing, so it can be resent.
+	pending, err := svc.repo.FindPendingInvitation(orgID, "invitee@example.com")
+	if err != nil {
+		t.Fatalf("pending invitation: %v", err)
+	}
+	if pending.Status != organizations.InvitationPending {
+		t.Fatalf("invitation = %+v, want it pending", pending)
+	}
+}
@@ -0,0 +1,272 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+const maxJoinRequestMessage = 500
+
+type JoinRequestDTO struct {
+	ID         uint
+	OrgID      uint
+	UserID     uint
+	Message    string
+	Status     string
+	Permission dto.PermissionType
+	DecidedBy  *uint
+	DecidedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// RequestToJoin records userID's request to join an organization that has
+// join requests enabled.
+func (s *Service) RequestToJoin(ctx context.Context, orgID, userID uint, message string) (*JoinRequestDTO, error) {
+	var request *JoinRequestDTO
+	err := s.audited(ctx, joinRequestEvent(AuditJoinRequestCreated, orgID, 0), func(tx *Service, event *auditEvent) (err error) {
+		request, err = tx.requestToJoin(ctx, orgID, userID, message)
+		if err == nil {
+			event.targetID = request.ID
+		}
+		return err
+	})
+	return request, err
+}
+
+func (s *Service) requestToJoin(ctx context.Context, orgID, userID uint, message string) (*JoinRequestDTO, error) {
+	message = strings.TrimSpace(message)
+	if len(message) > maxJoinRequestMessage {
+		return nil, fmt.Errorf("message cannot exceed %d characters", maxJoinRequestMessage)
+	}
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.GetSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Bool(SettingJoinRequestsEnabled) {
+		return nil, ErrJoinRequestsDisabled
+	}
+	if err := s.ensureUserAllowed(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.IsOrgMember(orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member {
+		return nil, ErrAlreadyMember
+	}
+
+	if _, err := s.repo.FindPendingJoinRequest(orgID, userID); err == nil {
+		return nil, ErrJoinRequestPending
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	request := &organizations.JoinRequestModel{
+		OrgID:   orgID,
+		UserID:  userID,
+		Message: message,
+		Status:  organizations.JoinRequestPending,
+	}
+	if err := s.repo.CreateJoinRequest(request); err != nil {
+		return nil, err
+	}
+
+	result := toJoinRequestDTO(*request)
+	return &result, nil
+}
+
+// ListJoinRequests returns the join requests of an organization, optionally
+// filtered by status.
+func (s *Service) ListJoinRequests(ctx context.Context, orgID uint, status string) ([]JoinRequestDTO, error) {
+	status = strings.ToUpper(status)
+	switch status {
+	case "", organizations.JoinRequestPending, organizations.JoinRequestApproved, organizations.JoinRequestRejected:
+	default:
+		return nil, errors.New("invalid join request status")
+	}
+
+	requests, err := s.repo.ListJoinRequests(orgID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]JoinRequestDTO, 0, len(requests))
+	for _, request := range requests {
+		dtos = append(dtos, toJoinRequestDTO(request))
+	}
+	return dtos, nil
+}
+
+// ApproveJoinRequest adds the applicant to the organization with permission,
+// or with the organization's default member permission when it is empty,
+// and notifies the applicant.
+func (s *Service) ApproveJoinRequest(ctx context.Context, orgID, requestID, deciderID uint, permission dto.PermissionType) (*JoinRequestDTO, error) {
+	var request *JoinRequestDTO
+	err := s.audited(ctx, joinRequestEvent(AuditJoinRequestApproved, orgID, requestID), func(tx *Service, _ *auditEvent) (err error) {
+		request, err = tx.approveJoinRequest(ctx, orgID, requestID, deciderID, permission)
+		return err
+	})
+	return request, err
+}
+
+func (s *Service) approveJoinRequest(ctx context.Context, orgID, requestID, deciderID uint, permission dto.PermissionType) (*JoinRequestDTO, error) {
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+
+	if permission == "" {
+		settings, err := s.GetSettings(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		permission = dto.PermissionType(settings.String(SettingDefaultMemberPermission))
+	}
+	if !isValidPermission(permission) {
+		return nil, ErrInvalidPermission
+	}
+	if err := s.ensureCanGrant(ctx, orgID, deciderID, permission, nil); err != nil {
+		return nil, err
+	}
+
+	request, err := s.pendingJoinRequest(orgID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureUserAllowed(ctx, orgID, request.UserID); err != nil {
+		return nil, err
+	}
+	member, err := s.repo.IsOrgMember(orgID, request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if member {
+		return nil, ErrAlreadyMember
+	}
+
+	err = s.withinQuota(orgID, func(tx *organizations.Repository) error {
+		return tx.ApproveJoinRequest(request.ID, deciderID, string(permission))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrJoinRequestNotFound
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			return nil, ErrAlreadyMember
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	request.Status = organizations.JoinRequestApproved
+	request.Permission = string(permission)
+	request.DecidedBy = &deciderID
+	request.DecidedAt = &now
+	s.notifyJoinDecision(ctx, request)
+
+	result := toJoinRequestDTO(*request)
+	return &result, nil
+}
+
+// RejectJoinRequest turns a join request down and notifies the applicant.
+func (s *Service) RejectJoinRequest(ctx context.Context, orgID, requestID, deciderID uint) (*JoinRequestDTO, error) {
+	var request *JoinRequestDTO
+	err := s.audited(ctx, joinRequestEvent(AuditJoinRequestRejected, orgID, requestID), func(tx *Service, _ *auditEvent) (err error) {
+		request, err = tx.rejectJoinRequest(ctx, orgID, requestID, deciderID)
+		return err
+	})
+	return request, err
+}
+
+func (s *Service) rejectJoinRequest(ctx context.Context, orgID, requestID, deciderID uint) (*JoinRequestDTO, error) {
+	request, err := s.pendingJoinRequest(orgID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RejectJoinRequest(request.ID, deciderID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJoinRequestNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	request.Status = organizations.JoinRequestRejected
+	request.DecidedBy = &deciderID
+	request.DecidedAt = &now
+	s.notifyJoinDecision(ctx, request)
+
+	result := toJoinRequestDTO(*request)
+	return &result, nil
+}
+
+func (s *Service) pendingJoinRequest(orgID, requestID uint) (*organizations.JoinRequestModel, error) {
+	request, err := s.repo.GetJoinRequest(orgID, requestID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJoinRequestNotFound
+		}
+		return nil, err
+	}
+	if request.Status != organizations.JoinRequestPending {
+		return nil, ErrJoinRequestNotFound
+	}
+	return request, nil
+}
+
+// notifyJoinDecision mails the applicant the outcome of their request. The
+// decision is already stored, so a failed notification is only logged.
+func (s *Service) notifyJoinDecision(ctx context.Context, request *organizations.JoinRequestModel) {
+	user, err := s.users.GetByID(ctx, request.UserID)
+	if err != nil {
+		log.Printf("join request %d: cannot load applicant: %v", request.ID, err)
+		return
+	}
+	org, err := s.repo.GetOrg(request.OrgID)
+	if err != nil {
+		log.Printf("join request %d: cannot load organization: %v", request.ID, err)
+		return
+	}
+
+	var subject, body string
+	if request.Status == organizations.JoinRequestApproved {
+		subject = fmt.Sprintf("Your request to join %s was approved", org.Name)
+		body = fmt.Sprintf("You are now a member of %s with %s permission.\n", org.Name, request.Permission)
+	} else {
+		subject = fmt.Sprintf("Your request to join %s was declined", org.Name)
+		body = fmt.Sprintf("Your request to join %s was declined.\n", org.Name)
+	}
+
+	if err := s.mailer.Send(ctx, user.Email, subject, body); err != nil {
+		log.Printf("join request %d: cannot notify applicant: %v", request.ID, err)
+	}
+}
+
+func toJoinRequestDTO(request organizations.JoinRequestModel) JoinRequestDTO {
+	return JoinRequestDTO{
+		ID:         request.ID,
+		OrgID:      request.OrgID,
+		UserID:     request.UserID,
+		Message:    request.Message,
+		Status:     request.Status,
+		Permission: dto.PermissionType(request.Permission),
+		DecidedBy:  request.DecidedBy,
+		DecidedAt:  request.DecidedAt,
+		CreatedAt:  request.CreatedAt,
+	}
+}
@@ -0,0 +1,160 @@
+package organizations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+// openJoinRequests returns a service with an organization owned by user 1
+// that accepts join requests.
+func openJoinRequests(t *testing.T) (*Service, uint) {
+	t.Helper()
+	svc := openService(t, stubUsers{
+		1: {ID: 1, Email: "owner@example.com"},
+		2: {ID: 2, Email: "applicant@example.com"},
+	})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	patch := map[string]json.RawMessage{string(SettingJoinRequestsEnabled): json.RawMessage("true")}
+	if _, err := svc.UpdateSettings(context.Background(), orgID, patch); err != nil {
+		t.Fatalf("enable join requests: %v", err)
+	}
+	return svc, orgID
+}
+
+func TestRequestToJoin_Disabled(t *testing.T) {
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	if _, err := svc.RequestToJoin(context.Background(), orgID, 2, ""); !errors.Is(err, ErrJoinRequestsDisabled) {
+		t.Fatalf("request = %v, want ErrJoinRequestsDisabled", err)
+	}
+}
+
+func TestRequestToJoin(t *testing.T) {
+	ctx := context.Background()
+	svc, orgID := openJoinRequests(t)
+
+	request, err := svc.RequestToJoin(ctx, orgID, 2, "  let me in  ")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if request.Status != organizations.JoinRequestPending || request.Message != "let me in" {
+		t.Fatalf("request = %+v", request)
+	}
+
+	if _, err := svc.RequestToJoin(ctx, orgID, 2, ""); !errors.Is(err, ErrJoinRequestPending) {
+		t.Fatalf("second request = %v, want ErrJoinRequestPending", err)
+	}
+	if _, err := svc.RequestToJoin(ctx, orgID, 1, ""); !errors.Is(err, ErrAlreadyMember) {
+		t.Fatalf("member request = %v, want ErrAlreadyMember", err)
+	}
+}
+
+func TestApproveJoinRequest(t *testing.T) {
+	ctx := context.Background()
+	svc, orgID := openJoinRequests(t)
+
+	request, err := svc.RequestToJoin(ctx, orgID, 2, "")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	approved, err := svc.ApproveJoinRequest(ctx, orgID, request.ID, 1, "")
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if approved.Status != organizations.JoinRequestApproved || approved.Permission != dto.PermissionRead {
+		t.Fatalf("approved = %+v, want APPROVED with the default READ permission", approved)
+	}
+
+	permission, err := svc.repo.GetUserPermissionInOrg(orgID, 2)
+	if err != nil || permission != dto.PermissionRead {
+		t.Fatalf("membership = %v, %v", permission, err)
+	}
+	if mail := sentMail(svc); len(mail.to) != 1 || mail.to[0] != "applicant@example.com" {
+		t.Fatalf("notified %v, want the applicant", mail.to)
+	}
+
+	if _, err := svc.ApproveJoinRequest(ctx, orgID, request.ID, 1, ""); !errors.Is(err, ErrJoinRequestNotFound) {
+		t.Fatalf("second approval = %v, want ErrJoinRequestNotFound", err)
+	}
+}
+
+func TestApproveJoinRequest_AlreadyMember(t *testing.T) {
+	ctx := context.Background()
+	svc, orgID := openJoinRequests(t)
+
+	request, err := svc.RequestToJoin(ctx, orgID, 2, "")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 2, dto.PermissionWrite, nil, nil); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+
+	if _, err := svc.ApproveJoinRequest(ctx, orgID, request.ID, 1, ""); !errors.Is(err, ErrAlreadyMember) {
+		t.Fatalf("approve = %v, want ErrAlreadyMember", err)
+	}
+	if permission, _ := svc.repo.GetUserPermissionInOrg(orgID, 2); permission != dto.PermissionWrite {
+		t.Fatalf("permission = %s, want the existing WRITE membership untouched", permission)
+	}
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 2, dto.PermissionRead, nil, nil); !errors.Is(err, ErrAlreadyMember) {
+		t.Fatalf("duplicate add = %v, want ErrAlreadyMember", err)
+	}
+}
+
+func TestApproveJoinRequest_CannotGrantMoreThanHeld(t *testing.T) {
+	ctx := context.Background()
+	svc, orgID := openJoinRequests(t)
+	svc.users = stubUsers{
+		1: {ID: 1, Email: "owner@example.com"},
+		2: {ID: 2, Email: "applicant@example.com"},
+		3: {ID: 3, Email: "writer@example.com"},
+	}
+
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 3, dto.PermissionWrite, nil, nil); err != nil {
+		t.Fatalf("add writer: %v", err)
+	}
+	request, err := svc.RequestToJoin(ctx, orgID, 2, "")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	if _, err := svc.ApproveJoinRequest(ctx, orgID, request.ID, 3, dto.PermissionRoot); !errors.Is(err, ErrCapabilityNotHeld) {
+		t.Fatalf("approve as ROOT = %v, want ErrCapabilityNotHeld", err)
+	}
+}
+
+func TestRejectJoinRequest(t *testing.T) {
+	ctx := context.Background()
+	svc, orgID := openJoinRequests(t)
+
+	request, err := svc.RequestToJoin(ctx, orgID, 2, "")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	rejected, err := svc.RejectJoinRequest(ctx, orgID, request.ID, 1)
+	if err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if rejected.Status != organizations.JoinRequestRejected || rejected.DecidedBy == nil || *rejected.DecidedBy != 1 {
+		t.Fatalf("rejected = %+v", rejected)
+	}
+	if member, _ := svc.repo.IsOrgMember(orgID, 2); member {
+		t.Fatal("rejected applicant became a member")
+	}
+	if mail := sentMail(svc); len(mail.to) != 1 || mail.to[0] != "applicant@example.com" {
+		t.Fatalf("notified %v, want the applicant", mail.to)
+	}
+
+	if _, err := svc.ApproveJoinRequest(ctx, orgID, request.ID, 1, ""); !errors.Is(err, ErrJoinRequestNotFound) {
+		t.Fatalf("approve after rejection = %v, want ErrJoinRequestNotFound", err)
+	}
+}
@@ -0,0 +1,60 @@
+package organizations
+
+import "meu-treino-golang/users-crud/dto"
+
+// PermissionLevel orders permission types so that a higher level implies
+// every lower one: ROOT implies WRITE, which implies READ.
+type PermissionLevel int
+
+const (
+	LevelNone PermissionLevel = iota
+	LevelRead
+	LevelWrite
+	LevelRoot
+)
+
+// LevelOf returns the level of a permission type, or LevelNone when the
+// permission is unknown.
+func LevelOf(permission dto.PermissionType) PermissionLevel {
+	switch permission {
+	case dto.PermissionRead:
+		return LevelRead
+	case dto.PermissionWrite:
+		return LevelWrite
+	case dto.PermissionRoot:
+		return LevelRoot
+	default:
+		return LevelNone
+	}
+}
+
+// LevelOfCapabilities returns the highest built-in level whose capabilities
+// are all among granted, or LevelNone when not even READ's are.
+func LevelOfCapabilities(granted []Capability) PermissionLevel {
+	level := LevelNone
+	for _, permission := range []dto.PermissionType{dto.PermissionRead, dto.PermissionWrite, dto.PermissionRoot} {
+		if holdsAll(granted, CapabilitiesFor(permission)) {
+			level = LevelOf(permission)
+		}
+	}
+	return level
+}
+
+// Satisfies reports whether a member holding level l may perform an
+// operation that requires the given level.
+func (l PermissionLevel) Satisfies(required PermissionLevel) bool {
+	return l != LevelNone && l >= required
+}
+
+func (l PermissionLevel) String() string {
+	switch l {
+	case LevelRead:
+		return string(dto.PermissionRead)
+	case LevelWrite:
+		return string(dto.PermissionWrite)
+	case LevelRoot:
+		return string(dto.PermissionRoot)
+	default:
+		return "NONE"
+	}
+}
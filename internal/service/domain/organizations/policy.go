@@ -0,0 +1,203 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+// Capability is a named permission to perform one kind of organization
+// operation. Roles are sets of capabilities.
+type Capability string
+
+const (
+	CapOrgUpdate         Capability = "org.update"
+	CapOrgDelete         Capability = "org.delete"
+	CapMembersRead       Capability = "members.read"
+	CapMembersAdd        Capability = "members.add"
+	CapMembersRemove     Capability = "members.remove"
+	CapMembersUpdateRole Capability = "members.update_role"
+	CapInvitationsManage Capability = "invitations.manage"
+	CapRolesManage       Capability = "roles.manage"
+	CapTeamsManage       Capability = "teams.manage"
+	CapOrgHierarchy      Capability = "org.hierarchy"
+	CapOrgArchive        Capability = "org.archive"
+	CapSettingsManage    Capability = "settings.manage"
+	CapDomainsManage     Capability = "domains.manage"
+	CapSSOManage         Capability = "sso.manage"
+	CapAuditRead         Capability = "audit.read"
+)
+
+// AllCapabilities lists every capability known to the policy.
+var AllCapabilities = []Capability{
+	CapOrgUpdate,
+	CapOrgDelete,
+	CapMembersRead,
+	CapMembersAdd,
+	CapMembersRemove,
+	CapMembersUpdateRole,
+	CapInvitationsManage,
+	CapRolesManage,
+	CapTeamsManage,
+	CapOrgHierarchy,
+	CapOrgArchive,
+	CapSettingsManage,
+	CapDomainsManage,
+	CapSSOManage,
+	CapAuditRead,
+}
+
+// systemRoles defines the built-in roles. Each level holds every capability
+// of the levels below it.
+var systemRoles = map[dto.PermissionType][]Capability{
+	dto.PermissionRead: {
+		CapMembersRead,
+	},
+	dto.PermissionWrite: {
+		CapMembersRead,
+		CapOrgUpdate,
+	},
+	dto.PermissionRoot: AllCapabilities,
+}
+
+// SystemRoles returns the built-in roles keyed by name, in the form the
+// storage layer seeds them.
+func SystemRoles() map[string][]string {
+	roles := make(map[string][]string, len(systemRoles))
+	for permission, capabilities := range systemRoles {
+		roles[string(permission)] = capabilityStrings(capabilities)
+	}
+	return roles
+}
+
+// CapabilitiesFor returns the capabilities granted by a built-in permission.
+func CapabilitiesFor(permission dto.PermissionType) []Capability {
+	return systemRoles[permission]
+}
+
+// IsKnownCapability reports whether the policy understands a capability.
+func IsKnownCapability(capability Capability) bool {
+	return slices.Contains(AllCapabilities, capability)
+}
+
+// Evaluate decides whether a set of capabilities allows the required one.
+func Evaluate(granted []Capability, required Capability) bool {
+	return IsKnownCapability(required) && slices.Contains(granted, required)
+}
+
+// Authorize checks whether userID holds capability in the organization,
+// either locally, as ROOT inherited from a parent organization or as a
+// platform admin. It returns ErrForbidden when the user lacks it, and
+// ErrTwoFactorRequired when the organization demands two-factor
+// authentication the member has not enabled.
+func (s *Service) Authorize(ctx context.Context, orgID, userID uint, capability Capability) error {
+	granted, err := s.memberCapabilities(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if Evaluate(granted, capability) {
+		return s.checkTwoFactor(ctx, orgID, userID, capability)
+	}
+
+	if Evaluate(CapabilitiesFor(dto.PermissionRoot), capability) {
+		inherited, err := s.inheritsRoot(orgID, userID)
+		if err != nil {
+			return err
+		}
+		if inherited {
+			return s.checkTwoFactor(ctx, orgID, userID, capability)
+		}
+	}
+
+	bypass, err := s.adminBypass(ctx, orgID, userID, capability)
+	if err != nil {
+		return err
+	}
+	if bypass {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// IsRootLevel reports whether a capability is held only by ROOT among the
+// built-in roles.
+func IsRootLevel(capability Capability) bool {
+	return !slices.Contains(CapabilitiesFor(dto.PermissionWrite), capability)
+}
+
+// checkTwoFactor refuses ROOT-level capabilities to members without
+// two-factor authentication when the organization requires it. Platform
+// admins are not members and are not affected.
+func (s *Service) checkTwoFactor(ctx context.Context, orgID, userID uint, capability Capability) error {
+	if !IsRootLevel(capability) {
+		return nil
+	}
+
+	stored, err := s.repo.GetOrgSettings(orgID)
+	if err != nil {
+		return err
+	}
+	if !effectiveSettings(stored).Bool(SettingRequireTwoFactorForRoot) {
+		return nil
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorRequired
+	}
+	return nil
+}
+
+// memberCapabilities resolves what a user may do in an organization: the
+// capabilities of the direct membership (a custom role wins over the
+// built-in role of its permission) plus those granted by the user's teams.
+func (s *Service) memberCapabilities(orgID, userID uint) ([]Capability, error) {
+	var granted []Capability
+
+	membership, err := s.repo.GetMembership(orgID, userID)
+	if err == nil {
+		granted = append(granted, membershipCapabilities(*membership)...)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	teamPermissions, err := s.repo.GetUserTeamPermissions(orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range teamPermissions {
+		granted = append(granted, CapabilitiesFor(dto.PermissionType(permission))...)
+	}
+	return granted, nil
+}
+
+func membershipCapabilities(membership organizations.OrgUserModel) []Capability {
+	if membership.Role != nil {
+		return parseCapabilities(membership.Role.CapabilityList())
+	}
+	return CapabilitiesFor(dto.PermissionType(membership.Permission))
+}
+
+func parseCapabilities(values []string) []Capability {
+	capabilities := make([]Capability, 0, len(values))
+	for _, value := range values {
+		capabilities = append(capabilities, Capability(value))
+	}
+	return capabilities
+}
+
+func capabilityStrings(capabilities []Capability) []string {
+	values := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		values = append(values, string(capability))
+	}
+	return values
+}
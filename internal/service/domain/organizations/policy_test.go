@@ -0,0 +1,127 @@
+package organizations
+
+import (
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+func TestLevelOf(t *testing.T) {
+	cases := map[dto.PermissionType]PermissionLevel{
+		dto.PermissionRead:  LevelRead,
+		dto.PermissionWrite: LevelWrite,
+		dto.PermissionRoot:  LevelRoot,
+		"ADMIN":             LevelNone,
+		"":                  LevelNone,
+	}
+	for permission, want := range cases {
+		if got := LevelOf(permission); got != want {
+			t.Fatalf("LevelOf(%q) = %v, want %v", permission, got, want)
+		}
+	}
+}
+
+func TestLevelOfCapabilities(t *testing.T) {
+	teamRoot := append(CapabilitiesFor(dto.PermissionRead), CapabilitiesFor(dto.PermissionRoot)...)
+
+	cases := []struct {
+		name    string
+		granted []Capability
+		want    PermissionLevel
+	}{
+		{"nothing", nil, LevelNone},
+		{"READ", CapabilitiesFor(dto.PermissionRead), LevelRead},
+		{"WRITE", CapabilitiesFor(dto.PermissionWrite), LevelWrite},
+		{"READ member in a ROOT team", teamRoot, LevelRoot},
+		{"custom role with every capability", AllCapabilities, LevelRoot},
+		{"custom role without members.read", []Capability{CapOrgUpdate, CapMembersAdd}, LevelNone},
+		{"custom role above WRITE", []Capability{CapMembersRead, CapOrgUpdate, CapMembersAdd}, LevelWrite},
+	}
+	for _, tc := range cases {
+		if got := LevelOfCapabilities(tc.granted); got != tc.want {
+			t.Errorf("%s: LevelOfCapabilities = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSatisfies_Hierarchy(t *testing.T) {
+	levels := []PermissionLevel{LevelRead, LevelWrite, LevelRoot}
+	for i, held := range levels {
+		for j, required := range levels {
+			if got, want := held.Satisfies(required), i >= j; got != want {
+				t.Fatalf("%v.Satisfies(%v) = %v, want %v", held, required, got, want)
+			}
+		}
+	}
+	if LevelNone.Satisfies(LevelNone) {
+		t.Fatalf("LevelNone must never satisfy a requirement")
+	}
+}
+
+// TestSystemRoles_FollowHierarchy checks that every built-in role holds all
+// capabilities of the roles below it.
+func TestSystemRoles_FollowHierarchy(t *testing.T) {
+	order := []dto.PermissionType{dto.PermissionRead, dto.PermissionWrite, dto.PermissionRoot}
+	for i := 1; i < len(order); i++ {
+		for _, capability := range CapabilitiesFor(order[i-1]) {
+			if !Evaluate(CapabilitiesFor(order[i]), capability) {
+				t.Fatalf("%s lacks %s held by %s", order[i], capability, order[i-1])
+			}
+		}
+	}
+}
+
+func TestEvaluate_UnknownCapabilityDenied(t *testing.T) {
+	granted := []Capability{"org.unknown"}
+	if Evaluate(granted, "org.unknown") {
+		t.Fatalf("unknown capabilities must be denied")
+	}
+}
+
+func TestMembershipCapabilities_CustomRoleOverridesPermission(t *testing.T) {
+	// A "member manager" can manage members but cannot delete the org.
+	membership := organizations.OrgUserModel{
+		Permission: string(dto.PermissionRead),
+		Role: &organizations.RoleModel{
+			Name:         "member manager",
+			Capabilities: "members.read,members.add,members.remove",
+		},
+	}
+
+	granted := membershipCapabilities(membership)
+	if !Evaluate(granted, CapMembersRemove) {
+		t.Fatalf("expected custom role to grant %s", CapMembersRemove)
+	}
+	if Evaluate(granted, CapOrgDelete) {
+		t.Fatalf("custom role must not grant %s", CapOrgDelete)
+	}
+}
+
+func TestValidateRole(t *testing.T) {
+	if _, err := validateRole("billing admin", []Capability{CapOrgUpdate}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := validateRole("root", []Capability{CapOrgUpdate}); err == nil {
+		t.Fatalf("expected error for reserved name")
+	}
+	if _, err := validateRole("custom", []Capability{"org.fly"}); err == nil {
+		t.Fatalf("expected error for unknown capability")
+	}
+	if _, err := validateRole("custom", nil); err == nil {
+		t.Fatalf("expected error for empty capabilities")
+	}
+}
+
+func TestIsRootLevel(t *testing.T) {
+	for _, capability := range []Capability{CapMembersRead, CapOrgUpdate} {
+		if IsRootLevel(capability) {
+			t.Fatalf("%s is held by WRITE and is not ROOT-level", capability)
+		}
+	}
+	for _, capability := range []Capability{CapMembersRemove, CapSettingsManage, CapOrgDelete} {
+		if !IsRootLevel(capability) {
+			t.Fatalf("%s is only held by ROOT", capability)
+		}
+	}
+}
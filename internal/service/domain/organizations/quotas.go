@@ -0,0 +1,208 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+// DefaultPlan is the plan of organizations that were never assigned one.
+const DefaultPlan = "free"
+
+// Quota resources.
+const (
+	ResourceMembers = "members"
+	ResourceRoots   = "roots"
+	ResourceTeams   = "teams"
+)
+
+// Plan sets the quotas of an organization. A zero limit means unlimited.
+type Plan struct {
+	Name       string
+	MaxMembers int
+	MaxRoots   int
+	MaxTeams   int
+}
+
+var plans = map[string]Plan{
+	"free":       {Name: "free", MaxMembers: 10, MaxRoots: 2, MaxTeams: 3},
+	"team":       {Name: "team", MaxMembers: 50, MaxRoots: 5, MaxTeams: 20},
+	"business":   {Name: "business", MaxMembers: 500, MaxRoots: 20, MaxTeams: 200},
+	"enterprise": {Name: "enterprise"},
+}
+
+// LookupPlan returns a plan by name.
+func LookupPlan(name string) (Plan, bool) {
+	plan, ok := plans[name]
+	return plan, ok
+}
+
+// ResourceUsage is the consumption of one quota. A zero Limit means unlimited.
+type ResourceUsage struct {
+	Used  int
+	Limit int
+}
+
+// Usage reports how much of its plan an organization consumes.
+type Usage struct {
+	Plan    string
+	Members ResourceUsage
+	Roots   ResourceUsage
+	Teams   ResourceUsage
+}
+
+// QuotaExceededError is returned when a change would take an organization
+// over one of its quotas. It carries the usage at the time of the check.
+type QuotaExceededError struct {
+	Resource string
+	Usage    Usage
+}
+
+func (e *QuotaExceededError) Error() string {
+	limit := e.Usage.resource(e.Resource).Limit
+	return fmt.Sprintf("%s quota of plan %q exceeded (limit %d)", e.Resource, e.Usage.Plan, limit)
+}
+
+// Is lets callers match any quota error with errors.Is(err, ErrQuotaExceeded).
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// GetUsage reports the quota consumption of an organization.
+func (s *Service) GetUsage(ctx context.Context, orgID uint) (*Usage, error) {
+	org, err := s.repo.GetOrg(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := loadUsage(s.repo, org)
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// SetPlan moves an organization to another plan. Usage above the new quotas
+// is kept; it only blocks further growth.
+func (s *Service) SetPlan(ctx context.Context, orgID uint, plan string) error {
+	if _, ok := LookupPlan(plan); !ok {
+		return ErrUnknownPlan
+	}
+	return s.audited(ctx, orgEvent(AuditOrgPlanChanged, orgID), func(tx *Service, _ *auditEvent) error {
+		if _, err := tx.repo.GetOrg(orgID); err != nil {
+			return err
+		}
+		return tx.repo.SetOrgPlan(orgID, plan)
+	})
+}
+
+// withinQuota runs fn while holding a lock on the organization and keeps its
+// changes only if every quota that grew stays within the plan. Usage is
+// measured before and after fn, so custom roles, teams and expired
+// memberships are accounted for the same way GetUsage reports them.
+func (s *Service) withinQuota(orgID uint, fn func(tx *organizations.Repository) error) error {
+	return s.repo.LockOrg(orgID, func(tx *organizations.Repository, org *organizations.OrganizationModel) error {
+		before, err := loadUsage(tx, org)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		after, err := loadUsage(tx, org)
+		if err != nil {
+			return err
+		}
+		return before.allowsChange(after)
+	})
+}
+
+// planOf returns the plan of an organization, treating unknown plan names as
+// the default plan.
+func planOf(org *organizations.OrganizationModel) Plan {
+	if plan, ok := plans[org.Plan]; ok {
+		return plan
+	}
+	return plans[DefaultPlan]
+}
+
+func loadUsage(repo *organizations.Repository, org *organizations.OrganizationModel) (Usage, error) {
+	memberships, err := repo.ListActiveMembers(org.ID)
+	if err != nil {
+		return Usage{}, err
+	}
+	teamMemberIDs, err := repo.ListTeamMemberIDs(org.ID)
+	if err != nil {
+		return Usage{}, err
+	}
+	teamPermissions, err := repo.ListOrgTeamPermissions(org.ID)
+	if err != nil {
+		return Usage{}, err
+	}
+	teams, err := repo.CountTeams(org.ID)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	members, roots := countMembers(memberships, teamMemberIDs, teamPermissions)
+	plan := planOf(org)
+	return Usage{
+		Plan:    plan.Name,
+		Members: ResourceUsage{Used: members, Limit: plan.MaxMembers},
+		Roots:   ResourceUsage{Used: roots, Limit: plan.MaxRoots},
+		Teams:   ResourceUsage{Used: int(teams), Limit: plan.MaxTeams},
+	}, nil
+}
+
+// countMembers counts the distinct users that are active members or in a
+// team and, among them, those holding any ROOT-level capability through
+// their permission, custom role or teams.
+func countMembers(memberships []organizations.OrgUserModel, teamMemberIDs []uint, teamPermissions map[uint][]string) (members, roots int) {
+	granted := make(map[uint][]Capability)
+	for _, userID := range teamMemberIDs {
+		granted[userID] = nil
+	}
+	for _, membership := range memberships {
+		granted[membership.UserID] = append(granted[membership.UserID], membershipCapabilities(membership)...)
+	}
+	for userID, permissions := range teamPermissions {
+		for _, permission := range permissions {
+			granted[userID] = append(granted[userID], CapabilitiesFor(dto.PermissionType(permission))...)
+		}
+	}
+
+	for _, capabilities := range granted {
+		members++
+		if slices.ContainsFunc(capabilities, IsRootLevel) {
+			roots++
+		}
+	}
+	return members, roots
+}
+
+// allowsChange checks a change that took the usage from u to after: every
+// resource that grew must stay within its limit. Usage already above a
+// limit, after a move to a smaller plan, only blocks further growth.
+func (u Usage) allowsChange(after Usage) error {
+	for _, resource := range []string{ResourceMembers, ResourceRoots, ResourceTeams} {
+		before, now := u.resource(resource), after.resource(resource)
+		if now.Used > before.Used && now.Limit > 0 && now.Used > now.Limit {
+			return &QuotaExceededError{Resource: resource, Usage: u}
+		}
+	}
+	return nil
+}
+
+func (u Usage) resource(name string) ResourceUsage {
+	switch name {
+	case ResourceMembers:
+		return u.Members
+	case ResourceRoots:
+		return u.Roots
+	default:
+		return u.Teams
+	}
+}
@@ -0,0 +1,94 @@
+package organizations
+
+import (
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+// grow returns u with the given amounts added to each resource.
+func grow(u Usage, members, roots, teams int) Usage {
+	u.Members.Used += members
+	u.Roots.Used += roots
+	u.Teams.Used += teams
+	return u
+}
+
+func TestUsage_AllowsChange(t *testing.T) {
+	usage := Usage{
+		Plan:    "free",
+		Members: ResourceUsage{Used: 9, Limit: 10},
+		Roots:   ResourceUsage{Used: 2, Limit: 2},
+		Teams:   ResourceUsage{Used: 0, Limit: 0},
+	}
+
+	if err := usage.allowsChange(grow(usage, 1, 0, 0)); err != nil {
+		t.Fatalf("last seat should be allowed, got %v", err)
+	}
+	if err := usage.allowsChange(grow(usage, 0, 0, 100)); err != nil {
+		t.Fatalf("zero limit means unlimited, got %v", err)
+	}
+	if err := usage.allowsChange(grow(usage, 0, -1, 0)); err != nil {
+		t.Fatalf("removals never exceed a quota, got %v", err)
+	}
+
+	err := usage.allowsChange(grow(usage, 2, 0, 0))
+	var quotaErr *QuotaExceededError
+	if !errors.As(err, &quotaErr) || quotaErr.Resource != ResourceMembers {
+		t.Fatalf("expected members quota error, got %v", err)
+	}
+	if quotaErr.Usage.Members.Used != 9 {
+		t.Fatalf("quota error should carry the usage before the change, got %+v", quotaErr.Usage)
+	}
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatal("quota error should match ErrQuotaExceeded")
+	}
+
+	if err := usage.allowsChange(grow(usage, 1, 1, 0)); !errors.As(err, &quotaErr) || quotaErr.Resource != ResourceRoots {
+		t.Fatalf("expected roots quota error, got %v", err)
+	}
+
+	over := Usage{Plan: "free", Members: ResourceUsage{Used: 12, Limit: 10}}
+	if err := over.allowsChange(grow(over, -1, 0, 0)); err != nil {
+		t.Fatalf("shrinking above a lowered limit should be allowed, got %v", err)
+	}
+}
+
+func TestCountMembers(t *testing.T) {
+	rootRole := &organizations.RoleModel{Name: "owners", Capabilities: string(CapOrgDelete) + "," + string(CapMembersRead)}
+	writeRole := &organizations.RoleModel{Name: "editors", Capabilities: string(CapOrgUpdate) + "," + string(CapMembersRead)}
+
+	memberships := []organizations.OrgUserModel{
+		{UserID: 1, Permission: string(dto.PermissionRoot)},
+		{UserID: 2, Permission: string(dto.PermissionRead)},
+		{UserID: 3, Permission: string(dto.PermissionRead)},
+		{UserID: 4, Permission: string(dto.PermissionRead), Role: rootRole},
+		{UserID: 5, Permission: string(dto.PermissionRoot), Role: writeRole},
+	}
+	teamPermissions := map[uint][]string{
+		2: {string(dto.PermissionRoot)},
+		3: {string(dto.PermissionWrite), string(dto.PermissionRead)},
+	}
+
+	// User 6 is left in a team without a membership: they take a seat but
+	// their team grants nothing.
+	teamMemberIDs := []uint{2, 3, 6}
+
+	members, roots := countMembers(memberships, teamMemberIDs, teamPermissions)
+	if members != 6 {
+		t.Errorf("members = %d, want 6", members)
+	}
+	// ROOT directly, through a ROOT team and through a custom role with a
+	// ROOT-level capability; a custom role replaces the ROOT permission.
+	if roots != 3 {
+		t.Errorf("roots = %d, want 3", roots)
+	}
+}
+
+func TestPlans_ContainDefault(t *testing.T) {
+	if _, ok := LookupPlan(DefaultPlan); !ok {
+		t.Fatalf("default plan %q is not defined", DefaultPlan)
+	}
+}
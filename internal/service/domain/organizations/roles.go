@@ -0,0 +1,228 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+type RoleDTO struct {
+	ID           uint
+	OrgID        *uint
+	Name         string
+	Capabilities []Capability
+	System       bool
+}
+
+// CreateRole defines a custom role for an organization. actorID must hold
+// every capability the role grants.
+func (s *Service) CreateRole(ctx context.Context, orgID, actorID uint, name string, capabilities []Capability) (*RoleDTO, error) {
+	var role *RoleDTO
+	err := s.audited(ctx, roleEvent(AuditRoleCreated, orgID, 0), func(tx *Service, event *auditEvent) (err error) {
+		role, err = tx.createRole(ctx, orgID, actorID, name, capabilities)
+		if err == nil {
+			event.targetID = role.ID
+		}
+		return err
+	})
+	return role, err
+}
+
+func (s *Service) createRole(ctx context.Context, orgID, actorID uint, name string, capabilities []Capability) (*RoleDTO, error) {
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+
+	name, err := validateRole(name, capabilities)
+	if err != nil {
+		return nil, err
+	}
+	held, err := s.heldCapabilities(ctx, orgID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !holdsAll(held, capabilities) {
+		return nil, ErrCapabilityNotHeld
+	}
+
+	role, err := s.repo.CreateRole(orgID, name, capabilityStrings(capabilities))
+	if err != nil {
+		return nil, err
+	}
+
+	return &RoleDTO{
+		ID:           role.ID,
+		OrgID:        role.OrgID,
+		Name:         role.Name,
+		Capabilities: capabilities,
+	}, nil
+}
+
+// ListRoles returns the system roles and the organization's custom roles.
+func (s *Service) ListRoles(ctx context.Context, orgID uint) ([]RoleDTO, error) {
+	roles, err := s.repo.ListRoles(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]RoleDTO, 0, len(roles))
+	for _, role := range roles {
+		dtos = append(dtos, RoleDTO{
+			ID:           role.ID,
+			OrgID:        role.OrgID,
+			Name:         role.Name,
+			Capabilities: parseCapabilities(role.CapabilityList()),
+			System:       role.System,
+		})
+	}
+	return dtos, nil
+}
+
+// UpdateRole renames a custom role and replaces its capabilities. actorID
+// must hold every capability the role will grant and, while members hold
+// the role, every capability it grants now.
+func (s *Service) UpdateRole(ctx context.Context, orgID, actorID, roleID uint, name string, capabilities []Capability) error {
+	return s.audited(ctx, roleEvent(AuditRoleUpdated, orgID, roleID), func(tx *Service, _ *auditEvent) error {
+		return tx.updateRole(ctx, orgID, actorID, roleID, name, capabilities)
+	})
+}
+
+func (s *Service) updateRole(ctx context.Context, orgID, actorID, roleID uint, name string, capabilities []Capability) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	current, err := s.customRole(orgID, roleID)
+	if err != nil {
+		return err
+	}
+
+	name, err = validateRole(name, capabilities)
+	if err != nil {
+		return err
+	}
+	held, err := s.heldCapabilities(ctx, orgID, actorID)
+	if err != nil {
+		return err
+	}
+	if !holdsAll(held, capabilities) {
+		return ErrCapabilityNotHeld
+	}
+	// Like their memberships (see ensureCanChange), a role held by members
+	// may only be changed by someone holding everything it allows.
+	assigned, err := s.repo.CountRoleMembers(roleID)
+	if err != nil {
+		return err
+	}
+	if assigned > 0 && !holdsAll(held, current.Capabilities) {
+		return ErrCapabilityNotHeld
+	}
+	// Members holding the role may become ROOT-level.
+	return s.withinQuota(orgID, func(tx *organizations.Repository) error {
+		return tx.UpdateRole(roleID, name, capabilityStrings(capabilities))
+	})
+}
+
+// DeleteRole removes a custom role that no member is assigned to.
+func (s *Service) DeleteRole(ctx context.Context, orgID, roleID uint) error {
+	return s.audited(ctx, roleEvent(AuditRoleDeleted, orgID, roleID), func(tx *Service, _ *auditEvent) error {
+		return tx.deleteRole(orgID, roleID)
+	})
+}
+
+func (s *Service) deleteRole(orgID, roleID uint) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	if _, err := s.customRole(orgID, roleID); err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountRoleMembers(roleID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+	return s.repo.DeleteRole(roleID)
+}
+
+// resolveMemberRole checks that roleID may be assigned in the organization
+// and returns the permission to store alongside it. System roles map back to
+// their permission and are stored without a role ID.
+func (s *Service) resolveMemberRole(orgID uint, permission dto.PermissionType, roleID *uint) (dto.PermissionType, *uint, error) {
+	if roleID == nil {
+		if !isValidPermission(permission) {
+			return "", nil, ErrInvalidPermission
+		}
+		return permission, nil, nil
+	}
+
+	role, err := s.repo.GetRole(*roleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, ErrRoleNotFound
+		}
+		return "", nil, err
+	}
+	if role.System {
+		return dto.PermissionType(role.Name), nil, nil
+	}
+	if role.OrgID == nil || *role.OrgID != orgID {
+		return "", nil, ErrRoleNotFound
+	}
+
+	// Custom roles carry their own capabilities; the permission only keeps
+	// the membership's baseline level.
+	if permission == "" {
+		permission = dto.PermissionRead
+	}
+	if !isValidPermission(permission) {
+		return "", nil, ErrInvalidPermission
+	}
+	return permission, roleID, nil
+}
+
+func (s *Service) customRole(orgID, roleID uint) (*RoleDTO, error) {
+	role, err := s.repo.GetRole(roleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	if role.System {
+		return nil, ErrSystemRole
+	}
+	if role.OrgID == nil || *role.OrgID != orgID {
+		return nil, ErrRoleNotFound
+	}
+	return &RoleDTO{ID: role.ID, OrgID: role.OrgID, Name: role.Name, Capabilities: parseCapabilities(role.CapabilityList())}, nil
+}
+
+func validateRole(name string, capabilities []Capability) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("role name cannot be empty")
+	}
+	if _, reserved := systemRoles[dto.PermissionType(strings.ToUpper(name))]; reserved {
+		return "", fmt.Errorf("role name %q is reserved", name)
+	}
+	if len(capabilities) == 0 {
+		return "", errors.New("role must grant at least one capability")
+	}
+	for _, capability := range capabilities {
+		if !IsKnownCapability(capability) {
+			return "", fmt.Errorf("unknown capability %q", capability)
+		}
+	}
+	return name, nil
+}
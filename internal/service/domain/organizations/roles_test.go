@@ -0,0 +1,51 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func TestRoles_CannotGrantCapabilitiesNotHeld(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	// User 2 may manage roles, and nothing else.
+	manager, err := svc.CreateRole(ctx, orgID, 1, "role-manager", []Capability{CapRolesManage, CapMembersRead})
+	if err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 2, dto.PermissionRead, &manager.ID, nil); err != nil {
+		t.Fatalf("add role manager: %v", err)
+	}
+
+	escalated := []Capability{CapRolesManage, CapMembersRead, CapOrgDelete}
+	if err := svc.UpdateRole(ctx, orgID, 2, manager.ID, "role-manager", escalated); !errors.Is(err, ErrCapabilityNotHeld) {
+		t.Fatalf("add org.delete to own role = %v, want ErrCapabilityNotHeld", err)
+	}
+	if _, err := svc.CreateRole(ctx, orgID, 2, "deleter", []Capability{CapOrgDelete}); !errors.Is(err, ErrCapabilityNotHeld) {
+		t.Fatalf("create role with org.delete = %v, want ErrCapabilityNotHeld", err)
+	}
+	if _, err := svc.CreateRole(ctx, orgID, 2, "viewer", []Capability{CapMembersRead}); err != nil {
+		t.Fatalf("create role within held capabilities: %v", err)
+	}
+
+	// A role held by someone else that allows more than user 2 holds cannot
+	// be changed by user 2, not even to take capabilities away.
+	auditor, err := svc.CreateRole(ctx, orgID, 1, "auditor", []Capability{CapMembersRead, CapAuditRead})
+	if err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 3, dto.PermissionRead, &auditor.ID, nil); err != nil {
+		t.Fatalf("add auditor: %v", err)
+	}
+	if err := svc.UpdateRole(ctx, orgID, 2, auditor.ID, "auditor", []Capability{CapMembersRead}); !errors.Is(err, ErrCapabilityNotHeld) {
+		t.Fatalf("change a role allowing more than held = %v, want ErrCapabilityNotHeld", err)
+	}
+	if err := svc.UpdateRole(ctx, orgID, 1, auditor.ID, "auditor", []Capability{CapMembersRead}); err != nil {
+		t.Fatalf("ROOT changes the role: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package organizations
+
+import (
+	"maps"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+// memberState is a membership as seen while checking a change against the
+// rest of the organization.
+type memberState struct {
+	permission dto.PermissionType
+	roleID     *uint
+	active     bool
+	permanent  bool
+}
+
+// root reports whether the membership makes its user a ROOT member. A
+// custom role replaces the capabilities of the permission, so a ROOT
+// membership with one does not count.
+func (m memberState) root() bool {
+	return m.active && m.permission == dto.PermissionRoot && m.roleID == nil
+}
+
+// permanentRoot reports whether the membership is ROOT and never expires.
+func (m memberState) permanentRoot() bool {
+	return m.root() && m.permanent
+}
+
+// loadMemberStates returns the memberships of an organization keyed by user.
+func loadMemberStates(repo *organizations.Repository, orgID uint) (map[uint]memberState, error) {
+	memberships, err := repo.GetOrgUsers(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	states := make(map[uint]memberState, len(memberships))
+	for _, membership := range memberships {
+		states[membership.UserID] = memberState{
+			permission: dto.PermissionType(membership.Permission),
+			roleID:     membership.RoleID,
+			active:     membership.ExpiresAt == nil || membership.ExpiresAt.After(now),
+			permanent:  membership.ExpiresAt == nil,
+		}
+	}
+	return states, nil
+}
+
+func countRoots(states map[uint]memberState, isRoot func(memberState) bool) int {
+	roots := 0
+	for _, state := range states {
+		if isRoot(state) {
+			roots++
+		}
+	}
+	return roots
+}
+
+// isLastRoot reports whether userID has a ROOT membership, active or not,
+// and no other member is an active ROOT member.
+func isLastRoot(states map[uint]memberState, userID uint) bool {
+	state, ok := states[userID]
+	if !ok || state.permission != dto.PermissionRoot || state.roleID != nil {
+		return false
+	}
+	for otherID, other := range states {
+		if otherID != userID && other.root() {
+			return false
+		}
+	}
+	return true
+}
+
+// keepsRoot reports whether going from current to final leaves the
+// organization a ROOT member. An organization with a permanent ROOT member
+// must keep one, since a ROOT membership that expires leaves it without an
+// owner once the expiry passes; one whose ROOT members all expire must at
+// least keep one of them.
+func keepsRoot(current, final map[uint]memberState) bool {
+	if countRoots(current, memberState.permanentRoot) > 0 {
+		return countRoots(final, memberState.permanentRoot) > 0
+	}
+	return countRoots(current, memberState.root) == 0 || countRoots(final, memberState.root) > 0
+}
+
+// ensureKeepsRoot fails with ErrLastRoot when giving userID the membership
+// next, or removing it when next is nil, would leave the organization
+// without a ROOT member as keepsRoot defines it. repo must hold the
+// organization lock so concurrent changes cannot both pass.
+func ensureKeepsRoot(repo *organizations.Repository, orgID, userID uint, next *memberState) error {
+	current, err := loadMemberStates(repo, orgID)
+	if err != nil {
+		return err
+	}
+
+	final := maps.Clone(current)
+	if next == nil {
+		delete(final, userID)
+	} else {
+		final[userID] = *next
+	}
+	if !keepsRoot(current, final) {
+		return ErrLastRoot
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func TestMemberState_Root(t *testing.T) {
+	roleID := uint(4)
+	cases := []struct {
+		name  string
+		state memberState
+		want  bool
+	}{
+		{"active ROOT", memberState{permission: dto.PermissionRoot, active: true}, true},
+		{"expired ROOT", memberState{permission: dto.PermissionRoot}, false},
+		{"ROOT with a custom role", memberState{permission: dto.PermissionRoot, roleID: &roleID, active: true}, false},
+		{"active WRITE", memberState{permission: dto.PermissionWrite, active: true}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.state.root(); got != tc.want {
+			t.Errorf("%s: root = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestKeepsRoot(t *testing.T) {
+	permanentRoot := memberState{permission: dto.PermissionRoot, active: true, permanent: true}
+	expiringRoot := memberState{permission: dto.PermissionRoot, active: true}
+	reader := memberState{permission: dto.PermissionRead, active: true, permanent: true}
+
+	cases := []struct {
+		name           string
+		current, final map[uint]memberState
+		want           bool
+	}{
+		{"permanent ROOT kept", map[uint]memberState{1: permanentRoot, 2: reader}, map[uint]memberState{1: permanentRoot}, true},
+		{"last permanent ROOT removed", map[uint]memberState{1: permanentRoot}, map[uint]memberState{}, false},
+		{"last permanent ROOT made to expire", map[uint]memberState{1: permanentRoot}, map[uint]memberState{1: expiringRoot}, false},
+		{"permanent ROOT replaced by an expiring one", map[uint]memberState{1: permanentRoot, 2: expiringRoot}, map[uint]memberState{2: expiringRoot}, false},
+		{"only expiring ROOT members, one kept", map[uint]memberState{1: expiringRoot, 2: expiringRoot}, map[uint]memberState{2: expiringRoot}, true},
+		{"only expiring ROOT members, none kept", map[uint]memberState{1: expiringRoot}, map[uint]memberState{1: reader}, false},
+		{"no ROOT member to begin with", map[uint]memberState{1: reader}, map[uint]memberState{}, true},
+	}
+	for _, tc := range cases {
+		if got := keepsRoot(tc.current, tc.final); got != tc.want {
+			t.Errorf("%s: keepsRoot = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIsLastRoot(t *testing.T) {
+	expiredRoot := memberState{permission: dto.PermissionRoot}
+	activeRoot := memberState{permission: dto.PermissionRoot, active: true, permanent: true}
+	reader := memberState{permission: dto.PermissionRead, active: true, permanent: true}
+
+	if !isLastRoot(map[uint]memberState{1: expiredRoot, 2: reader}, 1) {
+		t.Error("an expired ROOT with no other ROOT should be the last one")
+	}
+	if isLastRoot(map[uint]memberState{1: expiredRoot, 2: activeRoot}, 1) {
+		t.Error("an expired ROOT next to an active ROOT is not the last one")
+	}
+	if !isLastRoot(map[uint]memberState{1: expiredRoot, 2: expiredRoot}, 1) {
+		t.Error("other expired ROOT members do not keep the organization")
+	}
+	if isLastRoot(map[uint]memberState{2: reader}, 2) {
+		t.Error("a READ member is never the last ROOT")
+	}
+}
+
+func TestUpdateUserPermission_KeepsLastRoot(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	if err := svc.UpdateUserPermission(ctx, orgID, 1, 1, dto.PermissionWrite, nil); !errors.Is(err, ErrLastRoot) {
+		t.Fatalf("demote last ROOT = %v, want ErrLastRoot", err)
+	}
+	role, err := svc.CreateRole(ctx, orgID, 1, "auditor", []Capability{CapMembersRead, CapAuditRead})
+	if err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+	if err := svc.UpdateUserPermission(ctx, orgID, 1, 1, dto.PermissionRoot, &role.ID); !errors.Is(err, ErrLastRoot) {
+		t.Fatalf("give last ROOT a custom role = %v, want ErrLastRoot", err)
+	}
+
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 2, dto.PermissionRoot, nil, nil); err != nil {
+		t.Fatalf("add second ROOT: %v", err)
+	}
+	if err := svc.UpdateUserPermission(ctx, orgID, 1, 1, dto.PermissionWrite, nil); err != nil {
+		t.Fatalf("demote with another ROOT left: %v", err)
+	}
+}
+
+func TestRemoveUserFromOrg_KeepsLastRoot(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	if err := svc.RemoveUserFromOrg(ctx, orgID, 1, 1); !errors.Is(err, ErrLastRoot) {
+		t.Fatalf("remove last ROOT = %v, want ErrLastRoot", err)
+	}
+	if member, _ := svc.repo.IsOrgMember(orgID, 1); !member {
+		t.Fatal("last ROOT was removed")
+	}
+
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 2, dto.PermissionRoot, nil, nil); err != nil {
+		t.Fatalf("add second ROOT: %v", err)
+	}
+	if err := svc.RemoveUserFromOrg(ctx, orgID, 2, 1); err != nil {
+		t.Fatalf("remove with another ROOT left: %v", err)
+	}
+}
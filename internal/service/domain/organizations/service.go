@@ -3,51 +3,154 @@ package organizations
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/oidc"
+	"meu-treino-golang/users-crud/internal/service"
 	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
 )
 
 type IOrganizationService interface {
-	CreateOrg(ctx context.Context, name string) (uint, error)
+	CreateOrg(ctx context.Context, name string, creatorID uint) (uint, error)
 	GetOrg(ctx context.Context, orgID uint) (*OrganizationDTO, error)
-	ListOrgs(ctx context.Context) ([]OrganizationDTO, error)
+	ResolveOrgRef(ctx context.Context, ref string) (uint, string, error)
+	SetParent(ctx context.Context, orgID uint, parentID *uint, inheritParentAccess bool) error
+	SetInheritParentAccess(ctx context.Context, orgID uint, inheritParentAccess bool) error
+	ListAncestors(ctx context.Context, orgID uint) ([]OrgNodeDTO, error)
+	ListSubtree(ctx context.Context, orgID uint) ([]OrgNodeDTO, error)
+	ListOrgs(ctx context.Context, includeArchived bool) ([]OrganizationDTO, error)
 	UpdateOrg(ctx context.Context, orgID uint, name string) error
-	DeleteOrg(ctx context.Context, orgID uint) error
+	GetDeletionImpact(ctx context.Context, orgID uint) (*DeletionImpact, error)
+	RequestOrgDeletion(ctx context.Context, orgID, requestedBy uint) (*DeletionJobDTO, error)
+	GetDeletionJob(ctx context.Context, jobID uint) (*DeletionJobDTO, error)
+	ArchiveOrg(ctx context.Context, orgID uint) error
+	UnarchiveOrg(ctx context.Context, orgID uint) error
+	GetUsage(ctx context.Context, orgID uint) (*Usage, error)
+	SetPlan(ctx context.Context, orgID uint, plan string) error
+	IsPlatformAdmin(ctx context.Context, userID uint) (bool, error)
+	ListAllOrgs(ctx context.Context, page common.Pagination) ([]OrgSummaryDTO, common.Pagination, error)
+	ListAuditLog(ctx context.Context, filter AuditFilter, page common.Pagination) ([]AuditEntryDTO, common.Pagination, error)
+	GetSettings(ctx context.Context, orgID uint) (Settings, error)
+	UpdateSettings(ctx context.Context, orgID uint, patch map[string]json.RawMessage) (Settings, error)
 	
-	AddUserToOrg(ctx context.Context, orgID, userID uint, permission dto.PermissionType) error
+	AddUserToOrg(ctx context.Context, orgID, actorID, userID uint, permission dto.PermissionType, roleID *uint, expiresAt *time.Time) error
 	GetOrgUsers(ctx context.Context, orgID uint) ([]OrgUserDTO, error)
-	UpdateUserPermission(ctx context.Context, orgID, userID uint, permission dto.PermissionType) error
-	RemoveUserFromOrg(ctx context.Context, orgID, userID uint) error
+	BulkUpdateMembers(ctx context.Context, orgID, actorID uint, ops []BulkOperation) ([]BulkResult, error)
+	SetMembershipExpiry(ctx context.Context, orgID, actorID, userID uint, expiresAt *time.Time) error
+	ListExpiringMembers(ctx context.Context, orgID uint, within time.Duration) ([]OrgUserDTO, error)
+	UpdateUserPermission(ctx context.Context, orgID, actorID, userID uint, permission dto.PermissionType, roleID *uint) error
+	RemoveUserFromOrg(ctx context.Context, orgID, actorID, userID uint) error
 	GetUserPermissionInOrg(ctx context.Context, orgID, userID uint) (dto.PermissionType, error)
+	Authorize(ctx context.Context, orgID, userID uint, capability Capability) error
+	ListUserOrgs(ctx context.Context, userID uint, minPermission dto.PermissionType, page common.Pagination) ([]UserOrgDTO, common.Pagination, error)
+
+	CreateRole(ctx context.Context, orgID, actorID uint, name string, capabilities []Capability) (*RoleDTO, error)
+	ListRoles(ctx context.Context, orgID uint) ([]RoleDTO, error)
+	UpdateRole(ctx context.Context, orgID, actorID, roleID uint, name string, capabilities []Capability) error
+	DeleteRole(ctx context.Context, orgID, roleID uint) error
+
+	CreateTeam(ctx context.Context, orgID, actorID uint, name string, permission dto.PermissionType) (*TeamDTO, error)
+	GetTeam(ctx context.Context, orgID, teamID uint) (*TeamDTO, error)
+	ListTeams(ctx context.Context, orgID uint) ([]TeamDTO, error)
+	UpdateTeam(ctx context.Context, orgID, actorID, teamID uint, name string, permission dto.PermissionType) error
+	DeleteTeam(ctx context.Context, orgID, teamID uint) error
+	AddTeamMember(ctx context.Context, orgID, actorID, teamID, userID uint) error
+	RemoveTeamMember(ctx context.Context, orgID, teamID, userID uint) error
+
+	RequestToJoin(ctx context.Context, orgID, userID uint, message string) (*JoinRequestDTO, error)
+	ListJoinRequests(ctx context.Context, orgID uint, status string) ([]JoinRequestDTO, error)
+	ApproveJoinRequest(ctx context.Context, orgID, requestID, deciderID uint, permission dto.PermissionType) (*JoinRequestDTO, error)
+	RejectJoinRequest(ctx context.Context, orgID, requestID, deciderID uint) (*JoinRequestDTO, error)
+
+	ClaimDomain(ctx context.Context, orgID uint, domain string) (*DomainClaimDTO, error)
+	ListDomainClaims(ctx context.Context, orgID uint) ([]DomainClaimDTO, error)
+	VerifyDomain(ctx context.Context, orgID, claimID uint) (*DomainClaimDTO, error)
+	RemoveDomainClaim(ctx context.Context, orgID, claimID uint) error
+
+	GetSSOConfig(ctx context.Context, orgID uint) (*SSOConfigDTO, error)
+	SetSSOConfig(ctx context.Context, orgID uint, input SSOConfigInput) (*SSOConfigDTO, error)
+	DeleteSSOConfig(ctx context.Context, orgID uint) error
+	StartSSOLogin(ctx context.Context, orgID uint) (authURL, binding string, err error)
+	FinishSSOLogin(ctx context.Context, orgID uint, state, binding, code string) (*service.UserDTO, error)
+
+	InviteUser(ctx context.Context, orgID, inviterID uint, email string, permission dto.PermissionType) (*InvitationDTO, error)
+	ListPendingInvitations(ctx context.Context, orgID uint) ([]InvitationDTO, error)
+	RevokeInvitation(ctx context.Context, orgID, invitationID uint) error
+	ResendInvitation(ctx context.Context, orgID, invitationID uint) error
+	AcceptInvitation(ctx context.Context, token string, userID uint) (*InvitationDTO, error)
+	DeclineInvitation(ctx context.Context, token string) error
 }
 
 type OrganizationDTO struct {
-	ID   uint
-	Name string
+	ID                  uint
+	Name                string
+	Slug                string
+	ParentID            *uint
+	InheritParentAccess bool
+	ArchivedAt          *time.Time
 }
 
 type OrgUserDTO struct {
 	UserID     uint
 	OrgID      uint
 	Permission dto.PermissionType
+	RoleID     *uint
+	RoleName   string
+	ExpiresAt  *time.Time
 }
 
 type Service struct {
-	repo *organizations.Repository
+	repo        *organizations.Repository
+	users       service.IUserRepository
+	mailer      common.Mailer
+	verifier    common.DomainVerifier
+	oidc        *oidc.Client
+	tokenSecret []byte
+	environment string
 }
 
-func NewService(repo *organizations.Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *organizations.Repository, users service.IUserRepository, mailer common.Mailer, verifier common.DomainVerifier, oidcClient *oidc.Client, tokenSecret []byte, environment string) *Service {
+	return &Service{
+		repo:        repo,
+		users:       users,
+		mailer:      mailer,
+		verifier:    verifier,
+		oidc:        oidcClient,
+		tokenSecret: tokenSecret,
+		environment: environment,
+	}
 }
 
-// CreateOrg creates a new organization.
-func (s *Service) CreateOrg(ctx context.Context, name string) (uint, error) {
+// CreateOrg creates a new organization with creatorID as its first ROOT member.
+func (s *Service) CreateOrg(ctx context.Context, name string, creatorID uint) (uint, error) {
+	var orgID uint
+	err := s.audited(ctx, orgEvent(AuditOrgCreated, 0), func(tx *Service, event *auditEvent) (err error) {
+		orgID, err = tx.createOrg(name, creatorID)
+		event.orgID, event.targetID = orgID, orgID
+		return err
+	})
+	return orgID, err
+}
+
+func (s *Service) createOrg(name string, creatorID uint) (uint, error) {
 	if name == "" {
 		return 0, errors.New("organization name cannot be empty")
 	}
-	return s.repo.CreateOrg(name)
+	if creatorID == 0 {
+		return 0, errors.New("organization creator is required")
+	}
+
+	slug, err := s.uniqueSlug(name, 0)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.CreateOrg(name, slug, creatorID)
 }
 
 func (s *Service) GetOrg(ctx context.Context, orgID uint) (*OrganizationDTO, error) {
@@ -56,13 +159,19 @@ func (s *Service) GetOrg(ctx context.Context, orgID uint) (*OrganizationDTO, err
 		return nil, err
 	}
 	return &OrganizationDTO{
-		ID:   org.ID,
-		Name: org.Name,
+		ID:                  org.ID,
+		Name:                org.Name,
+		Slug:                org.Slug,
+		ParentID:            org.ParentID,
+		InheritParentAccess: org.InheritParentAccess,
+		ArchivedAt:          org.ArchivedAt,
 	}, nil
 }
 
-func (s *Service) ListOrgs(ctx context.Context) ([]OrganizationDTO, error) {
-	orgs, err := s.repo.ListOrgs()
+// ListOrgs returns the organizations; archived ones are only included when
+// includeArchived is set.
+func (s *Service) ListOrgs(ctx context.Context, includeArchived bool) ([]OrganizationDTO, error) {
+	orgs, err := s.repo.ListOrgs(includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -70,30 +179,82 @@ func (s *Service) ListOrgs(ctx context.Context) ([]OrganizationDTO, error) {
 	dtos := make([]OrganizationDTO, 0, len(orgs))
 	for _, org := range orgs {
 		dtos = append(dtos, OrganizationDTO{
-			ID:   org.ID,
-			Name: org.Name,
+			ID:                  org.ID,
+			Name:                org.Name,
+			Slug:                org.Slug,
+			ParentID:            org.ParentID,
+			InheritParentAccess: org.InheritParentAccess,
+			ArchivedAt:          org.ArchivedAt,
 		})
 	}
 	return dtos, nil
 }
 
+// UpdateOrg renames an organization and regenerates its slug; the old slug
+// keeps resolving to the organization.
 func (s *Service) UpdateOrg(ctx context.Context, orgID uint, name string) error {
+	return s.audited(ctx, orgEvent(AuditOrgUpdated, orgID), func(tx *Service, _ *auditEvent) error {
+		return tx.updateOrg(orgID, name)
+	})
+}
+
+func (s *Service) updateOrg(orgID uint, name string) error {
 	if name == "" {
 		return errors.New("organization name cannot be empty")
 	}
-	return s.repo.UpdateOrg(orgID, name)
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	slug, err := s.uniqueSlug(name, orgID)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateOrg(orgID, name, slug)
+}
+
+// AddUserToOrg adds a user to an organization with a built-in permission or
+// a custom role. A non-nil expiresAt makes the membership time-bound.
+// actorID cannot grant capabilities it does not hold itself.
+func (s *Service) AddUserToOrg(ctx context.Context, orgID, actorID, userID uint, permission dto.PermissionType, roleID *uint, expiresAt *time.Time) error {
+	return s.audited(ctx, memberEvent(AuditMemberAdded, orgID, userID), func(tx *Service, _ *auditEvent) error {
+		if err := tx.ensureCanGrant(ctx, orgID, actorID, permission, roleID); err != nil {
+			return err
+		}
+		return tx.addUserToOrg(ctx, orgID, userID, permission, roleID, expiresAt)
+	})
 }
 
-func (s *Service) DeleteOrg(ctx context.Context, orgID uint) error {
-	return s.repo.DeleteOrg(orgID)
+// addMember adds a member on the service's own behalf, as domain auto-join
+// and single sign-on do, with no caller whose capabilities limit the grant.
+func (s *Service) addMember(ctx context.Context, orgID, userID uint, permission dto.PermissionType) error {
+	return s.audited(ctx, memberEvent(AuditMemberAdded, orgID, userID), func(tx *Service, _ *auditEvent) error {
+		return tx.addUserToOrg(ctx, orgID, userID, permission, nil, nil)
+	})
 }
 
-// AddUserToOrg adds a user to an organization.
-func (s *Service) AddUserToOrg(ctx context.Context, orgID, userID uint, permission dto.PermissionType) error {
-	if !isValidPermission(permission) {
-		return errors.New("invalid permission type")
+func (s *Service) addUserToOrg(ctx context.Context, orgID, userID uint, permission dto.PermissionType, roleID *uint, expiresAt *time.Time) error {
+	if err := validateExpiry(expiresAt); err != nil {
+		return err
 	}
-	return s.repo.AddUserToOrg(orgID, userID, permission)
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+	if err := s.ensureUserAllowed(ctx, orgID, userID); err != nil {
+		return err
+	}
+
+	permission, roleID, err := s.resolveMemberRole(orgID, permission, roleID)
+	if err != nil {
+		return err
+	}
+	err = s.withinQuota(orgID, func(tx *organizations.Repository) error {
+		return tx.AddUserToOrg(orgID, userID, permission, roleID, expiresAt)
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrAlreadyMember
+	}
+	return err
 }
 
 func (s *Service) GetOrgUsers(ctx context.Context, orgID uint) ([]OrgUserDTO, error) {
@@ -104,32 +265,144 @@ func (s *Service) GetOrgUsers(ctx context.Context, orgID uint) ([]OrgUserDTO, er
 	
 	dtos := make([]OrgUserDTO, 0, len(users))
 	for _, user := range users {
-		dtos = append(dtos, OrgUserDTO{
-			UserID:     user.UserID,
-			OrgID:      user.OrgID,
-			Permission: dto.PermissionType(user.Permission),
-		})
+		dtos = append(dtos, toOrgUserDTO(user))
 	}
 	return dtos, nil
 }
 
-func (s *Service) UpdateUserPermission(ctx context.Context, orgID, userID uint, permission dto.PermissionType) error {
-	if !isValidPermission(permission) {
-		return errors.New("invalid permission type")
+// UpdateUserPermission changes the permission or role of a member. actorID
+// can neither grant capabilities it does not hold nor change a member who
+// holds some, and the organization must keep a ROOT member.
+func (s *Service) UpdateUserPermission(ctx context.Context, orgID, actorID, userID uint, permission dto.PermissionType, roleID *uint) error {
+	return s.audited(ctx, memberEvent(AuditMemberUpdated, orgID, userID), func(tx *Service, _ *auditEvent) error {
+		held, err := tx.heldCapabilities(ctx, orgID, actorID)
+		if err != nil {
+			return err
+		}
+		if err := tx.checkChange(held, orgID, userID); err != nil {
+			return err
+		}
+		if err := tx.checkGrantRequest(held, orgID, permission, roleID); err != nil {
+			return err
+		}
+		return tx.updateUserPermission(orgID, userID, permission, roleID)
+	})
+}
+
+// updateMember changes a member's permission on the service's own behalf,
+// as single sign-on does.
+func (s *Service) updateMember(ctx context.Context, orgID, userID uint, permission dto.PermissionType) error {
+	return s.audited(ctx, memberEvent(AuditMemberUpdated, orgID, userID), func(tx *Service, _ *auditEvent) error {
+		return tx.updateUserPermission(orgID, userID, permission, nil)
+	})
+}
+
+func (s *Service) updateUserPermission(orgID, userID uint, permission dto.PermissionType, roleID *uint) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	permission, roleID, err := s.resolveMemberRole(orgID, permission, roleID)
+	if err != nil {
+		return err
 	}
-	return s.repo.UpdateUserPermission(orgID, userID, permission)
+	return s.withinQuota(orgID, func(tx *organizations.Repository) error {
+		membership, err := tx.GetMembership(orgID, userID)
+		if err != nil {
+			return err
+		}
+		next := memberState{permission: permission, roleID: roleID, active: true, permanent: membership.ExpiresAt == nil}
+		if err := ensureKeepsRoot(tx, orgID, userID, &next); err != nil {
+			return err
+		}
+		return tx.UpdateUserPermission(orgID, userID, permission, roleID)
+	})
 }
 
-func (s *Service) RemoveUserFromOrg(ctx context.Context, orgID, userID uint) error {
-	return s.repo.RemoveUserFromOrg(orgID, userID)
+// RemoveUserFromOrg ends a membership. actorID cannot remove a member who
+// holds capabilities it does not hold itself, nor the last ROOT member.
+func (s *Service) RemoveUserFromOrg(ctx context.Context, orgID, actorID, userID uint) error {
+	return s.audited(ctx, memberEvent(AuditMemberRemoved, orgID, userID), func(tx *Service, _ *auditEvent) error {
+		if err := tx.ensureCanChange(ctx, orgID, actorID, userID); err != nil {
+			return err
+		}
+		return tx.removeUserFromOrg(orgID, userID)
+	})
 }
 
+func (s *Service) removeUserFromOrg(orgID, userID uint) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+	return s.repo.LockOrg(orgID, func(tx *organizations.Repository, _ *organizations.OrganizationModel) error {
+		if err := ensureKeepsRoot(tx, orgID, userID, nil); err != nil {
+			return err
+		}
+		return tx.RemoveUserFromOrg(orgID, userID)
+	})
+}
+
+// GetUserPermissionInOrg returns the user's effective permission: the
+// highest of the direct membership, every team grant and ROOT inherited from
+// a parent organization.
 func (s *Service) GetUserPermissionInOrg(ctx context.Context, orgID, userID uint) (dto.PermissionType, error) {
-	return s.repo.GetUserPermissionInOrg(orgID, userID)
+	local, err := s.localPermission(orgID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if local != dto.PermissionRoot {
+		inherited, err := s.inheritsRoot(orgID, userID)
+		if err != nil {
+			return "", err
+		}
+		if inherited {
+			local = dto.PermissionRoot
+		}
+	}
+
+	if LevelOf(local) == LevelNone {
+		return "", gorm.ErrRecordNotFound
+	}
+	return local, nil
+}
+
+// localPermission returns the highest permission a user holds in the
+// organization itself, through direct membership or teams, or "" when none.
+func (s *Service) localPermission(orgID, userID uint) (dto.PermissionType, error) {
+	direct, err := s.repo.GetUserPermissionInOrg(orgID, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	teamPermissions, err := s.repo.GetUserTeamPermissions(orgID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	effective := direct
+	for _, permission := range teamPermissions {
+		if LevelOf(dto.PermissionType(permission)) > LevelOf(effective) {
+			effective = dto.PermissionType(permission)
+		}
+	}
+	return effective, nil
 }
 
 func isValidPermission(permission dto.PermissionType) bool {
-	return permission == dto.PermissionRead || 
-		   permission == dto.PermissionWrite || 
-		   permission == dto.PermissionRoot
+	return LevelOf(permission) != LevelNone
+}
+
+func toOrgUserDTO(user organizations.OrgUserModel) OrgUserDTO {
+	orgUser := OrgUserDTO{
+		UserID:     user.UserID,
+		OrgID:      user.OrgID,
+		Permission: dto.PermissionType(user.Permission),
+		RoleID:     user.RoleID,
+		ExpiresAt:  user.ExpiresAt,
+	}
+	if user.Role != nil {
+		orgUser.RoleName = user.Role.Name
+	}
+	return orgUser
 }
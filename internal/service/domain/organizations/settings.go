@@ -0,0 +1,327 @@
+package organizations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+// SettingKey names a per-organization setting.
+type SettingKey string
+
+const (
+	SettingDefaultMemberPermission SettingKey = "default_member_permission"
+	SettingAllowedEmailDomains     SettingKey = "allowed_email_domains"
+	SettingJoinRequestsEnabled     SettingKey = "join_requests_enabled"
+	SettingMembershipExpiryAction  SettingKey = "membership_expiry_action"
+	SettingRequireTwoFactorForRoot SettingKey = "require_two_factor_for_root"
+)
+
+// SettingType is the JSON type a setting value must have.
+type SettingType string
+
+const (
+	SettingTypeBool       SettingType = "bool"
+	SettingTypeString     SettingType = "string"
+	SettingTypeStringList SettingType = "string_list"
+)
+
+// SettingDefinition describes a known setting. normalize, when set, validates
+// a decoded value and returns it in canonical form.
+type SettingDefinition struct {
+	Key         SettingKey
+	Type        SettingType
+	Default     any
+	Description string
+
+	normalize func(value any) (any, error)
+}
+
+// settingRegistry lists every setting an organization may override. Values
+// are always handed out in the Go type matching Type: bool, string or
+// []string.
+var settingRegistry = map[SettingKey]SettingDefinition{
+	SettingDefaultMemberPermission: {
+		Key:         SettingDefaultMemberPermission,
+		Type:        SettingTypeString,
+		Default:     string(dto.PermissionRead),
+		Description: "Permission given to members who join without an explicit one (READ or WRITE).",
+		normalize:   normalizeDefaultPermission,
+	},
+	SettingAllowedEmailDomains: {
+		Key:         SettingAllowedEmailDomains,
+		Type:        SettingTypeStringList,
+		Default:     []string{},
+		Description: "Email domains the organization accepts members from. Empty allows any domain.",
+		normalize:   normalizeEmailDomains,
+	},
+	SettingJoinRequestsEnabled: {
+		Key:         SettingJoinRequestsEnabled,
+		Type:        SettingTypeBool,
+		Default:     false,
+		Description: "Whether users may ask to join the organization.",
+	},
+	SettingMembershipExpiryAction: {
+		Key:         SettingMembershipExpiryAction,
+		Type:        SettingTypeString,
+		Default:     organizations.ExpiryActionRemove,
+		Description: "What happens to an expired membership: REMOVE it or DOWNGRADE it to a permanent READ membership.",
+		normalize:   normalizeExpiryAction,
+	},
+	SettingRequireTwoFactorForRoot: {
+		Key:         SettingRequireTwoFactorForRoot,
+		Type:        SettingTypeBool,
+		Default:     false,
+		Description: "Whether ROOT-level operations need the member to have two-factor authentication enabled.",
+	},
+}
+
+// Settings holds the effective settings of an organization: every known key,
+// either overridden or at its default.
+type Settings map[SettingKey]any
+
+// SettingDefinitions returns every known setting ordered by key.
+func SettingDefinitions() []SettingDefinition {
+	definitions := make([]SettingDefinition, 0, len(settingRegistry))
+	for _, definition := range settingRegistry {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Key < definitions[j].Key })
+	return definitions
+}
+
+// DefaultSettings returns the settings of an organization that overrides none.
+func DefaultSettings() Settings {
+	settings := make(Settings, len(settingRegistry))
+	for key, definition := range settingRegistry {
+		settings[key] = definition.Default
+	}
+	return settings
+}
+
+// Bool returns a boolean setting, falling back to its default.
+func (s Settings) Bool(key SettingKey) bool {
+	if value, ok := s[key].(bool); ok {
+		return value
+	}
+	value, _ := settingRegistry[key].Default.(bool)
+	return value
+}
+
+// String returns a string setting, falling back to its default.
+func (s Settings) String(key SettingKey) string {
+	if value, ok := s[key].(string); ok {
+		return value
+	}
+	value, _ := settingRegistry[key].Default.(string)
+	return value
+}
+
+// StringList returns a list setting, falling back to its default.
+func (s Settings) StringList(key SettingKey) []string {
+	if value, ok := s[key].([]string); ok {
+		return value
+	}
+	value, _ := settingRegistry[key].Default.([]string)
+	return value
+}
+
+// ensureEmailAllowed returns ErrEmailDomainNotAllowed when the organization
+// restricts its members to allowed_email_domains and email is not in one.
+func (s *Service) ensureEmailAllowed(ctx context.Context, orgID uint, email string) error {
+	settings, err := s.GetSettings(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if !emailAllowed(settings.StringList(SettingAllowedEmailDomains), email) {
+		return ErrEmailDomainNotAllowed
+	}
+	return nil
+}
+
+// ensureUserAllowed is ensureEmailAllowed for the email of userID.
+func (s *Service) ensureUserAllowed(ctx context.Context, orgID, userID uint) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.ensureEmailAllowed(ctx, orgID, user.Email)
+}
+
+// emailAllowed reports whether email belongs to one of domains. An empty
+// list allows every address.
+func emailAllowed(domains []string, email string) bool {
+	return len(domains) == 0 || slices.Contains(domains, emailDomain(email))
+}
+
+// GetSettings returns the effective settings of an organization. Stored
+// values that no longer pass validation fall back to their default.
+func (s *Service) GetSettings(ctx context.Context, orgID uint) (Settings, error) {
+	if _, err := s.repo.GetOrg(orgID); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repo.GetOrgSettings(orgID)
+	if err != nil {
+		return nil, err
+	}
+	return effectiveSettings(stored), nil
+}
+
+// UpdateSettings applies a partial update. A null value resets the setting to
+// its default. The patch is rejected as a whole if any key is unknown or any
+// value is invalid.
+func (s *Service) UpdateSettings(ctx context.Context, orgID uint, patch map[string]json.RawMessage) (Settings, error) {
+	var settings Settings
+	err := s.audited(ctx, settingsEvent(orgID), func(tx *Service, _ *auditEvent) (err error) {
+		settings, err = tx.updateSettings(orgID, patch)
+		return err
+	})
+	return settings, err
+}
+
+func (s *Service) updateSettings(orgID uint, patch map[string]json.RawMessage) (Settings, error) {
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+
+	set := organizations.SettingValues{}
+	var unset []string
+	for name, raw := range patch {
+		definition, ok := settingRegistry[SettingKey(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSetting, name)
+		}
+		if isJSONNull(raw) {
+			unset = append(unset, name)
+			continue
+		}
+
+		value, err := definition.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		set[name] = encoded
+	}
+
+	stored, err := s.repo.PatchOrgSettings(orgID, set, unset)
+	if err != nil {
+		return nil, err
+	}
+	return effectiveSettings(stored), nil
+}
+
+// Parse decodes and validates a JSON value for the setting.
+func (d SettingDefinition) Parse(raw json.RawMessage) (any, error) {
+	var value any
+	var err error
+	switch d.Type {
+	case SettingTypeBool:
+		var v bool
+		err = json.Unmarshal(raw, &v)
+		value = v
+	case SettingTypeString:
+		var v string
+		err = json.Unmarshal(raw, &v)
+		value = v
+	case SettingTypeStringList:
+		var v []string
+		err = json.Unmarshal(raw, &v)
+		if v == nil {
+			v = []string{}
+		}
+		value = v
+	default:
+		return nil, fmt.Errorf("%w: %s has unsupported type %s", ErrInvalidSetting, d.Key, d.Type)
+	}
+	if err != nil || isJSONNull(raw) {
+		return nil, fmt.Errorf("%w: %s must be a %s", ErrInvalidSetting, d.Key, d.Type)
+	}
+
+	if d.normalize != nil {
+		value, err = d.normalize(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSetting, d.Key, err)
+		}
+	}
+	return value, nil
+}
+
+func effectiveSettings(stored organizations.SettingValues) Settings {
+	settings := DefaultSettings()
+	for name, raw := range stored {
+		definition, ok := settingRegistry[SettingKey(name)]
+		if !ok {
+			continue
+		}
+		if value, err := definition.Parse(raw); err == nil {
+			settings[definition.Key] = value
+		}
+	}
+	return settings
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return strings.TrimSpace(string(raw)) == "null"
+}
+
+func normalizeDefaultPermission(value any) (any, error) {
+	permission := dto.PermissionType(strings.ToUpper(value.(string)))
+	if permission != dto.PermissionRead && permission != dto.PermissionWrite {
+		return nil, errors.New("must be READ or WRITE")
+	}
+	return string(permission), nil
+}
+
+func normalizeExpiryAction(value any) (any, error) {
+	action := strings.ToUpper(value.(string))
+	if action != organizations.ExpiryActionRemove && action != organizations.ExpiryActionDowngrade {
+		return nil, errors.New("must be REMOVE or DOWNGRADE")
+	}
+	return action, nil
+}
+
+func normalizeEmailDomains(value any) (any, error) {
+	seen := map[string]bool{}
+	domains := []string{}
+	for _, domain := range value.([]string) {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if !isValidDomain(domain) {
+			return nil, fmt.Errorf("%q is not a valid domain", domain)
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+// isValidDomain accepts lowercase hostnames with at least two labels.
+func isValidDomain(domain string) bool {
+	if len(domain) > 253 || !strings.Contains(domain, ".") {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package organizations
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+func TestSettingDefinition_Parse(t *testing.T) {
+	cases := []struct {
+		key  SettingKey
+		raw  string
+		want any
+	}{
+		{SettingDefaultMemberPermission, `"write"`, "WRITE"},
+		{SettingAllowedEmailDomains, `[" Acme.com", "acme.com", "mail.acme.com.br"]`, []string{"acme.com", "mail.acme.com.br"}},
+		{SettingAllowedEmailDomains, `[]`, []string{}},
+		{SettingMembershipExpiryAction, `"downgrade"`, "DOWNGRADE"},
+		{SettingRequireTwoFactorForRoot, `true`, true},
+	}
+	for _, tc := range cases {
+		got, err := settingRegistry[tc.key].Parse(json.RawMessage(tc.raw))
+		if err != nil {
+			t.Fatalf("%s %s: unexpected error %v", tc.key, tc.raw, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s %s = %#v, want %#v", tc.key, tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestSettingDefinition_ParseRejectsInvalidValues(t *testing.T) {
+	cases := []struct {
+		key SettingKey
+		raw string
+	}{
+		{SettingDefaultMemberPermission, `"ROOT"`},
+		{SettingDefaultMemberPermission, `1`},
+		{SettingAllowedEmailDomains, `["user@acme.com"]`},
+		{SettingAllowedEmailDomains, `["localhost"]`},
+		{SettingAllowedEmailDomains, `"acme.com"`},
+		{SettingJoinRequestsEnabled, `"true"`},
+		{SettingMembershipExpiryAction, `"KEEP"`},
+	}
+	for _, tc := range cases {
+		if _, err := settingRegistry[tc.key].Parse(json.RawMessage(tc.raw)); !errors.Is(err, ErrInvalidSetting) {
+			t.Fatalf("%s %s: expected ErrInvalidSetting, got %v", tc.key, tc.raw, err)
+		}
+	}
+}
+
+func TestEffectiveSettings_FallsBackToDefaults(t *testing.T) {
+	settings := effectiveSettings(organizations.SettingValues{
+		string(SettingJoinRequestsEnabled):     json.RawMessage(`true`),
+		string(SettingDefaultMemberPermission): json.RawMessage(`"ROOT"`),
+		"session_lifetime_minutes":             json.RawMessage(`120`),
+	})
+
+	if !settings.Bool(SettingJoinRequestsEnabled) {
+		t.Fatal("stored join_requests_enabled should be kept")
+	}
+	if got := settings.String(SettingDefaultMemberPermission); got != "READ" {
+		t.Fatalf("invalid stored value should fall back to default, got %q", got)
+	}
+	if got := settings.StringList(SettingAllowedEmailDomains); len(got) != 0 {
+		t.Fatalf("allowed domains = %v, want empty", got)
+	}
+	if _, ok := settings["session_lifetime_minutes"]; ok {
+		t.Fatal("unknown stored keys must be ignored")
+	}
+}
+
+func TestDefaultSettings_JoinRequestsDisabled(t *testing.T) {
+	if DefaultSettings().Bool(SettingJoinRequestsEnabled) {
+		t.Fatal("join requests must be opt-in")
+	}
+}
+
+func TestEmailAllowed(t *testing.T) {
+	cases := []struct {
+		domains []string
+		email   string
+		want    bool
+	}{
+		{nil, "ana@anywhere.com", true},
+		{[]string{}, "ana@anywhere.com", true},
+		{[]string{"acme.com"}, "ana@acme.com", true},
+		{[]string{"acme.com"}, "Ana@ACME.com", true},
+		{[]string{"acme.com", "acme.com.br"}, "ana@acme.com.br", true},
+		{[]string{"acme.com"}, "ana@mail.acme.com", false},
+		{[]string{"acme.com"}, "ana@evil.com", false},
+		{[]string{"acme.com"}, "not-an-email", false},
+	}
+	for _, tc := range cases {
+		if got := emailAllowed(tc.domains, tc.email); got != tc.want {
+			t.Errorf("emailAllowed(%v, %q) = %v, want %v", tc.domains, tc.email, got, tc.want)
+		}
+	}
+}
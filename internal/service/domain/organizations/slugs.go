@@ -0,0 +1,155 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+const maxSlugLength = 48
+
+// reservedSlugs cannot be used as organization handles because they collide
+// with routes or are likely to confuse users.
+var reservedSlugs = map[string]bool{
+	"admin":       true,
+	"api":         true,
+	"invitations": true,
+	"me":          true,
+	"new":         true,
+	"org":         true,
+	"orgs":        true,
+	"root":        true,
+	"settings":    true,
+	"system":      true,
+	"users":       true,
+}
+
+// Slugify turns a name into a URL-safe handle: lowercase ASCII letters,
+// digits and single hyphens. Accents are dropped ("Organização" becomes
+// "organizacao"). Reserved words and purely numeric results, which would be
+// mistaken for an ID, are prefixed with "org-".
+func Slugify(name string) string {
+	folded, _, err := transform.String(
+		transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC),
+		name,
+	)
+	if err != nil {
+		folded = name
+	}
+
+	var b strings.Builder
+	lastHyphen := true
+	for _, r := range strings.ToLower(folded) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			lastHyphen = false
+		case !lastHyphen:
+			b.WriteByte('-')
+			lastHyphen = true
+		}
+	}
+
+	slug := strings.Trim(b.String(), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+
+	if slug == "" {
+		return "org"
+	}
+	if _, err := strconv.ParseUint(slug, 10, 64); err == nil || reservedSlugs[slug] {
+		return "org-" + slug
+	}
+	return slug
+}
+
+// uniqueSlug derives a slug from name that no other organization uses or
+// used, appending "-2", "-3", ... on collision. orgID is the organization
+// being renamed, or zero for a new one; it may reclaim its own old slugs.
+func (s *Service) uniqueSlug(name string, orgID uint) (string, error) {
+	base := Slugify(name)
+	for i := 1; ; i++ {
+		candidate := base
+		if i > 1 {
+			suffix := fmt.Sprintf("-%d", i)
+			candidate = strings.TrimRight(truncate(base, maxSlugLength-len(suffix)), "-") + suffix
+		}
+
+		owner, err := s.repo.SlugOwner(candidate)
+		if err != nil {
+			return "", err
+		}
+		if owner == 0 || owner == orgID {
+			return candidate, nil
+		}
+	}
+}
+
+// ResolveOrgRef turns an organization reference from a URL, either a numeric
+// ID or a slug, into the organization ID. For slugs it also returns the
+// current slug, which differs from ref when ref is an old slug.
+func (s *Service) ResolveOrgRef(ctx context.Context, ref string) (uint, string, error) {
+	if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		return uint(id), "", nil
+	}
+
+	ref = strings.ToLower(ref)
+	org, err := s.repo.GetOrgBySlug(ref)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		org, err = s.repo.GetOrgBySlugHistory(ref)
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return org.ID, org.Slug, nil
+}
+
+// Migrate prepares organization data after the schema migration: it seeds
+// the system roles, makes the audit log append-only, allows one pending
+// invitation per address and gives a slug to organizations created before
+// slugs existed.
+func Migrate(ctx context.Context, repo *organizations.Repository) error {
+	if err := repo.SeedSystemRoles(SystemRoles()); err != nil {
+		return err
+	}
+	if err := repo.MigrateAuditLog(); err != nil {
+		return err
+	}
+	if err := repo.MigrateInvitations(); err != nil {
+		return err
+	}
+
+	s := &Service{repo: repo}
+	orgs, err := repo.ListOrgsWithoutSlug()
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		slug, err := s.uniqueSlug(org.Name, org.ID)
+		if err != nil {
+			return err
+		}
+		if err := repo.SetOrgSlug(org.ID, slug); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return value[:length]
+}
@@ -0,0 +1,32 @@
+package organizations
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Acme Corp":            "acme-corp",
+		"  Tech   Company!! ":  "tech-company",
+		"Organização São João": "organizacao-sao-joao",
+		"ACME---Corp & Sons":   "acme-corp-sons",
+		"2024":                 "org-2024",
+		"Admin":                "org-admin",
+		"!!!":                  "org",
+		"über-café_ltd.":       "uber-cafe-ltd",
+	}
+	for name, want := range cases {
+		if got := Slugify(name); got != want {
+			t.Fatalf("Slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSlugify_TruncatesLongNames(t *testing.T) {
+	name := "a very long organization name that keeps going well past the limit"
+	got := Slugify(name)
+	if len(got) > maxSlugLength {
+		t.Fatalf("slug %q longer than %d", got, maxSlugLength)
+	}
+	if got[len(got)-1] == '-' {
+		t.Fatalf("slug %q must not end with a hyphen", got)
+	}
+}
@@ -0,0 +1,463 @@
+package organizations
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/oidc"
+	"meu-treino-golang/users-crud/internal/service"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+// SSOLoginTTL is how long the user has to sign in at the identity provider.
+const SSOLoginTTL = 10 * time.Minute
+
+const defaultGroupsClaim = "groups"
+
+// SSOConfigDTO is an organization's OpenID Connect identity provider. The
+// client secret is never returned.
+type SSOConfigDTO struct {
+	OrgID            uint
+	Issuer           string
+	ClientID         string
+	ClientSecretSet  bool
+	RedirectURL      string
+	GroupsClaim      string
+	GroupPermissions map[string]dto.PermissionType
+	Enabled          bool
+	UpdatedAt        time.Time
+}
+
+// SSOConfigInput configures single sign-on. An empty ClientSecret keeps the
+// stored one; GroupsClaim defaults to "groups".
+type SSOConfigInput struct {
+	Issuer           string
+	ClientID         string
+	ClientSecret     string
+	RedirectURL      string
+	GroupsClaim      string
+	GroupPermissions map[string]dto.PermissionType
+	Enabled          bool
+}
+
+func (s *Service) GetSSOConfig(ctx context.Context, orgID uint) (*SSOConfigDTO, error) {
+	config, err := s.repo.GetSSOConfig(orgID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSSONotConfigured
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := toSSOConfigDTO(*config)
+	return &result, nil
+}
+
+// SetSSOConfig creates or replaces the organization's identity provider.
+// The issuer must publish a discovery document.
+func (s *Service) SetSSOConfig(ctx context.Context, orgID uint, input SSOConfigInput) (*SSOConfigDTO, error) {
+	input, err := normalizeSSOConfig(input, s.environment != common.EnvProduction)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.oidc.Discover(ctx, input.Issuer); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSSOConfig, err)
+	}
+
+	var result *SSOConfigDTO
+	err = s.audited(ctx, ssoEvent(AuditSSOConfigured, orgID), func(tx *Service, _ *auditEvent) (err error) {
+		result, err = tx.setSSOConfig(orgID, input)
+		return err
+	})
+	return result, err
+}
+
+func (s *Service) setSSOConfig(orgID uint, input SSOConfigInput) (*SSOConfigDTO, error) {
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+
+	secret := input.ClientSecret
+	if secret == "" {
+		current, err := s.repo.GetSSOConfig(orgID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if current != nil {
+			secret = current.ClientSecret
+		}
+	}
+
+	permissions := organizations.GroupPermissions{}
+	for group, permission := range input.GroupPermissions {
+		permissions[group] = string(permission)
+	}
+	config := &organizations.OrgSSOConfigModel{
+		OrgID:            orgID,
+		Issuer:           input.Issuer,
+		ClientID:         input.ClientID,
+		ClientSecret:     secret,
+		RedirectURL:      input.RedirectURL,
+		GroupsClaim:      input.GroupsClaim,
+		GroupPermissions: permissions,
+		Enabled:          input.Enabled,
+	}
+	if err := s.repo.SaveSSOConfig(config); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.GetSSOConfig(orgID)
+	if err != nil {
+		return nil, err
+	}
+	result := toSSOConfigDTO(*saved)
+	return &result, nil
+}
+
+// DeleteSSOConfig turns single sign-on off and forgets the identity
+// provider. Users keep their accounts and memberships.
+func (s *Service) DeleteSSOConfig(ctx context.Context, orgID uint) error {
+	return s.audited(ctx, ssoEvent(AuditSSORemoved, orgID), func(tx *Service, _ *auditEvent) error {
+		if err := tx.ensureWritable(orgID); err != nil {
+			return err
+		}
+		if err := tx.repo.DeleteSSOConfig(orgID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSSONotConfigured
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// StartSSOLogin begins a sign-in through the organization's identity
+// provider and returns the URL to send the user to, with the binding the
+// browser must keep for FinishSSOLogin. The state, nonce and PKCE verifier
+// stay on the server until then.
+func (s *Service) StartSSOLogin(ctx context.Context, orgID uint) (authURL, binding string, err error) {
+	config, err := s.enabledSSOConfig(orgID)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err = s.oidc.AuthCodeURL(ctx, oidcConfig(config), state, nonce, challenge)
+	if err != nil {
+		return "", "", err
+	}
+	err = s.repo.CreateSSOLogin(&organizations.OrgSSOLoginModel{
+		State:        state,
+		OrgID:        orgID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(SSOLoginTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, ssoStateBinding(state), nil
+}
+
+// ssoStateBinding is the hash of a sign-in state. The browser that started
+// the sign-in keeps it and must present it at the callback, so a callback
+// URL carrying someone else's state cannot complete a sign-in.
+func ssoStateBinding(state string) string {
+	digest := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(digest[:])
+}
+
+// FinishSSOLogin completes a sign-in when the identity provider redirects
+// back with state and code to the browser holding binding, and returns the
+// signed-in user.
+//
+// Identities already linked sign in as their user. Otherwise the verified
+// email address, which must belong to a domain the organization verified,
+// is linked to the account using it, or a new account is created. The
+// membership then follows the identity provider groups; see
+// syncSSOMembership.
+func (s *Service) FinishSSOLogin(ctx context.Context, orgID uint, state, binding, code string) (*service.UserDTO, error) {
+	if subtle.ConstantTimeCompare([]byte(binding), []byte(ssoStateBinding(state))) != 1 {
+		return nil, ErrSSOStateMismatch
+	}
+	login, err := s.repo.ConsumeSSOLogin(state)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSSOLoginExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if login.OrgID != orgID || time.Now().After(login.ExpiresAt) {
+		return nil, ErrSSOLoginExpired
+	}
+
+	config, err := s.enabledSSOConfig(orgID)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.oidc.Exchange(ctx, oidcConfig(config), code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	permission, mapped, err := ssoPermission(claims.Strings(config.GroupsClaim), config.GroupPermissions)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.ssoUser(ctx, orgID, config.Issuer, claims)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncSSOMembership(ctx, orgID, user.ID, permission, mapped); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ssoUser returns the user of an identity, linking or creating it on first
+// sign-in.
+func (s *Service) ssoUser(ctx context.Context, orgID uint, issuer string, claims *oidc.Claims) (*service.UserDTO, error) {
+	identity, err := s.repo.FindSSOIdentity(orgID, issuer, claims.Subject)
+	if err == nil {
+		return s.users.GetByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if !claims.EmailVerified || claims.Email == "" {
+		return nil, ErrSSOEmailNotVerified
+	}
+	owner, err := s.repo.FindVerifiedDomain(emailDomain(claims.Email))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && owner.OrgID != orgID) {
+		return nil, ErrSSODomainNotOwned
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByEmail(ctx, claims.Email)
+	if errors.Is(err, common.ErrUserNotFound) {
+		user, err = s.provisionSSOUser(ctx, claims)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event := auditEvent{action: AuditSSOIdentityLinked, orgID: orgID, targetType: AuditTargetMember, targetID: user.ID}
+	err = s.audited(ctx, event, func(tx *Service, event *auditEvent) error {
+		event.after = map[string]interface{}{"issuer": issuer, "subject": claims.Subject, "email": claims.Email}
+		return tx.repo.CreateSSOIdentity(&organizations.OrgSSOIdentityModel{
+			OrgID:   orgID,
+			Issuer:  issuer,
+			Subject: claims.Subject,
+			UserID:  user.ID,
+		})
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// A concurrent sign-in of the same identity linked it first.
+		identity, err := s.repo.FindSSOIdentity(orgID, issuer, claims.Subject)
+		if err != nil {
+			return nil, err
+		}
+		return s.users.GetByID(ctx, identity.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("sso: linked %s subject %q to user %d in organization %d", issuer, claims.Subject, user.ID, orgID)
+	return user, nil
+}
+
+// provisionSSOUser creates the account of an identity signing in for the
+// first time. It has no password; the user signs in through SSO or sets
+// one later. Domain auto-join does not run, since syncSSOMembership adds
+// the membership.
+func (s *Service) provisionSSOUser(ctx context.Context, claims *oidc.Claims) (*service.UserDTO, error) {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = claims.Email[:strings.LastIndex(claims.Email, "@")]
+	}
+
+	userID, err := s.users.Create(ctx, name, claims.Email, "")
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// A concurrent sign-in created the account first.
+		return s.users.GetByEmail(ctx, claims.Email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("sso: provisioned user %d for %s", userID, claims.Email)
+	return s.users.GetByID(ctx, userID)
+}
+
+// syncSSOMembership makes the user a member of the organization. New
+// members get the permission mapped from their groups, or the default
+// member permission when the organization maps no groups. Existing members
+// follow their mapped permission, except those given a custom role, which
+// is left to the organization's admins, and the last ROOT member, who keeps
+// ROOT so the organization is not left without one.
+func (s *Service) syncSSOMembership(ctx context.Context, orgID, userID uint, permission dto.PermissionType, mapped bool) error {
+	membership, err := s.repo.GetMembership(orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if !mapped {
+			settings, err := s.GetSettings(ctx, orgID)
+			if err != nil {
+				return err
+			}
+			permission = dto.PermissionType(settings.String(SettingDefaultMemberPermission))
+		}
+		return s.addMember(ctx, orgID, userID, permission)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !mapped || membership.RoleID != nil || dto.PermissionType(membership.Permission) == permission {
+		return nil
+	}
+	err = s.updateMember(ctx, orgID, userID, permission)
+	if errors.Is(err, ErrLastRoot) {
+		log.Printf("sso: kept user %d ROOT in organization %d instead of %s: they are its last ROOT member", userID, orgID, permission)
+		return nil
+	}
+	return err
+}
+
+// ssoPermission returns the highest permission the groups are mapped to.
+// mapped is false when the organization maps no groups. When it does, a
+// user in none of them is refused with ErrSSOAccessDenied.
+func ssoPermission(groups []string, mapping organizations.GroupPermissions) (dto.PermissionType, bool, error) {
+	if len(mapping) == 0 {
+		return "", false, nil
+	}
+
+	best := LevelNone
+	for _, group := range groups {
+		if level := LevelOf(dto.PermissionType(mapping[group])); level > best {
+			best = level
+		}
+	}
+	if best == LevelNone {
+		return "", true, ErrSSOAccessDenied
+	}
+	return dto.PermissionType(best.String()), true, nil
+}
+
+// normalizeSSOConfig validates a configuration and fills in its defaults.
+// allowLoopback accepts http issuers on the loopback interface.
+func normalizeSSOConfig(input SSOConfigInput, allowLoopback bool) (SSOConfigInput, error) {
+	input.Issuer = strings.TrimSpace(input.Issuer)
+	input.ClientID = strings.TrimSpace(input.ClientID)
+	input.RedirectURL = strings.TrimSpace(input.RedirectURL)
+	input.GroupsClaim = strings.TrimSpace(input.GroupsClaim)
+	if input.GroupsClaim == "" {
+		input.GroupsClaim = defaultGroupsClaim
+	}
+
+	switch {
+	case !isSecureURL(input.Issuer, allowLoopback):
+		return input, fmt.Errorf("%w: issuer must be an https URL", ErrInvalidSSOConfig)
+	case input.ClientID == "" || len(input.ClientID) > 255:
+		return input, fmt.Errorf("%w: client_id is required", ErrInvalidSSOConfig)
+	case len(input.GroupsClaim) > 64:
+		return input, fmt.Errorf("%w: groups_claim is too long", ErrInvalidSSOConfig)
+	}
+	redirect, err := url.Parse(input.RedirectURL)
+	if err != nil || (redirect.Scheme != "https" && redirect.Scheme != "http") || redirect.Host == "" || len(input.RedirectURL) > 2048 {
+		return input, fmt.Errorf("%w: redirect_url must be an absolute URL", ErrInvalidSSOConfig)
+	}
+	for group, permission := range input.GroupPermissions {
+		if group == "" || LevelOf(permission) == LevelNone {
+			return input, fmt.Errorf("%w: group %q maps to unknown permission %q", ErrInvalidSSOConfig, group, permission)
+		}
+	}
+	return input, nil
+}
+
+// isSecureURL accepts https URLs and, when allowLoopback is set, http URLs
+// on the loopback interface for local identity providers. Production never
+// allows them, so an issuer cannot point the server at its own services.
+func isSecureURL(raw string, allowLoopback bool) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return false
+	}
+	if parsed.Scheme == "https" {
+		return true
+	}
+	if parsed.Scheme != "http" || !allowLoopback {
+		return false
+	}
+	if parsed.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(parsed.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Service) enabledSSOConfig(orgID uint) (*organizations.OrgSSOConfigModel, error) {
+	config, err := s.repo.GetSSOConfig(orgID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSSONotConfigured
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return nil, ErrSSONotConfigured
+	}
+	return config, nil
+}
+
+func oidcConfig(config *organizations.OrgSSOConfigModel) oidc.Config {
+	return oidc.Config{
+		Issuer:       config.Issuer,
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RedirectURL:  config.RedirectURL,
+	}
+}
+
+func toSSOConfigDTO(config organizations.OrgSSOConfigModel) SSOConfigDTO {
+	permissions := make(map[string]dto.PermissionType, len(config.GroupPermissions))
+	for group, permission := range config.GroupPermissions {
+		permissions[group] = dto.PermissionType(permission)
+	}
+	return SSOConfigDTO{
+		OrgID:            config.OrgID,
+		Issuer:           config.Issuer,
+		ClientID:         config.ClientID,
+		ClientSecretSet:  config.ClientSecret != "",
+		RedirectURL:      config.RedirectURL,
+		GroupsClaim:      config.GroupsClaim,
+		GroupPermissions: permissions,
+		Enabled:          config.Enabled,
+		UpdatedAt:        config.UpdatedAt,
+	}
+}
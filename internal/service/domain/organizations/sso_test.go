@@ -0,0 +1,279 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/oidc"
+	"meu-treino-golang/users-crud/internal/oidc/oidctest"
+	"meu-treino-golang/users-crud/internal/service"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+func TestSSOPermission(t *testing.T) {
+	mapping := organizations.GroupPermissions{
+		"staff":       "READ",
+		"engineering": "WRITE",
+		"admins":      "ROOT",
+	}
+
+	cases := []struct {
+		groups []string
+		want   dto.PermissionType
+		err    error
+	}{
+		{[]string{"staff"}, dto.PermissionRead, nil},
+		{[]string{"staff", "admins", "engineering"}, dto.PermissionRoot, nil},
+		{[]string{"sales", "engineering"}, dto.PermissionWrite, nil},
+		{[]string{"sales"}, "", ErrSSOAccessDenied},
+		{nil, "", ErrSSOAccessDenied},
+	}
+	for _, tc := range cases {
+		got, mapped, err := ssoPermission(tc.groups, mapping)
+		if got != tc.want || !mapped || !errors.Is(err, tc.err) {
+			t.Fatalf("ssoPermission(%v) = %q, %v, %v; want %q, %v", tc.groups, got, mapped, err, tc.want, tc.err)
+		}
+	}
+
+	// Without a mapping every user gets in, with the default permission.
+	if got, mapped, err := ssoPermission([]string{"admins"}, nil); got != "" || mapped || err != nil {
+		t.Fatalf("no mapping: %q, %v, %v", got, mapped, err)
+	}
+}
+
+func TestNormalizeSSOConfig(t *testing.T) {
+	valid := SSOConfigInput{
+		Issuer:      " https://login.acme.com ",
+		ClientID:    "users-crud",
+		RedirectURL: "https://app.acme.com/api/auth/sso/acme/callback",
+	}
+	got, err := normalizeSSOConfig(valid, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Issuer != "https://login.acme.com" || got.GroupsClaim != "groups" {
+		t.Fatalf("normalized = %+v", got)
+	}
+
+	invalid := map[string]func(*SSOConfigInput){
+		"http issuer":        func(in *SSOConfigInput) { in.Issuer = "http://login.acme.com" },
+		"issuer with query":  func(in *SSOConfigInput) { in.Issuer = "https://login.acme.com?tenant=1" },
+		"no client id":       func(in *SSOConfigInput) { in.ClientID = " " },
+		"relative redirect":  func(in *SSOConfigInput) { in.RedirectURL = "/api/auth/sso/acme/callback" },
+		"unknown permission": func(in *SSOConfigInput) { in.GroupPermissions = map[string]dto.PermissionType{"admins": "OWNER"} },
+		"empty group":        func(in *SSOConfigInput) { in.GroupPermissions = map[string]dto.PermissionType{"": "READ"} },
+	}
+	for name, change := range invalid {
+		input := valid
+		change(&input)
+		if _, err := normalizeSSOConfig(input, true); !errors.Is(err, ErrInvalidSSOConfig) {
+			t.Fatalf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestIsSecureURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://login.acme.com":         true,
+		"https://login.acme.com/tenant/": true,
+		"http://localhost:8081":          true,
+		"http://127.0.0.1:8081":          true,
+		"http://[::1]:8081":              true,
+		"http://login.acme.com":          false,
+		"ftp://login.acme.com":           false,
+		"login.acme.com":                 false,
+		"https://login.acme.com#x":       false,
+	}
+	for raw, want := range cases {
+		if got := isSecureURL(raw, true); got != want {
+			t.Fatalf("isSecureURL(%q) = %v, want %v", raw, got, want)
+		}
+	}
+
+	// Production accepts only https.
+	for raw, want := range map[string]bool{
+		"https://login.acme.com": true,
+		"http://localhost:8081":  false,
+		"http://127.0.0.1:8081":  false,
+		"http://[::1]:8081":      false,
+	} {
+		if got := isSecureURL(raw, false); got != want {
+			t.Fatalf("isSecureURL(%q) without loopback = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestSetSSOConfig_RejectsLoopbackIssuerInProduction(t *testing.T) {
+	provider := oidctest.NewProvider(t, "users-crud", "secret")
+	s := &Service{oidc: oidc.NewClient(nil), environment: common.EnvProduction}
+
+	_, err := s.SetSSOConfig(context.Background(), 1, SSOConfigInput{
+		Issuer:      provider.Issuer,
+		ClientID:    "users-crud",
+		RedirectURL: "https://app.acme.com/api/auth/sso/acme/callback",
+	})
+	if !errors.Is(err, ErrInvalidSSOConfig) || errors.Is(err, oidc.ErrProvider) {
+		t.Fatalf("err = %v, want the issuer rejected before discovery", err)
+	}
+}
+
+func TestFinishSSOLogin_RequiresBinding(t *testing.T) {
+	s := &Service{}
+	state := "state-from-the-callback-url"
+
+	for _, binding := range []string{"", ssoStateBinding("another-state"), state} {
+		if _, err := s.FinishSSOLogin(context.Background(), 1, state, binding, "code"); !errors.Is(err, ErrSSOStateMismatch) {
+			t.Fatalf("binding %q: err = %v, want ErrSSOStateMismatch", binding, err)
+		}
+	}
+}
+
+func TestSetSSOConfig_ChecksDiscoveryBeforeStorage(t *testing.T) {
+	provider := oidctest.NewProvider(t, "users-crud", "secret")
+	s := &Service{oidc: oidc.NewClient(nil)}
+
+	_, err := s.SetSSOConfig(context.Background(), 1, SSOConfigInput{
+		Issuer:      provider.Issuer + "/not-an-issuer",
+		ClientID:    "users-crud",
+		RedirectURL: "https://app.acme.com/api/auth/sso/acme/callback",
+	})
+	if !errors.Is(err, ErrInvalidSSOConfig) || !errors.Is(err, oidc.ErrProvider) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// signUpUsers lets sign-ins find users by email and create new ones.
+type signUpUsers struct {
+	stubUsers
+}
+
+func (u signUpUsers) Create(ctx context.Context, name, email, passwordHash string) (uint, error) {
+	id := uint(len(u.stubUsers) + 1)
+	u.stubUsers[id] = service.UserDTO{ID: id, Name: name, Email: email}
+	return id, nil
+}
+
+func (u signUpUsers) GetByEmail(ctx context.Context, email string) (*service.UserDTO, error) {
+	for _, user := range u.stubUsers {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, common.ErrUserNotFound
+}
+
+// signIn runs a whole sign-in of user at the provider.
+func signIn(t *testing.T, svc *Service, provider *oidctest.Provider, orgID uint, user oidctest.User) (*service.UserDTO, error) {
+	t.Helper()
+	ctx := context.Background()
+	authURL, binding, err := svc.StartSSOLogin(ctx, orgID)
+	if err != nil {
+		t.Fatalf("start sign-in: %v", err)
+	}
+	provider.User = user
+	callback, err := provider.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	query := callback.Query()
+	return svc.FinishSSOLogin(ctx, orgID, query.Get("state"), binding, query.Get("code"))
+}
+
+func TestFinishSSOLogin(t *testing.T) {
+	ctx := context.Background()
+	users := signUpUsers{stubUsers{
+		1: {ID: 1, Email: "owner@acme.test"},
+		2: {ID: 2, Email: "alice@acme.test"},
+	}}
+	svc := openService(t, users)
+	svc.oidc = oidc.NewClient(nil)
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	now := time.Now()
+	claim := &organizations.OrgDomainModel{OrgID: orgID, Domain: "acme.test", Token: "token"}
+	if err := svc.repo.CreateDomainClaim(claim); err != nil {
+		t.Fatalf("claim domain: %v", err)
+	}
+	if err := svc.repo.MarkDomainVerified(claim.ID, now); err != nil {
+		t.Fatalf("verify domain: %v", err)
+	}
+
+	provider := oidctest.NewProvider(t, "users-crud", "secret")
+	_, err := svc.SetSSOConfig(ctx, orgID, SSOConfigInput{
+		Issuer:       provider.Issuer,
+		ClientID:     "users-crud",
+		ClientSecret: "secret",
+		RedirectURL:  "https://app.acme.test/api/auth/sso/acme/callback",
+		GroupPermissions: map[string]dto.PermissionType{
+			"staff":       dto.PermissionRead,
+			"engineering": dto.PermissionWrite,
+			"admins":      dto.PermissionRoot,
+		},
+		Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("configure SSO: %v", err)
+	}
+
+	permissionOf := func(userID uint) dto.PermissionType {
+		t.Helper()
+		membership, err := svc.repo.GetMembership(orgID, userID)
+		if err != nil {
+			t.Fatalf("membership of %d: %v", userID, err)
+		}
+		return dto.PermissionType(membership.Permission)
+	}
+
+	// An unknown verified email gets an account, with the highest
+	// permission its groups map to.
+	user, err := signIn(t, svc, provider, orgID, oidctest.User{
+		Subject: "bob", Email: "bob@acme.test", EmailVerified: true, Name: "Bob", Groups: []string{"staff", "engineering"},
+	})
+	if err != nil {
+		t.Fatalf("provisioning sign-in: %v", err)
+	}
+	if user.Email != "bob@acme.test" || permissionOf(user.ID) != dto.PermissionWrite {
+		t.Fatalf("provisioned %+v as %s, want bob as WRITE", user, permissionOf(user.ID))
+	}
+
+	// A verified email of an existing account is linked to it, and later
+	// sign-ins follow the groups.
+	alice := oidctest.User{Subject: "alice", Email: "alice@acme.test", EmailVerified: true, Groups: []string{"admins"}}
+	if user, err = signIn(t, svc, provider, orgID, alice); err != nil || user.ID != 2 {
+		t.Fatalf("linking sign-in = %+v, %v, want user 2", user, err)
+	}
+	if permissionOf(2) != dto.PermissionRoot {
+		t.Fatalf("alice = %s, want ROOT", permissionOf(2))
+	}
+	alice.Groups = []string{"staff"}
+	if user, err = signIn(t, svc, provider, orgID, alice); err != nil || user.ID != 2 || permissionOf(2) != dto.PermissionRead {
+		t.Fatalf("second sign-in = %+v, %v as %s, want user 2 as READ", user, err, permissionOf(2))
+	}
+
+	// Unverified emails are not trusted, and users outside the mapped
+	// groups are turned away.
+	if _, err := signIn(t, svc, provider, orgID, oidctest.User{
+		Subject: "eve", Email: "owner@acme.test", Groups: []string{"admins"},
+	}); !errors.Is(err, ErrSSOEmailNotVerified) {
+		t.Fatalf("unverified email: err = %v, want ErrSSOEmailNotVerified", err)
+	}
+	if _, err := signIn(t, svc, provider, orgID, oidctest.User{
+		Subject: "carol", Email: "carol@acme.test", EmailVerified: true, Groups: []string{"sales"},
+	}); !errors.Is(err, ErrSSOAccessDenied) {
+		t.Fatalf("unmapped groups: err = %v, want ErrSSOAccessDenied", err)
+	}
+
+	// The last ROOT member keeps ROOT when the groups would downgrade them.
+	if user, err = signIn(t, svc, provider, orgID, oidctest.User{
+		Subject: "owner", Email: "owner@acme.test", EmailVerified: true, Groups: []string{"staff"},
+	}); err != nil || user.ID != 1 {
+		t.Fatalf("last ROOT sign-in = %+v, %v, want user 1", user, err)
+	}
+	if permissionOf(1) != dto.PermissionRoot {
+		t.Fatalf("last ROOT = %s, want ROOT kept", permissionOf(1))
+	}
+}
@@ -0,0 +1,220 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+type TeamDTO struct {
+	ID         uint
+	OrgID      uint
+	Name       string
+	Permission dto.PermissionType
+	MemberIDs  []uint
+}
+
+// CreateTeam creates a team whose members receive permission in the
+// organization. actorID must hold everything permission allows.
+func (s *Service) CreateTeam(ctx context.Context, orgID, actorID uint, name string, permission dto.PermissionType) (*TeamDTO, error) {
+	var team *TeamDTO
+	err := s.audited(ctx, teamEvent(AuditTeamCreated, orgID, 0), func(tx *Service, event *auditEvent) (err error) {
+		team, err = tx.createTeam(ctx, orgID, actorID, name, permission)
+		if err == nil {
+			event.targetID = team.ID
+		}
+		return err
+	})
+	return team, err
+}
+
+func (s *Service) createTeam(ctx context.Context, orgID, actorID uint, name string, permission dto.PermissionType) (*TeamDTO, error) {
+	if err := s.ensureWritable(orgID); err != nil {
+		return nil, err
+	}
+
+	name, err := validateTeam(name, permission)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureHoldsPermission(ctx, orgID, actorID, permission); err != nil {
+		return nil, err
+	}
+
+	var team *organizations.TeamModel
+	err = s.withinQuota(orgID, func(tx *organizations.Repository) error {
+		team, err = tx.CreateTeam(orgID, name, string(permission))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := toTeamDTO(*team)
+	return &result, nil
+}
+
+func (s *Service) GetTeam(ctx context.Context, orgID, teamID uint) (*TeamDTO, error) {
+	team, err := s.repo.GetTeam(orgID, teamID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, err
+	}
+	result := toTeamDTO(*team)
+	return &result, nil
+}
+
+func (s *Service) ListTeams(ctx context.Context, orgID uint) ([]TeamDTO, error) {
+	teams, err := s.repo.ListTeams(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]TeamDTO, 0, len(teams))
+	for _, team := range teams {
+		dtos = append(dtos, toTeamDTO(team))
+	}
+	return dtos, nil
+}
+
+// UpdateTeam renames a team and changes its permission. actorID must hold
+// everything both the current and the new permission allow.
+func (s *Service) UpdateTeam(ctx context.Context, orgID, actorID, teamID uint, name string, permission dto.PermissionType) error {
+	return s.audited(ctx, teamEvent(AuditTeamUpdated, orgID, teamID), func(tx *Service, _ *auditEvent) error {
+		return tx.updateTeam(ctx, orgID, actorID, teamID, name, permission)
+	})
+}
+
+func (s *Service) updateTeam(ctx context.Context, orgID, actorID, teamID uint, name string, permission dto.PermissionType) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	name, err := validateTeam(name, permission)
+	if err != nil {
+		return err
+	}
+	team, err := s.GetTeam(ctx, orgID, teamID)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureHoldsPermission(ctx, orgID, actorID, team.Permission, permission); err != nil {
+		return err
+	}
+	return s.withinQuota(orgID, func(tx *organizations.Repository) error {
+		return tx.UpdateTeam(orgID, teamID, name, string(permission))
+	})
+}
+
+func (s *Service) DeleteTeam(ctx context.Context, orgID, teamID uint) error {
+	return s.audited(ctx, teamEvent(AuditTeamDeleted, orgID, teamID), func(tx *Service, _ *auditEvent) error {
+		return tx.deleteTeam(ctx, orgID, teamID)
+	})
+}
+
+func (s *Service) deleteTeam(ctx context.Context, orgID, teamID uint) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	if _, err := s.GetTeam(ctx, orgID, teamID); err != nil {
+		return err
+	}
+	return s.repo.DeleteTeam(orgID, teamID)
+}
+
+// AddTeamMember adds userID to a team. actorID must hold everything the
+// team's permission allows.
+func (s *Service) AddTeamMember(ctx context.Context, orgID, actorID, teamID, userID uint) error {
+	return s.audited(ctx, teamEvent(AuditTeamMemberAdded, orgID, teamID), func(tx *Service, _ *auditEvent) error {
+		return tx.addTeamMember(ctx, orgID, actorID, teamID, userID)
+	})
+}
+
+func (s *Service) addTeamMember(ctx context.Context, orgID, actorID, teamID, userID uint) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	team, err := s.GetTeam(ctx, orgID, teamID)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureHoldsPermission(ctx, orgID, actorID, team.Permission); err != nil {
+		return err
+	}
+	// Teams only widen what members may do; they never make someone a member.
+	if _, err := s.repo.GetMembership(orgID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotMember
+		}
+		return err
+	}
+	return s.withinQuota(orgID, func(tx *organizations.Repository) error {
+		return tx.AddTeamMember(teamID, userID)
+	})
+}
+
+func (s *Service) RemoveTeamMember(ctx context.Context, orgID, teamID, userID uint) error {
+	return s.audited(ctx, teamEvent(AuditTeamMemberRemoved, orgID, teamID), func(tx *Service, _ *auditEvent) error {
+		return tx.removeTeamMember(ctx, orgID, teamID, userID)
+	})
+}
+
+func (s *Service) removeTeamMember(ctx context.Context, orgID, teamID, userID uint) error {
+	if err := s.ensureWritable(orgID); err != nil {
+		return err
+	}
+
+	if _, err := s.GetTeam(ctx, orgID, teamID); err != nil {
+		return err
+	}
+	return s.repo.RemoveTeamMember(teamID, userID)
+}
+
+// ensureHoldsPermission fails with ErrCapabilityNotHeld unless actorID holds
+// everything each of permissions allows, so teams cannot hand out more than
+// their managers have (see ensureCanGrant).
+func (s *Service) ensureHoldsPermission(ctx context.Context, orgID, actorID uint, permissions ...dto.PermissionType) error {
+	held, err := s.heldCapabilities(ctx, orgID, actorID)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if !holdsAll(held, CapabilitiesFor(permission)) {
+			return ErrCapabilityNotHeld
+		}
+	}
+	return nil
+}
+
+func validateTeam(name string, permission dto.PermissionType) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("team name cannot be empty")
+	}
+	if !isValidPermission(permission) {
+		return "", ErrInvalidPermission
+	}
+	return name, nil
+}
+
+func toTeamDTO(team organizations.TeamModel) TeamDTO {
+	memberIDs := make([]uint, 0, len(team.Members))
+	for _, member := range team.Members {
+		memberIDs = append(memberIDs, member.UserID)
+	}
+	return TeamDTO{
+		ID:         team.ID,
+		OrgID:      team.OrgID,
+		Name:       team.Name,
+		Permission: dto.PermissionType(team.Permission),
+		MemberIDs:  memberIDs,
+	}
+}
@@ -0,0 +1,74 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func TestAddTeamMember_RequiresMembership(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	team, err := svc.CreateTeam(ctx, orgID, 1, "admins", dto.PermissionRoot)
+	if err != nil {
+		t.Fatalf("create team: %v", err)
+	}
+
+	if err := svc.AddTeamMember(ctx, orgID, 1, team.ID, 2); !errors.Is(err, ErrNotMember) {
+		t.Fatalf("non-member: err = %v, want ErrNotMember", err)
+	}
+
+	if err := svc.AddUserToOrg(ctx, orgID, 1, 2, dto.PermissionRead, nil, nil); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if err := svc.AddTeamMember(ctx, orgID, 1, team.ID, 2); err != nil {
+		t.Fatalf("member: err = %v", err)
+	}
+}
+
+func TestTeams_CannotGrantPermissionNotHeld(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3}})
+	orgID := mustCreateOrg(t, svc, "acme", 1)
+
+	// User 2 may read the organization and manage teams, and nothing else.
+	capabilities := append(CapabilitiesFor(dto.PermissionRead), CapTeamsManage)
+	manager, err := svc.CreateRole(ctx, orgID, 1, "team-manager", capabilities)
+	if err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+	for userID, roleID := range map[uint]*uint{2: &manager.ID, 3: nil} {
+		if err := svc.AddUserToOrg(ctx, orgID, 1, userID, dto.PermissionRead, roleID, nil); err != nil {
+			t.Fatalf("add member %d: %v", userID, err)
+		}
+	}
+
+	if _, err := svc.CreateTeam(ctx, orgID, 2, "admins", dto.PermissionRoot); !errors.Is(err, ErrCapabilityNotHeld) {
+		t.Fatalf("create ROOT team = %v, want ErrCapabilityNotHeld", err)
+	}
+	readers, err := svc.CreateTeam(ctx, orgID, 2, "readers", dto.PermissionRead)
+	if err != nil {
+		t.Fatalf("create READ team: %v", err)
+	}
+	if err := svc.UpdateTeam(ctx, orgID, 2, readers.ID, "readers", dto.PermissionWrite); !errors.Is(err, ErrCapabilityNotHeld) {
+		t.Fatalf("raise team to WRITE = %v, want ErrCapabilityNotHeld", err)
+	}
+	if err := svc.AddTeamMember(ctx, orgID, 2, readers.ID, 3); err != nil {
+		t.Fatalf("add member to READ team: %v", err)
+	}
+
+	admins, err := svc.CreateTeam(ctx, orgID, 1, "admins", dto.PermissionRoot)
+	if err != nil {
+		t.Fatalf("ROOT creates ROOT team: %v", err)
+	}
+	if err := svc.AddTeamMember(ctx, orgID, 2, admins.ID, 2); !errors.Is(err, ErrCapabilityNotHeld) {
+		t.Fatalf("join ROOT team = %v, want ErrCapabilityNotHeld", err)
+	}
+	if err := svc.UpdateTeam(ctx, orgID, 2, admins.ID, "admins", dto.PermissionRead); !errors.Is(err, ErrCapabilityNotHeld) {
+		t.Fatalf("downgrade ROOT team = %v, want ErrCapabilityNotHeld", err)
+	}
+}
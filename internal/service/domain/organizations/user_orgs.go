@@ -0,0 +1,95 @@
+package organizations
+
+import (
+	"context"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+)
+
+// UserOrgDTO is an organization seen from one of its members. Permission is
+// the member's effective level, custom role and teams included.
+type UserOrgDTO struct {
+	OrgID      uint
+	Name       string
+	Slug       string
+	Permission dto.PermissionType
+	RoleID     *uint
+	RoleName   string
+	ExpiresAt  *time.Time
+}
+
+// ListUserOrgs returns the organizations a user belongs to. When
+// minPermission is set, only memberships whose effective capabilities in the
+// organization (custom role and teams included) reach that level are listed.
+func (s *Service) ListUserOrgs(ctx context.Context, userID uint, minPermission dto.PermissionType, page common.Pagination) ([]UserOrgDTO, common.Pagination, error) {
+	page.Normalize()
+	minLevel := LevelNone
+	if minPermission != "" {
+		if minLevel = LevelOf(minPermission); minLevel == LevelNone {
+			return nil, page, ErrInvalidPermission
+		}
+	}
+
+	teamPermissions, err := s.repo.ListUserTeamPermissions(userID)
+	if err != nil {
+		return nil, page, err
+	}
+
+	if minLevel == LevelNone {
+		memberships, total, err := s.repo.ListUserOrgs(userID, page.Offset(), page.Limit)
+		if err != nil {
+			return nil, page, err
+		}
+		page.Total = total
+		return toUserOrgDTOs(memberships, teamPermissions), page, nil
+	}
+
+	memberships, err := s.repo.ListUserMemberships(userID)
+	if err != nil {
+		return nil, page, err
+	}
+
+	var matching []organizations.OrgUserModel
+	for _, membership := range memberships {
+		if effectiveLevel(membership, teamPermissions).Satisfies(minLevel) {
+			matching = append(matching, membership)
+		}
+	}
+
+	page.Total = int64(len(matching))
+	from := min(page.Offset(), len(matching))
+	to := min(from+page.Limit, len(matching))
+	return toUserOrgDTOs(matching[from:to], teamPermissions), page, nil
+}
+
+// effectiveLevel returns the level a membership reaches with the user's
+// team permissions in its organization.
+func effectiveLevel(membership organizations.OrgUserModel, teamPermissions map[uint][]string) PermissionLevel {
+	granted := membershipCapabilities(membership)
+	for _, permission := range teamPermissions[membership.OrgID] {
+		granted = append(granted, CapabilitiesFor(dto.PermissionType(permission))...)
+	}
+	return LevelOfCapabilities(granted)
+}
+
+func toUserOrgDTOs(memberships []organizations.OrgUserModel, teamPermissions map[uint][]string) []UserOrgDTO {
+	dtos := make([]UserOrgDTO, 0, len(memberships))
+	for _, membership := range memberships {
+		userOrg := UserOrgDTO{
+			OrgID:      membership.OrgID,
+			Name:       membership.Organization.Name,
+			Slug:       membership.Organization.Slug,
+			Permission: dto.PermissionType(effectiveLevel(membership, teamPermissions).String()),
+			RoleID:     membership.RoleID,
+			ExpiresAt:  membership.ExpiresAt,
+		}
+		if membership.Role != nil {
+			userOrg.RoleName = membership.Role.Name
+		}
+		dtos = append(dtos, userOrg)
+	}
+	return dtos
+}
@@ -0,0 +1,129 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+)
+
+// seedUserOrgs makes user 2 a READ member of acme, a WRITE member of globex
+// and a READ member of initech who is ROOT there through a team.
+func seedUserOrgs(t *testing.T, svc *Service) {
+	t.Helper()
+	ctx := context.Background()
+	for name, permission := range map[string]dto.PermissionType{
+		"acme":    dto.PermissionRead,
+		"globex":  dto.PermissionWrite,
+		"initech": dto.PermissionRead,
+	} {
+		orgID := mustCreateOrg(t, svc, name, 1)
+		if err := svc.AddUserToOrg(ctx, orgID, 1, 2, permission, nil, nil); err != nil {
+			t.Fatalf("add member to %s: %v", name, err)
+		}
+		if name != "initech" {
+			continue
+		}
+		team, err := svc.CreateTeam(ctx, orgID, 1, "admins", dto.PermissionRoot)
+		if err != nil {
+			t.Fatalf("create team: %v", err)
+		}
+		if err := svc.AddTeamMember(ctx, orgID, 1, team.ID, 2); err != nil {
+			t.Fatalf("add team member: %v", err)
+		}
+	}
+}
+
+func userOrgPermissions(orgs []UserOrgDTO) map[string]dto.PermissionType {
+	permissions := make(map[string]dto.PermissionType, len(orgs))
+	for _, org := range orgs {
+		permissions[org.Name] = org.Permission
+	}
+	return permissions
+}
+
+func TestListUserOrgs_EffectivePermission(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}})
+	seedUserOrgs(t, svc)
+
+	orgs, page, err := svc.ListUserOrgs(ctx, 2, "", common.Pagination{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Total != 3 {
+		t.Fatalf("total = %d, want 3", page.Total)
+	}
+	want := map[string]dto.PermissionType{
+		"acme":    dto.PermissionRead,
+		"globex":  dto.PermissionWrite,
+		"initech": dto.PermissionRoot,
+	}
+	got := userOrgPermissions(orgs)
+	for name, permission := range want {
+		if got[name] != permission {
+			t.Errorf("%s permission = %q, want %q", name, got[name], permission)
+		}
+	}
+}
+
+func TestListUserOrgs_MinPermission(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}})
+	seedUserOrgs(t, svc)
+
+	tests := []struct {
+		minPermission dto.PermissionType
+		want          []string
+	}{
+		{dto.PermissionRead, []string{"acme", "globex", "initech"}},
+		{dto.PermissionWrite, []string{"globex", "initech"}},
+		{dto.PermissionRoot, []string{"initech"}},
+	}
+	for _, tt := range tests {
+		orgs, page, err := svc.ListUserOrgs(ctx, 2, tt.minPermission, common.Pagination{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.minPermission, err)
+		}
+		got := userOrgPermissions(orgs)
+		if len(orgs) != len(tt.want) || page.Total != int64(len(tt.want)) {
+			t.Fatalf("%s: got %v (total %d), want %v", tt.minPermission, got, page.Total, tt.want)
+		}
+		for _, name := range tt.want {
+			if _, ok := got[name]; !ok {
+				t.Errorf("%s: %s missing from %v", tt.minPermission, name, got)
+			}
+		}
+	}
+
+	if _, _, err := svc.ListUserOrgs(ctx, 2, "OWNER", common.Pagination{}); !errors.Is(err, ErrInvalidPermission) {
+		t.Fatalf("unknown permission: err = %v, want ErrInvalidPermission", err)
+	}
+}
+
+func TestListUserOrgs_Pagination(t *testing.T) {
+	ctx := context.Background()
+	svc := openService(t, stubUsers{1: {ID: 1}, 2: {ID: 2}})
+	seedUserOrgs(t, svc)
+
+	for _, minPermission := range []dto.PermissionType{"", dto.PermissionRead} {
+		seen := map[string]bool{}
+		for _, tt := range []struct{ page, want int }{{1, 2}, {2, 1}, {3, 0}} {
+			orgs, page, err := svc.ListUserOrgs(ctx, 2, minPermission, common.Pagination{Page: tt.page, Limit: 2})
+			if err != nil {
+				t.Fatalf("page %d: %v", tt.page, err)
+			}
+			if len(orgs) != tt.want || page.Total != 3 {
+				t.Fatalf("min %q page %d: %d orgs (total %d), want %d (total 3)", minPermission, tt.page, len(orgs), page.Total, tt.want)
+			}
+			for _, org := range orgs {
+				if seen[org.Name] {
+					t.Fatalf("min %q: %s listed twice", minPermission, org.Name)
+				}
+				seen[org.Name] = true
+			}
+		}
+	}
+}
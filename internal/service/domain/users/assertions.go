@@ -2,4 +2,7 @@ package users
 
 import "meu-treino-golang/users-crud/internal/service"
 
-var _ service.IUserService = (*Service)(nil)
+var (
+	_ service.IUserService  = (*Service)(nil)
+	_ service.ILoginService = (*LoginService)(nil)
+)
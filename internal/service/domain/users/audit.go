@@ -0,0 +1,54 @@
+package users
+
+import (
+	"context"
+	"strings"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+)
+
+// Audited account actions. They are listed with the organization changes
+// in the platform audit log.
+const (
+	AuditUserImpersonated      = "user.impersonated"
+	AuditUserUnlocked          = "user.unlocked"
+	AuditUserPasswordChanged   = "user.password_changed"
+	AuditUserTwoFactorEnabled  = "user.two_factor_enabled"
+	AuditUserTwoFactorDisabled = "user.two_factor_disabled"
+)
+
+// AuditTargetUser is the target type of account actions; the target ID is
+// the user whose account changed.
+const AuditTargetUser = "user"
+
+// maxAuditUserAgent is the size of the user agent column.
+const maxAuditUserAgent = 512
+
+// accountAudit describes action on the account of userID, made by the actor
+// of the request found in ctx. Under impersonation the actor is the platform
+// admin and the impersonated user is recorded alongside.
+func accountAudit(ctx context.Context, action string, userID uint) service.AuditEntryDTO {
+	request := common.RequestInfoFrom(ctx)
+	userAgent := request.UserAgent
+	if len(userAgent) > maxAuditUserAgent {
+		userAgent = userAgent[:maxAuditUserAgent]
+	}
+	entry := service.AuditEntryDTO{
+		Action:     action,
+		TargetType: AuditTargetUser,
+		TargetID:   userID,
+		IP:         request.IP,
+		UserAgent:  strings.ToValidUTF8(userAgent, ""),
+		RequestID:  request.RequestID,
+	}
+
+	if identity, ok := common.IdentityFrom(ctx); ok {
+		entry.ActorID = identity.UserID
+		if identity.Impersonated() {
+			subject := identity.UserID
+			entry.ActorID, entry.OnBehalfOfID = identity.ActorID, &subject
+		}
+	}
+	return entry
+}
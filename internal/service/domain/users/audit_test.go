@@ -0,0 +1,122 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+)
+
+func TestAccountAudit(t *testing.T) {
+	ctx := common.WithRequestInfo(context.Background(), common.RequestInfo{
+		IP:        "10.0.0.1",
+		UserAgent: strings.Repeat("a", maxAuditUserAgent+10),
+		RequestID: "req-1",
+	})
+
+	entry := accountAudit(common.WithIdentity(ctx, common.Identity{UserID: 5}), AuditUserUnlocked, 7)
+	if entry.ActorID != 5 || entry.OnBehalfOfID != nil || entry.TargetType != AuditTargetUser || entry.TargetID != 7 {
+		t.Fatalf("entry = %+v, want user 5 acting on user 7", entry)
+	}
+	if entry.IP != "10.0.0.1" || entry.RequestID != "req-1" || len(entry.UserAgent) != maxAuditUserAgent {
+		t.Fatalf("request = %q %q (%d bytes of user agent)", entry.IP, entry.RequestID, len(entry.UserAgent))
+	}
+
+	entry = accountAudit(common.WithIdentity(ctx, common.Identity{UserID: 5, ActorID: 9}), AuditUserPasswordChanged, 5)
+	if entry.ActorID != 9 || entry.OnBehalfOfID == nil || *entry.OnBehalfOfID != 5 {
+		t.Fatalf("impersonated entry = %+v, want admin 9 on behalf of 5", entry)
+	}
+}
+
+// auditedActions returns the actions of the recorded entries.
+func auditedActions(entries []service.AuditEntryDTO) []string {
+	actions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+func TestLoginService_AuditsAccountChanges(t *testing.T) {
+	login, _, _, now := newTestLogin(t)
+	audit := login.audit.(*memoryAudit)
+	ctx := common.WithIdentity(context.Background(), common.Identity{UserID: 1})
+
+	if err := login.ChangePassword(ctx, 1, "wrong", "a new password"); !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Fatalf("wrong current password: err = %v", err)
+	}
+	if len(audit.entries) != 0 {
+		t.Fatalf("failed change audited: %+v", audit.entries)
+	}
+	if err := login.ChangePassword(ctx, 1, "correct horse", "a new password"); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, _, err := login.EnrollTwoFactor(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := login.ConfirmTwoFactor(ctx, 1, currentCode(t, secret, *now)); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(totpPeriod)
+	if err := login.DisableTwoFactor(ctx, 1, currentCode(t, secret, *now)); err != nil {
+		t.Fatal(err)
+	}
+
+	admin := common.WithIdentity(context.Background(), common.Identity{UserID: 9})
+	if err := login.Unlock(admin, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{AuditUserPasswordChanged, AuditUserTwoFactorEnabled, AuditUserTwoFactorDisabled, AuditUserUnlocked}
+	if got := auditedActions(audit.entries); !reflect.DeepEqual(got, want) {
+		t.Fatalf("audited %v, want %v", got, want)
+	}
+	for i, actor := range []uint{1, 1, 1, 9} {
+		if entry := audit.entries[i]; entry.ActorID != actor || entry.TargetID != 1 {
+			t.Errorf("%s: actor %d on %d, want %d on 1", entry.Action, entry.ActorID, entry.TargetID, actor)
+		}
+	}
+}
+
+func TestImpersonate_Audited(t *testing.T) {
+	repo := &mockRepo{listResp: []service.UserDTO{
+		{ID: 1, Name: "Ops", PlatformAdmin: true},
+		{ID: 2, Name: "Customer"},
+	}}
+	audit := &memoryAudit{}
+	svc := NewService(repo, audit)
+	ctx := common.WithIdentity(context.Background(), common.Identity{UserID: 1})
+
+	issueErr := errors.New("signing failed")
+	failing := func(*service.UserDTO) error { return issueErr }
+	if _, err := svc.Impersonate(ctx, 1, 2, failing); !errors.Is(err, issueErr) {
+		t.Fatalf("failed issue: err = %v", err)
+	}
+	if _, err := svc.Impersonate(ctx, 1, 1, func(*service.UserDTO) error { return nil }); !errors.Is(err, common.ErrImpersonateSelf) {
+		t.Fatalf("self: err = %v", err)
+	}
+	if len(audit.entries) != 0 {
+		t.Fatalf("refused impersonations audited: %+v", audit.entries)
+	}
+
+	var issued uint
+	subject, err := svc.Impersonate(ctx, 1, 2, func(subject *service.UserDTO) error {
+		issued = subject.ID
+		return nil
+	})
+	if err != nil || subject.ID != 2 || issued != 2 {
+		t.Fatalf("got %+v, %v (issued for %d)", subject, err, issued)
+	}
+	if len(audit.entries) != 1 {
+		t.Fatalf("entries = %+v, want one", audit.entries)
+	}
+	if entry := audit.entries[0]; entry.Action != AuditUserImpersonated || entry.ActorID != 1 || entry.TargetID != 2 {
+		t.Fatalf("entry = %+v, want user 1 impersonating user 2", entry)
+	}
+}
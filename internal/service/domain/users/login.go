@@ -0,0 +1,329 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// securityMetrics counts login outcomes. It is published with expvar and
+// served to platform admins.
+var securityMetrics = expvar.NewMap("security")
+
+// Keys of securityMetrics.
+const (
+	metricLoginSucceeded  = "login_succeeded"
+	metricLoginFailed     = "login_failed"
+	metricLoginThrottled  = "login_throttled"
+	metricAccountLocked   = "account_locked"
+	metricAccountUnlocked = "account_unlocked"
+)
+
+// throttlePolicy slows down repeated failed logins for one key: past the
+// free failures every attempt waits twice as long as the one before, and
+// every lockAfter failures the key is locked for lockFor. Failures are
+// forgotten a window after the last one.
+type throttlePolicy struct {
+	free      int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	lockAfter int
+	lockFor   time.Duration
+	window    time.Duration
+}
+
+var (
+	// accountThrottle applies to an email address, whether or not an
+	// account uses it, so throttling does not reveal which accounts exist.
+	accountThrottle = throttlePolicy{
+		free:      3,
+		baseDelay: time.Second,
+		maxDelay:  time.Minute,
+		lockAfter: 10,
+		lockFor:   15 * time.Minute,
+		window:    24 * time.Hour,
+	}
+	// clientThrottle applies to a client IP and is looser, since many users
+	// may share one address.
+	clientThrottle = throttlePolicy{
+		free:      20,
+		baseDelay: time.Second,
+		maxDelay:  30 * time.Second,
+		lockAfter: 100,
+		lockFor:   15 * time.Minute,
+		window:    time.Hour,
+	}
+)
+
+// current returns attempts without the failures the window has forgotten.
+func (p throttlePolicy) current(attempts service.LoginAttemptsDTO, now time.Time) service.LoginAttemptsDTO {
+	if now.Sub(attempts.LastFailureAt) >= p.window && !attempts.LockedUntil.After(now) {
+		return service.LoginAttemptsDTO{}
+	}
+	return attempts
+}
+
+// wait returns how long the next attempt must wait, zero when it may go
+// ahead now.
+func (p throttlePolicy) wait(attempts service.LoginAttemptsDTO, now time.Time) time.Duration {
+	attempts = p.current(attempts, now)
+	until := attempts.LockedUntil
+	if extra := attempts.Failures - p.free; extra > 0 {
+		delay := p.maxDelay
+		if extra <= 16 {
+			delay = min(p.baseDelay<<(extra-1), p.maxDelay)
+		}
+		if next := attempts.LastFailureAt.Add(delay); next.After(until) {
+			until = next
+		}
+	}
+	if until.After(now) {
+		return until.Sub(now)
+	}
+	return 0
+}
+
+// fail counts a failed attempt. It reports whether the attempt locked the
+// key.
+func (p throttlePolicy) fail(attempts service.LoginAttemptsDTO, now time.Time) (service.LoginAttemptsDTO, bool) {
+	attempts = p.current(attempts, now)
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	if attempts.Failures%p.lockAfter == 0 {
+		attempts.LockedUntil = now.Add(p.lockFor)
+		return attempts, true
+	}
+	return attempts, false
+}
+
+// forgive takes back a failure counted at now by fail, including the
+// lockout it caused.
+func (p throttlePolicy) forgive(attempts service.LoginAttemptsDTO, now time.Time) service.LoginAttemptsDTO {
+	if attempts.Failures > 0 {
+		attempts.Failures--
+	}
+	if attempts.LockedUntil.Equal(now.Add(p.lockFor)) {
+		attempts.LockedUntil = time.Time{}
+	}
+	return attempts
+}
+
+// pruneInterval is how often stale login counters are deleted.
+const pruneInterval = time.Hour
+
+// LoginService checks passwords and second factors, and holds back repeated
+// failures per account and per client IP.
+type LoginService struct {
+	users       service.IUserRepository
+	credentials service.ICredentialRepository
+	twoFactor   service.ITwoFactorRepository
+	audit       service.IAccountAuditRepository
+	mailer      common.Mailer
+	now         func() time.Time
+
+	mu        sync.Mutex
+	nextPrune time.Time
+}
+
+// NewLoginService creates the login service. Password changes, unlocks and
+// two-factor changes are recorded through audit.
+func NewLoginService(users service.IUserRepository, credentials service.ICredentialRepository, twoFactor service.ITwoFactorRepository, audit service.IAccountAuditRepository, mailer common.Mailer) *LoginService {
+	return &LoginService{users: users, credentials: credentials, twoFactor: twoFactor, audit: audit, mailer: mailer, now: time.Now}
+}
+
+// Login returns the user whose email and password match. Wrong emails and
+// wrong passwords fail alike with common.ErrInvalidCredentials, and both
+// count toward throttling, which fails with a *common.LoginThrottledError.
+// Users with TwoFactorEnabled still have to pass VerifySecondFactor.
+func (s *LoginService) Login(ctx context.Context, email, password, clientIP string) (*service.UserDTO, error) {
+	return s.attempt(ctx, email, clientIP, func() (*service.UserDTO, error) {
+		return s.checkPassword(ctx, email, password)
+	})
+}
+
+// attempt runs check as one login attempt, throttled for the email and
+// the client. check returns common.ErrInvalidCredentials on failure, along
+// with the user when the account exists.
+func (s *LoginService) attempt(ctx context.Context, email, clientIP string, check func() (*service.UserDTO, error)) (*service.UserDTO, error) {
+	// Postgres keeps microseconds; forgive compares against stored times.
+	now := s.now().Truncate(time.Microsecond)
+	s.maybePrune(ctx, now)
+
+	account := accountKey(email)
+	client := "ip:" + clientIP
+	for _, key := range []struct {
+		name   string
+		policy throttlePolicy
+	}{{account, accountThrottle}, {client, clientThrottle}} {
+		attempts, err := s.credentials.GetLoginAttempts(ctx, key.name)
+		if err != nil {
+			return nil, err
+		}
+		if wait := key.policy.wait(attempts, now); wait > 0 {
+			securityMetrics.Add(metricLoginThrottled, 1)
+			return nil, &common.LoginThrottledError{RetryAfter: wait}
+		}
+	}
+
+	// The attempt counts as a failure before it is checked, so concurrent
+	// guesses cannot outrun the lockout; success takes it back.
+	var locked bool
+	err := s.credentials.UpdateLoginAttempts(ctx, account, func(attempts service.LoginAttemptsDTO) service.LoginAttemptsDTO {
+		attempts, locked = accountThrottle.fail(attempts, now)
+		return attempts
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = s.credentials.UpdateLoginAttempts(ctx, client, func(attempts service.LoginAttemptsDTO) service.LoginAttemptsDTO {
+		attempts, _ = clientThrottle.fail(attempts, now)
+		return attempts
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := check()
+	if err != nil && !errors.Is(err, common.ErrInvalidCredentials) {
+		return nil, err
+	}
+	if err == nil {
+		if err := s.credentials.DeleteLoginAttempts(ctx, account); err != nil {
+			return nil, err
+		}
+		err := s.credentials.UpdateLoginAttempts(ctx, client, func(attempts service.LoginAttemptsDTO) service.LoginAttemptsDTO {
+			return clientThrottle.forgive(attempts, now)
+		})
+		if err != nil {
+			return nil, err
+		}
+		securityMetrics.Add(metricLoginSucceeded, 1)
+		return user, nil
+	}
+
+	securityMetrics.Add(metricLoginFailed, 1)
+	if locked {
+		securityMetrics.Add(metricAccountLocked, 1)
+		log.Printf("login: locked %s after repeated failures until %s", account, now.Add(accountThrottle.lockFor).Format(time.RFC3339))
+		if user != nil {
+			s.notifyLocked(ctx, user, now.Add(accountThrottle.lockFor))
+		}
+	}
+	return nil, common.ErrInvalidCredentials
+}
+
+// dummyHash is compared against when there is no password to check, so
+// unknown accounts take as long to reject as wrong passwords.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// checkPassword returns the user and no error when the password matches.
+// On common.ErrInvalidCredentials the user is still returned when the
+// account exists.
+func (s *LoginService) checkPassword(ctx context.Context, email, password string) (*service.UserDTO, error) {
+	user, err := s.users.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil && !errors.Is(err, common.ErrUserNotFound) {
+		return nil, err
+	}
+
+	var hash string
+	if user != nil {
+		if hash, err = s.credentials.GetPasswordHash(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return user, common.ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return user, common.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func (s *LoginService) notifyLocked(ctx context.Context, user *service.UserDTO, until time.Time) {
+	body := fmt.Sprintf("Hello %s,\n\nAfter repeated failed login attempts, password login to your account is locked until %s.\n"+
+		"If this was not you, consider changing your password once the lock ends.\n",
+		user.Name, until.UTC().Format(time.RFC1123))
+	if err := s.mailer.Send(ctx, user.Email, "Your account was temporarily locked", body); err != nil {
+		log.Printf("login: notifying user %d of a lockout failed: %v", user.ID, err)
+	}
+}
+
+// ChangePassword sets a user's password. When the user already has one,
+// current must match it.
+func (s *LoginService) ChangePassword(ctx context.Context, userID uint, current, next string) error {
+	hash, err := s.credentials.GetPasswordHash(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(current)) != nil {
+		return common.ErrInvalidCredentials
+	}
+
+	newHash, err := hashPassword(next)
+	if err != nil {
+		return err
+	}
+	return s.audit.Audited(ctx, accountAudit(ctx, AuditUserPasswordChanged, userID), func(credentials service.ICredentialRepository, _ service.ITwoFactorRepository) error {
+		return credentials.SetPasswordHash(ctx, userID, newHash)
+	})
+}
+
+// Unlock clears the failed logins of a user's account, lifting a lockout.
+func (s *LoginService) Unlock(ctx context.Context, userID uint) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = s.audit.Audited(ctx, accountAudit(ctx, AuditUserUnlocked, userID), func(credentials service.ICredentialRepository, _ service.ITwoFactorRepository) error {
+		return credentials.DeleteLoginAttempts(ctx, accountKey(user.Email))
+	})
+	if err != nil {
+		return err
+	}
+	securityMetrics.Add(metricAccountUnlocked, 1)
+	return nil
+}
+
+// maybePrune deletes stale login counters in the background, at most once
+// per pruneInterval.
+func (s *LoginService) maybePrune(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	due := now.After(s.nextPrune)
+	if due {
+		s.nextPrune = now.Add(pruneInterval)
+	}
+	s.mu.Unlock()
+
+	if due {
+		// The longest window decides when a counter is stale.
+		before := now.Add(-max(accountThrottle.window, clientThrottle.window))
+		go func() {
+			if err := s.credentials.DeleteStaleLoginAttempts(context.WithoutCancel(ctx), before); err != nil {
+				log.Printf("login: pruning login attempts failed: %v", err)
+			}
+		}()
+	}
+}
+
+// accountKey names the throttling counters of an email address.
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
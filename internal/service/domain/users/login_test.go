@@ -0,0 +1,235 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryCredentials is an in-memory credential repository.
+type memoryCredentials struct {
+	hashes   map[uint]string
+	attempts map[string]service.LoginAttemptsDTO
+}
+
+func newMemoryCredentials() *memoryCredentials {
+	return &memoryCredentials{hashes: map[uint]string{}, attempts: map[string]service.LoginAttemptsDTO{}}
+}
+
+func (m *memoryCredentials) GetPasswordHash(ctx context.Context, userID uint) (string, error) {
+	return m.hashes[userID], nil
+}
+
+func (m *memoryCredentials) SetPasswordHash(ctx context.Context, userID uint, hash string) error {
+	m.hashes[userID] = hash
+	return nil
+}
+
+func (m *memoryCredentials) GetLoginAttempts(ctx context.Context, key string) (service.LoginAttemptsDTO, error) {
+	return m.attempts[key], nil
+}
+
+func (m *memoryCredentials) UpdateLoginAttempts(ctx context.Context, key string, fn func(attempts service.LoginAttemptsDTO) service.LoginAttemptsDTO) error {
+	m.attempts[key] = fn(m.attempts[key])
+	return nil
+}
+
+func (m *memoryCredentials) DeleteLoginAttempts(ctx context.Context, key string) error {
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *memoryCredentials) DeleteStaleLoginAttempts(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+// memoryAudit runs audited changes on the test repositories and keeps the
+// entries of the ones that succeeded.
+type memoryAudit struct {
+	credentials service.ICredentialRepository
+	twoFactor   service.ITwoFactorRepository
+	entries     []service.AuditEntryDTO
+}
+
+func (m *memoryAudit) Audited(ctx context.Context, entry service.AuditEntryDTO, fn func(credentials service.ICredentialRepository, twoFactor service.ITwoFactorRepository) error) error {
+	if err := fn(m.credentials, m.twoFactor); err != nil {
+		return err
+	}
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+type recordingMailer struct {
+	to     []string
+	bodies []string
+}
+
+func (m *recordingMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.to = append(m.to, to)
+	m.bodies = append(m.bodies, body)
+	return nil
+}
+
+func newTestLogin(t *testing.T) (*LoginService, *memoryCredentials, *recordingMailer, *time.Time) {
+	t.Helper()
+	users := &mockRepo{listResp: []service.UserDTO{{ID: 1, Name: "Alice", Email: "alice@acme.com"}}}
+	credentials := newMemoryCredentials()
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials.hashes[1] = string(hash)
+
+	mailer := &recordingMailer{}
+	twoFactor := newMemoryTwoFactor()
+	audit := &memoryAudit{credentials: credentials, twoFactor: twoFactor}
+	login := NewLoginService(users, credentials, twoFactor, audit, mailer)
+	now := time.Unix(1_700_000_000, 0)
+	login.now = func() time.Time { return now }
+	// Keep the background pruning out of the test.
+	login.nextPrune = now.Add(time.Hour)
+	return login, credentials, mailer, &now
+}
+
+func TestThrottlePolicy(t *testing.T) {
+	policy := throttlePolicy{free: 2, baseDelay: time.Second, maxDelay: 4 * time.Second, lockAfter: 6, lockFor: time.Hour, window: 2 * time.Hour}
+	now := time.Unix(1000, 0)
+
+	var attempts service.LoginAttemptsDTO
+	wantWaits := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second}
+	for i, want := range wantWaits {
+		attempts, _ = policy.fail(attempts, now)
+		if got := policy.wait(attempts, now); got != want {
+			t.Fatalf("after %d failures wait = %v, want %v", i+1, got, want)
+		}
+	}
+
+	attempts, locked := policy.fail(attempts, now)
+	if !locked || policy.wait(attempts, now) != time.Hour {
+		t.Fatalf("sixth failure: locked = %v, wait = %v", locked, policy.wait(attempts, now))
+	}
+
+	if got := policy.wait(attempts, now.Add(3*time.Hour)); got != 0 {
+		t.Fatalf("failures outside the window still wait %v", got)
+	}
+	if forgotten, _ := policy.fail(attempts, now.Add(3*time.Hour)); forgotten.Failures != 1 {
+		t.Fatalf("failures outside the window were kept: %+v", forgotten)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	login, credentials, _, _ := newTestLogin(t)
+
+	user, err := login.Login(context.Background(), " alice@acme.com ", "correct horse", "192.0.2.1")
+	if err != nil || user.ID != 1 {
+		t.Fatalf("got %+v, %v", user, err)
+	}
+	if _, ok := credentials.attempts["email:alice@acme.com"]; ok {
+		t.Fatalf("successful login left account failures: %+v", credentials.attempts)
+	}
+	if got := credentials.attempts["ip:192.0.2.1"].Failures; got != 0 {
+		t.Fatalf("successful login counted against the client: %d", got)
+	}
+}
+
+func TestLogin_UnknownAccountsLookLikeWrongPasswords(t *testing.T) {
+	login, credentials, _, _ := newTestLogin(t)
+
+	for _, email := range []string{"alice@acme.com", "nobody@acme.com"} {
+		for i := 0; i < accountThrottle.free; i++ {
+			if _, err := login.Login(context.Background(), email, "wrong password", "192.0.2.1"); !errors.Is(err, common.ErrInvalidCredentials) {
+				t.Fatalf("%s attempt %d: err = %v", email, i, err)
+			}
+		}
+		_, err := login.Login(context.Background(), email, "wrong password", "192.0.2.1")
+		if !errors.Is(err, common.ErrInvalidCredentials) {
+			t.Fatalf("%s: err = %v", email, err)
+		}
+		_, err = login.Login(context.Background(), email, "wrong password", "192.0.2.1")
+		var throttled *common.LoginThrottledError
+		if !errors.As(err, &throttled) || throttled.RetryAfter != accountThrottle.baseDelay {
+			t.Fatalf("%s: err = %v, want a %v delay", email, err, accountThrottle.baseDelay)
+		}
+	}
+
+	if credentials.attempts["email:alice@acme.com"] != credentials.attempts["email:nobody@acme.com"] {
+		t.Fatalf("counters differ: %+v", credentials.attempts)
+	}
+}
+
+func TestLogin_LockoutNotifiesAndUnlocks(t *testing.T) {
+	login, credentials, mailer, now := newTestLogin(t)
+	ctx := context.Background()
+
+	// Each failure comes after the previous delay, until the lockout.
+	for i := 0; i < accountThrottle.lockAfter; i++ {
+		if _, err := login.Login(ctx, "alice@acme.com", "wrong password", "192.0.2.1"); !errors.Is(err, common.ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v", i, err)
+		}
+		*now = now.Add(accountThrottle.maxDelay)
+	}
+	if len(mailer.to) != 1 || mailer.to[0] != "alice@acme.com" {
+		t.Fatalf("notifications sent to %v", mailer.to)
+	}
+
+	// The right password does not help while locked.
+	_, err := login.Login(ctx, "alice@acme.com", "correct horse", "192.0.2.1")
+	var throttled *common.LoginThrottledError
+	if !errors.As(err, &throttled) || throttled.RetryAfter != accountThrottle.lockFor-accountThrottle.maxDelay {
+		t.Fatalf("err = %v", err)
+	}
+
+	if err := login.Unlock(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := credentials.attempts["email:alice@acme.com"]; ok {
+		t.Fatal("unlock kept the account counters")
+	}
+	if _, err := login.Login(ctx, "alice@acme.com", "correct horse", "192.0.2.1"); err != nil {
+		t.Fatalf("login after unlock: %v", err)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	login, credentials, _, _ := newTestLogin(t)
+	ctx := context.Background()
+
+	if err := login.ChangePassword(ctx, 1, "wrong password", "a new password"); !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Fatalf("wrong current password: err = %v", err)
+	}
+	if err := login.ChangePassword(ctx, 1, "correct horse", "short"); !errors.Is(err, common.ErrInvalidInput) {
+		t.Fatalf("short password: err = %v", err)
+	}
+	if err := login.ChangePassword(ctx, 1, "correct horse", "a new password"); err != nil {
+		t.Fatal(err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(credentials.hashes[1]), []byte("a new password")) != nil {
+		t.Fatal("password was not changed")
+	}
+
+	// Users without a password set their first one without a current one.
+	if err := login.ChangePassword(ctx, 2, "", "a first password"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateUser_HashesPassword(t *testing.T) {
+	repo := &mockRepo{createID: 1}
+	svc := NewService(repo, nil)
+
+	if _, err := svc.CreateUser(context.Background(), "Alice", "alice@acme.com", "short"); !errors.Is(err, common.ErrInvalidInput) {
+		t.Fatalf("short password: err = %v", err)
+	}
+	if _, err := svc.CreateUser(context.Background(), "Alice", "alice@acme.com", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(repo.lastHash), []byte("correct horse")) != nil {
+		t.Fatalf("stored hash %q does not match the password", repo.lastHash)
+	}
+}
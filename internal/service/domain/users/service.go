@@ -4,18 +4,25 @@ import (
 	"context"
 	"errors"
 
+	"meu-treino-golang/users-crud/internal/common"
 	"meu-treino-golang/users-crud/internal/service"
 )
 
 type Service struct {
-	repo service.IUserRepository
+	repo      service.IUserRepository
+	audit     service.IAccountAuditRepository
+	listeners []service.UserCreatedListener
 }
 
-func NewService(repo service.IUserRepository) *Service {
-	return &Service{repo: repo}
+// NewService creates the user service. listeners are notified of every user
+// it creates.
+func NewService(repo service.IUserRepository, audit service.IAccountAuditRepository, listeners ...service.UserCreatedListener) *Service {
+	return &Service{repo: repo, audit: audit, listeners: listeners}
 }
 
-func (s *Service) CreateUser(ctx context.Context, name, email string) (uint, error) {
+// CreateUser creates a user. password is optional; without one the user
+// cannot log in with a password.
+func (s *Service) CreateUser(ctx context.Context, name, email, password string) (uint, error) {
 	if name == "" {
 		return 0, errors.New("name cannot be empty")
 	}
@@ -24,7 +31,25 @@ func (s *Service) CreateUser(ctx context.Context, name, email string) (uint, err
 		return 0, errors.New("email cannot be empty")
 	}
 
-	return s.repo.Create(ctx, name, email)
+	var passwordHash string
+	if password != "" {
+		hash, err := hashPassword(password)
+		if err != nil {
+			return 0, err
+		}
+		passwordHash = hash
+	}
+
+	id, err := s.repo.Create(ctx, name, email, passwordHash)
+	if err != nil {
+		return 0, err
+	}
+
+	user := service.UserDTO{ID: id, Name: name, Email: email}
+	for _, listener := range s.listeners {
+		listener.UserCreated(ctx, user)
+	}
+	return id, nil
 }
 
 func (s *Service) ListUsers(ctx context.Context) ([]service.UserDTO, error) {
@@ -34,3 +59,39 @@ func (s *Service) ListUsers(ctx context.Context) ([]service.UserDTO, error) {
 func (s *Service) GetUserByID(ctx context.Context, id uint) (*service.UserDTO, error) {
 	return s.repo.GetByID(ctx, id)
 }
+
+// ImpersonationTarget checks that actorID may impersonate subjectID and
+// returns the subject. Platform admins cannot be impersonated, so
+// impersonation never grants more than a regular user holds.
+func (s *Service) ImpersonationTarget(ctx context.Context, actorID, subjectID uint) (*service.UserDTO, error) {
+	if actorID == subjectID {
+		return nil, common.ErrImpersonateSelf
+	}
+
+	subject, err := s.repo.GetByID(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if subject.PlatformAdmin {
+		return nil, common.ErrImpersonateAdmin
+	}
+	return subject, nil
+}
+
+// Impersonate lets actorID act as subjectID. It checks the pair like
+// ImpersonationTarget, then calls issue for the subject in the transaction
+// that records the impersonation, so no token is handed out unaudited.
+func (s *Service) Impersonate(ctx context.Context, actorID, subjectID uint, issue func(subject *service.UserDTO) error) (*service.UserDTO, error) {
+	subject, err := s.ImpersonationTarget(ctx, actorID, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Audited(ctx, accountAudit(ctx, AuditUserImpersonated, subject.ID), func(service.ICredentialRepository, service.ITwoFactorRepository) error {
+		return issue(subject)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subject, nil
+}
@@ -2,9 +2,11 @@ package users
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 
+	"meu-treino-golang/users-crud/internal/common"
 	"meu-treino-golang/users-crud/internal/service"
 )
 
@@ -16,11 +18,13 @@ type mockRepo struct {
 
     lastName  string
     lastEmail string
+    lastHash  string
 }
 
-func (m *mockRepo) Create(ctx context.Context, name, email string) (uint, error) {
+func (m *mockRepo) Create(ctx context.Context, name, email, passwordHash string) (uint, error) {
     m.lastName = name
     m.lastEmail = email
+    m.lastHash = passwordHash
     return m.createID, m.createErr
 }
 
@@ -39,17 +43,27 @@ func (m *mockRepo) GetByID(ctx context.Context, id uint) (*service.UserDTO, erro
     return nil, nil
 }
 
+func (m *mockRepo) GetByEmail(ctx context.Context, email string) (*service.UserDTO, error) {
+    for _, u := range m.listResp {
+        if u.Email == email {
+            copy := u
+            return &copy, nil
+        }
+    }
+    return nil, nil
+}
+
 func TestCreateUser_EmptyName(t *testing.T) {
-    svc := NewService(&mockRepo{})
-    if _, err := svc.CreateUser(context.Background(), "", "a@b.com"); err == nil {
+    svc := NewService(&mockRepo{}, nil)
+    if _, err := svc.CreateUser(context.Background(), "", "a@b.com", ""); err == nil {
         t.Fatalf("expected error for empty name")
     }
 }
 
 func TestCreateUser_Success(t *testing.T) {
     mr := &mockRepo{createID: 123}
-    svc := NewService(mr)
-    id, err := svc.CreateUser(context.Background(), "Alice", "alice@example.com")
+    svc := NewService(mr, nil)
+    id, err := svc.CreateUser(context.Background(), "Alice", "alice@example.com", "")
     if err != nil {
         t.Fatalf("unexpected error: %v", err)
     }
@@ -64,7 +78,7 @@ func TestCreateUser_Success(t *testing.T) {
 func TestListUsers_DelegatesToRepo(t *testing.T) {
     resp := []service.UserDTO{{ID: 1, Name: "A", Email: "a@a.com"}}
     mr := &mockRepo{listResp: resp}
-    svc := NewService(mr)
+    svc := NewService(mr, nil)
     list, err := svc.ListUsers(context.Background())
     if err != nil {
         t.Fatalf("unexpected error: %v", err)
@@ -73,3 +87,54 @@ func TestListUsers_DelegatesToRepo(t *testing.T) {
         t.Fatalf("expected %+v got %+v", resp, list)
     }
 }
+
+type recordingListener struct {
+    users []service.UserDTO
+}
+
+func (l *recordingListener) UserCreated(ctx context.Context, user service.UserDTO) {
+    l.users = append(l.users, user)
+}
+
+func TestCreateUser_NotifiesListeners(t *testing.T) {
+    listener := &recordingListener{}
+    svc := NewService(&mockRepo{createID: 7}, nil, listener)
+    if _, err := svc.CreateUser(context.Background(), "Bob", "bob@acme.com", ""); err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    want := []service.UserDTO{{ID: 7, Name: "Bob", Email: "bob@acme.com"}}
+    if !reflect.DeepEqual(listener.users, want) {
+        t.Fatalf("listener got %+v, want %+v", listener.users, want)
+    }
+}
+
+func TestCreateUser_FailureSkipsListeners(t *testing.T) {
+    listener := &recordingListener{}
+    svc := NewService(&mockRepo{createErr: errors.New("boom")}, nil, listener)
+    if _, err := svc.CreateUser(context.Background(), "Bob", "bob@acme.com", ""); err == nil {
+        t.Fatalf("expected error")
+    }
+    if len(listener.users) != 0 {
+        t.Fatalf("listener must not be notified of failed creations")
+    }
+}
+
+func TestImpersonationTarget(t *testing.T) {
+    repo := &mockRepo{listResp: []service.UserDTO{
+        {ID: 1, Name: "Ops", PlatformAdmin: true},
+        {ID: 2, Name: "Customer"},
+        {ID: 3, Name: "Other Ops", PlatformAdmin: true},
+    }}
+    svc := NewService(repo, nil)
+
+    subject, err := svc.ImpersonationTarget(context.Background(), 1, 2)
+    if err != nil || subject.ID != 2 {
+        t.Fatalf("got %+v, %v", subject, err)
+    }
+    if _, err := svc.ImpersonationTarget(context.Background(), 1, 1); !errors.Is(err, common.ErrImpersonateSelf) {
+        t.Fatalf("self: err = %v", err)
+    }
+    if _, err := svc.ImpersonationTarget(context.Background(), 1, 3); !errors.Is(err, common.ErrImpersonateAdmin) {
+        t.Fatalf("admin: err = %v", err)
+    }
+}
@@ -0,0 +1,133 @@
+package users
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238). They are the defaults authenticator apps
+// assume, so the provisioning URI states them only for clarity.
+const (
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+	// totpSkew is how many steps a code may be off, for clock drift.
+	totpSkew = 1
+	// totpSecretBytes is the secret size RFC 4226 recommends for SHA-1.
+	totpSecretBytes = 20
+
+	totpIssuer = "Users CRUD"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func newTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(secret), nil
+}
+
+// totpURI is the otpauth:// URI authenticator apps enroll from, usually
+// shown as a QR code.
+func totpURI(account, secret string) string {
+	label := url.PathEscape(totpIssuer) + ":" + url.PathEscape(account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", totpIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// totpStep is the time step now falls in.
+func totpStep(now time.Time) int64 {
+	return now.Unix() / int64(totpPeriod.Seconds())
+}
+
+// hotp computes the RFC 4226 code of counter.
+func hotp(secret []byte, counter int64, digits int) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(counter))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+// matchTOTP returns the time step whose code is code, looking up to
+// totpSkew steps around now.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := totpStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step, totpDigits)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// newRecoveryCodes returns fresh recovery codes, formatted for the user,
+// and their hashes, which are what is stored.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		encoded := strings.ToLower(base32NoPadding.EncodeToString(raw))
+		code := encoded[0:4] + "-" + encoded[4:8] + "-" + encoded[8:12] + "-" + encoded[12:16]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code as typed, ignoring case, spaces
+// and dashes. The codes are random, so an unsalted hash is enough.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// isTOTPCode tells a TOTP code from a recovery code.
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package users
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+)
+
+// EnrollTwoFactor starts a TOTP enrollment and returns the secret and its
+// provisioning URI. Nothing changes at login until ConfirmTwoFactor; a new
+// enrollment replaces an unconfirmed one.
+func (s *LoginService) EnrollTwoFactor(ctx context.Context, userID uint) (secret, uri string, err error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err = newTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.twoFactor.SetPendingTOTPSecret(ctx, userID, secret); err != nil {
+		return "", "", err
+	}
+	return secret, totpURI(user.Email, secret), nil
+}
+
+// ConfirmTwoFactor turns on two-factor authentication once the user proves
+// their authenticator works. It returns the recovery codes, which are not
+// stored and cannot be shown again.
+func (s *LoginService) ConfirmTwoFactor(ctx context.Context, userID uint, code string) ([]string, error) {
+	state, err := s.twoFactor.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Enabled {
+		return nil, common.ErrTwoFactorEnabled
+	}
+	if state.Secret == "" {
+		return nil, common.ErrTwoFactorNotPending
+	}
+
+	step, ok := matchTOTP(state.Secret, code, s.now())
+	if !ok {
+		return nil, common.ErrInvalidTwoFactor
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Audited(ctx, accountAudit(ctx, AuditUserTwoFactorEnabled, userID), func(_ service.ICredentialRepository, twoFactor service.ITwoFactorRepository) error {
+		return twoFactor.EnableTwoFactor(ctx, userID, step, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("login: user %d enabled two-factor authentication", userID)
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off. It takes a current
+// TOTP code or an unused recovery code.
+func (s *LoginService) DisableTwoFactor(ctx context.Context, userID uint, code string) error {
+	state, err := s.twoFactor.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !state.Enabled {
+		return common.ErrTwoFactorNotEnabled
+	}
+
+	ok, err := s.useCode(ctx, userID, state.Secret, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return common.ErrInvalidTwoFactor
+	}
+	err = s.audit.Audited(ctx, accountAudit(ctx, AuditUserTwoFactorDisabled, userID), func(_ service.ICredentialRepository, twoFactor service.ITwoFactorRepository) error {
+		return twoFactor.DisableTwoFactor(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("login: user %d disabled two-factor authentication", userID)
+	return nil
+}
+
+// VerifySecondFactor completes the login of a user who passed the password
+// step. code is a TOTP code or an unused recovery code; wrong codes are
+// throttled like wrong passwords.
+func (s *LoginService) VerifySecondFactor(ctx context.Context, userID uint, code, clientIP string) (*service.UserDTO, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.attempt(ctx, user.Email, clientIP, func() (*service.UserDTO, error) {
+		state, err := s.twoFactor.GetTwoFactor(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !state.Enabled {
+			return user, common.ErrInvalidCredentials
+		}
+
+		ok, err := s.useCode(ctx, userID, state.Secret, code)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return user, common.ErrInvalidCredentials
+		}
+		if !isTOTPCode(strings.TrimSpace(code)) {
+			log.Printf("login: user %d used a recovery code, %d left", userID, state.RecoveryCodesLeft-1)
+		}
+		return user, nil
+	})
+}
+
+// useCode accepts a TOTP code not used before, or consumes a recovery code.
+func (s *LoginService) useCode(ctx context.Context, userID uint, secret, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		step, ok := matchTOTP(secret, code, s.now())
+		if !ok {
+			return false, nil
+		}
+		return s.twoFactor.UseTOTPStep(ctx, userID, step)
+	}
+	return s.twoFactor.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+}
@@ -0,0 +1,203 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+)
+
+// memoryTwoFactor is an in-memory two-factor repository.
+type memoryTwoFactor struct {
+	states    map[uint]service.TwoFactorDTO
+	lastSteps map[uint]int64
+	recovery  map[uint]map[string]bool
+}
+
+func newMemoryTwoFactor() *memoryTwoFactor {
+	return &memoryTwoFactor{
+		states:    map[uint]service.TwoFactorDTO{},
+		lastSteps: map[uint]int64{},
+		recovery:  map[uint]map[string]bool{},
+	}
+}
+
+func (m *memoryTwoFactor) GetTwoFactor(ctx context.Context, userID uint) (service.TwoFactorDTO, error) {
+	state := m.states[userID]
+	state.RecoveryCodesLeft = 0
+	for _, used := range m.recovery[userID] {
+		if !used {
+			state.RecoveryCodesLeft++
+		}
+	}
+	return state, nil
+}
+
+func (m *memoryTwoFactor) SetPendingTOTPSecret(ctx context.Context, userID uint, secret string) error {
+	if m.states[userID].Enabled {
+		return common.ErrTwoFactorEnabled
+	}
+	m.states[userID] = service.TwoFactorDTO{Secret: secret}
+	return nil
+}
+
+func (m *memoryTwoFactor) EnableTwoFactor(ctx context.Context, userID uint, step int64, recoveryCodeHashes []string) error {
+	m.states[userID] = service.TwoFactorDTO{Secret: m.states[userID].Secret, Enabled: true}
+	m.lastSteps[userID] = step
+	m.recovery[userID] = map[string]bool{}
+	for _, hash := range recoveryCodeHashes {
+		m.recovery[userID][hash] = false
+	}
+	return nil
+}
+
+func (m *memoryTwoFactor) DisableTwoFactor(ctx context.Context, userID uint) error {
+	delete(m.states, userID)
+	delete(m.lastSteps, userID)
+	delete(m.recovery, userID)
+	return nil
+}
+
+func (m *memoryTwoFactor) UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	if m.lastSteps[userID] >= step {
+		return false, nil
+	}
+	m.lastSteps[userID] = step
+	return true, nil
+}
+
+func (m *memoryTwoFactor) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	used, ok := m.recovery[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.recovery[userID][codeHash] = true
+	return true, nil
+}
+
+// TestHOTP checks the RFC 6238 appendix B vectors for SHA-1.
+func TestHOTP(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	for _, tc := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+	} {
+		if got := hotp(secret, totpStep(time.Unix(tc.unix, 0)), 8); got != tc.code {
+			t.Errorf("T=%d: got %s, want %s", tc.unix, got, tc.code)
+		}
+	}
+}
+
+func TestMatchTOTP_AllowsOneStepOfDrift(t *testing.T) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := base32NoPadding.DecodeString(secret)
+	now := time.Unix(1_700_000_000, 0)
+	step := totpStep(now)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		if got, ok := matchTOTP(secret, hotp(key, step+offset, totpDigits), now); !ok || got != step+offset {
+			t.Fatalf("offset %d: step = %d, ok = %v", offset, got, ok)
+		}
+	}
+	if _, ok := matchTOTP(secret, hotp(key, step+2, totpDigits), now); ok {
+		t.Fatal("code two steps ahead was accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(totpURI("alice@acme.com", "JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/"+totpIssuer+":alice@acme.com" {
+		t.Fatalf("unexpected URI %s", uri)
+	}
+	if uri.Query().Get("secret") != "JBSWY3DPEHPK3PXP" || uri.Query().Get("issuer") != totpIssuer {
+		t.Fatalf("unexpected query %v", uri.Query())
+	}
+}
+
+// currentCode returns the code an authenticator app would show.
+func currentCode(t *testing.T, secret string, now time.Time) string {
+	t.Helper()
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hotp(key, totpStep(now), totpDigits)
+}
+
+func TestTwoFactorEnrollmentAndLogin(t *testing.T) {
+	login, _, _, now := newTestLogin(t)
+	twoFactor := login.twoFactor.(*memoryTwoFactor)
+	ctx := context.Background()
+
+	secret, _, err := login.EnrollTwoFactor(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := "000000"
+	if currentCode(t, secret, *now) == wrong {
+		wrong = "111111"
+	}
+	if _, err := login.ConfirmTwoFactor(ctx, 1, wrong); !errors.Is(err, common.ErrInvalidTwoFactor) {
+		t.Fatalf("wrong confirmation code: err = %v", err)
+	}
+	codes, err := login.ConfirmTwoFactor(ctx, 1, currentCode(t, secret, *now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount || !twoFactor.states[1].Enabled {
+		t.Fatalf("codes = %v, state = %+v", codes, twoFactor.states[1])
+	}
+	for hash := range twoFactor.recovery[1] {
+		for _, code := range codes {
+			if hash == code {
+				t.Fatal("recovery codes are stored in clear")
+			}
+		}
+	}
+
+	// The confirmation code cannot be replayed at login.
+	if _, err := login.VerifySecondFactor(ctx, 1, currentCode(t, secret, *now), "192.0.2.1"); !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Fatalf("replayed code: err = %v", err)
+	}
+
+	*now = now.Add(totpPeriod)
+	if user, err := login.VerifySecondFactor(ctx, 1, currentCode(t, secret, *now), "192.0.2.1"); err != nil || user.ID != 1 {
+		t.Fatalf("got %+v, %v", user, err)
+	}
+
+	// Recovery codes work once, typed in any case.
+	recovery := codes[0]
+	*now = now.Add(accountThrottle.maxDelay)
+	if _, err := login.VerifySecondFactor(ctx, 1, " "+strings.ToUpper(recovery)+" ", "192.0.2.1"); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if _, err := login.VerifySecondFactor(ctx, 1, recovery, "192.0.2.1"); !errors.Is(err, common.ErrInvalidCredentials) {
+		t.Fatalf("reused recovery code: err = %v", err)
+	}
+
+	if _, _, err := login.EnrollTwoFactor(ctx, 1); !errors.Is(err, common.ErrTwoFactorEnabled) {
+		t.Fatalf("second enrollment: err = %v", err)
+	}
+	if err := login.DisableTwoFactor(ctx, 1, codes[1]); err != nil {
+		t.Fatal(err)
+	}
+	if twoFactor.states[1].Enabled {
+		t.Fatal("two-factor authentication still enabled")
+	}
+}
@@ -1,3 +1,44 @@
 package users
 
+import (
+	"fmt"
+
+	"meu-treino-golang/users-crud/internal/common"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
 // Validators can be extended here for additional domain logic
+
+const (
+	minPasswordLength = 8
+	// bcrypt ignores everything after the first 72 bytes.
+	maxPasswordBytes = 72
+)
+
+var (
+	errPasswordTooShort = fmt.Errorf("%w: password must be at least %d characters", common.ErrInvalidInput, minPasswordLength)
+	errPasswordTooLong  = fmt.Errorf("%w: password must be at most %d bytes", common.ErrInvalidInput, maxPasswordBytes)
+)
+
+func validatePassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return errPasswordTooShort
+	}
+	if len(password) > maxPasswordBytes {
+		return errPasswordTooLong
+	}
+	return nil
+}
+
+// hashPassword validates and hashes a password.
+func hashPassword(password string) (string, error) {
+	if err := validatePassword(password); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,107 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+)
+
+// emailVerificationTTL is how long a confirmation token stays valid.
+const emailVerificationTTL = 48 * time.Hour
+
+// EmailVerificationService mails confirmation tokens to new users and marks
+// their email verified when they send one back. Anyone may create a user
+// with any email, so whatever trusts the email, such as joining the
+// organization that owns its domain, listens for EmailVerified instead of
+// the user's creation.
+type EmailVerificationService struct {
+	users         service.IUserRepository
+	verifications service.IEmailVerificationRepository
+	mailer        common.Mailer
+	listeners     []service.EmailVerifiedListener
+	now           func() time.Time
+}
+
+// NewEmailVerificationService creates the email verification service.
+// listeners are notified of every email it verifies.
+func NewEmailVerificationService(users service.IUserRepository, verifications service.IEmailVerificationRepository, mailer common.Mailer, listeners ...service.EmailVerifiedListener) *EmailVerificationService {
+	return &EmailVerificationService{users: users, verifications: verifications, mailer: mailer, listeners: listeners, now: time.Now}
+}
+
+// UserCreated mails the first confirmation token to a new user. Failures
+// are logged; the user can ask for another token with SendVerification.
+func (s *EmailVerificationService) UserCreated(ctx context.Context, user service.UserDTO) {
+	if err := s.send(ctx, user); err != nil {
+		log.Printf("email verification for user %d failed: %v", user.ID, err)
+	}
+}
+
+// SendVerification mails a new confirmation token to the user, replacing
+// any earlier one.
+func (s *EmailVerificationService) SendVerification(ctx context.Context, userID uint) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return common.ErrEmailAlreadyVerified
+	}
+	return s.send(ctx, *user)
+}
+
+func (s *EmailVerificationService) send(ctx context.Context, user service.UserDTO) error {
+	token, err := newVerificationToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := s.now().Add(emailVerificationTTL)
+	if err := s.verifications.SetEmailVerification(ctx, user.ID, hashVerificationToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Confirm your email address with POST /api/users/verify-email using the token below. "+
+			"It expires at %s.\n\n%s\n",
+		expiresAt.Format(time.RFC3339), token,
+	)
+	return s.mailer.Send(ctx, user.Email, "Confirm your email address", body)
+}
+
+// VerifyEmail marks the email of the user holding token as verified and
+// notifies the listeners. Unknown, used and expired tokens fail alike with
+// common.ErrInvalidEmailVerification.
+func (s *EmailVerificationService) VerifyEmail(ctx context.Context, token string) (*service.UserDTO, error) {
+	if token == "" {
+		return nil, common.ErrInvalidEmailVerification
+	}
+
+	user, err := s.verifications.VerifyEmail(ctx, hashVerificationToken(token), s.now())
+	if err != nil {
+		return nil, err
+	}
+	for _, listener := range s.listeners {
+		listener.EmailVerified(ctx, *user)
+	}
+	return user, nil
+}
+
+func newVerificationToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+)
+
+// memoryVerifications is an in-memory email verification repository over
+// the users of a mockRepo.
+type memoryVerifications struct {
+	users     *mockRepo
+	hashes    map[uint]string
+	expiresAt map[uint]time.Time
+}
+
+func (m *memoryVerifications) SetEmailVerification(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error {
+	m.hashes[userID] = tokenHash
+	m.expiresAt[userID] = expiresAt
+	return nil
+}
+
+func (m *memoryVerifications) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (*service.UserDTO, error) {
+	for userID, hash := range m.hashes {
+		if hash != tokenHash || !m.expiresAt[userID].After(now) {
+			continue
+		}
+		delete(m.hashes, userID)
+		for i := range m.users.listResp {
+			if m.users.listResp[i].ID == userID {
+				m.users.listResp[i].EmailVerified = true
+				user := m.users.listResp[i]
+				return &user, nil
+			}
+		}
+	}
+	return nil, common.ErrInvalidEmailVerification
+}
+
+type verifiedListener struct {
+	users []service.UserDTO
+}
+
+func (l *verifiedListener) EmailVerified(ctx context.Context, user service.UserDTO) {
+	l.users = append(l.users, user)
+}
+
+func newTestVerification() (*EmailVerificationService, *recordingMailer, *verifiedListener, *time.Time) {
+	users := &mockRepo{listResp: []service.UserDTO{{ID: 1, Name: "Bob", Email: "bob@acme.com"}}}
+	verifications := &memoryVerifications{users: users, hashes: map[uint]string{}, expiresAt: map[uint]time.Time{}}
+	mailer := &recordingMailer{}
+	listener := &verifiedListener{}
+
+	svc := NewEmailVerificationService(users, verifications, mailer, listener)
+	now := time.Unix(1_700_000_000, 0)
+	svc.now = func() time.Time { return now }
+	return svc, mailer, listener, &now
+}
+
+// mailedToken returns the token in the last mail sent.
+func mailedToken(t *testing.T, mailer *recordingMailer) string {
+	t.Helper()
+	if len(mailer.bodies) == 0 {
+		t.Fatal("no mail sent")
+	}
+	lines := strings.Split(strings.TrimSpace(mailer.bodies[len(mailer.bodies)-1]), "\n")
+	return lines[len(lines)-1]
+}
+
+func TestCreateUser_DoesNotVerifyEmail(t *testing.T) {
+	svc, mailer, listener, _ := newTestVerification()
+	users := NewService(&mockRepo{createID: 1}, nil, svc)
+
+	if _, err := users.CreateUser(context.Background(), "Bob", "bob@acme.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.to) != 1 || mailer.to[0] != "bob@acme.com" {
+		t.Fatalf("mailed %v, want a confirmation to the new user", mailer.to)
+	}
+	if len(listener.users) != 0 {
+		t.Fatal("creating a user must not count as verifying their email")
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	svc, mailer, listener, _ := newTestVerification()
+
+	svc.UserCreated(ctx, service.UserDTO{ID: 1, Email: "bob@acme.com"})
+	token := mailedToken(t, mailer)
+
+	if _, err := svc.VerifyEmail(ctx, token+"x"); !errors.Is(err, common.ErrInvalidEmailVerification) {
+		t.Fatalf("wrong token: err = %v", err)
+	}
+	user, err := svc.VerifyEmail(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.EmailVerified || len(listener.users) != 1 || listener.users[0].ID != 1 {
+		t.Fatalf("user = %+v, listener got %+v", user, listener.users)
+	}
+
+	if _, err := svc.VerifyEmail(ctx, token); !errors.Is(err, common.ErrInvalidEmailVerification) {
+		t.Fatalf("reused token: err = %v", err)
+	}
+	if len(listener.users) != 1 {
+		t.Fatal("a reused token must not notify again")
+	}
+	if err := svc.SendVerification(ctx, 1); !errors.Is(err, common.ErrEmailAlreadyVerified) {
+		t.Fatalf("resend after verification: err = %v", err)
+	}
+}
+
+func TestVerifyEmail_Expired(t *testing.T) {
+	ctx := context.Background()
+	svc, mailer, listener, now := newTestVerification()
+
+	if err := svc.SendVerification(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(emailVerificationTTL)
+
+	if _, err := svc.VerifyEmail(ctx, mailedToken(t, mailer)); !errors.Is(err, common.ErrInvalidEmailVerification) {
+		t.Fatalf("expired token: err = %v", err)
+	}
+	if len(listener.users) != 0 {
+		t.Fatal("an expired token must not notify")
+	}
+}
+
+func TestSendVerification_ReplacesEarlierToken(t *testing.T) {
+	ctx := context.Background()
+	svc, mailer, _, _ := newTestVerification()
+
+	if err := svc.SendVerification(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	first := mailedToken(t, mailer)
+	if err := svc.SendVerification(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.VerifyEmail(ctx, first); !errors.Is(err, common.ErrInvalidEmailVerification) {
+		t.Fatalf("replaced token: err = %v", err)
+	}
+	if _, err := svc.VerifyEmail(ctx, mailedToken(t, mailer)); err != nil {
+		t.Fatalf("latest token: %v", err)
+	}
+}
@@ -1,9 +1,108 @@
 package service
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type IUserRepository interface {
-	Create(ctx context.Context, name, email string) (uint, error)
+	// Create stores a user. An empty passwordHash disables password login.
+	Create(ctx context.Context, name, email, passwordHash string) (uint, error)
 	List(ctx context.Context) ([]UserDTO, error)
 	GetByID(ctx context.Context, id uint) (*UserDTO, error)
+	GetByEmail(ctx context.Context, email string) (*UserDTO, error)
+}
+
+// UserCreatedListener is notified after a user has been created.
+type UserCreatedListener interface {
+	UserCreated(ctx context.Context, user UserDTO)
+}
+
+// EmailVerifiedListener is notified after a user has confirmed they own
+// their email address.
+type EmailVerifiedListener interface {
+	EmailVerified(ctx context.Context, user UserDTO)
+}
+
+// IEmailVerificationRepository stores the pending email confirmation of
+// each user as a hash of the token mailed to them.
+type IEmailVerificationRepository interface {
+	// SetEmailVerification replaces the pending confirmation of the user.
+	SetEmailVerification(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error
+	// VerifyEmail marks the email of the user whose pending confirmation
+	// has tokenHash and has not expired at now as verified, and returns the
+	// user. It fails with common.ErrInvalidEmailVerification otherwise.
+	VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (*UserDTO, error)
+}
+
+// ICredentialRepository stores password hashes and the failed login
+// counters that slow down password guessing.
+type ICredentialRepository interface {
+	// GetPasswordHash returns the user's password hash, empty when the user
+	// has no password.
+	GetPasswordHash(ctx context.Context, userID uint) (string, error)
+	SetPasswordHash(ctx context.Context, userID uint, hash string) error
+	// GetLoginAttempts returns the counters of key, zero when there are none.
+	GetLoginAttempts(ctx context.Context, key string) (LoginAttemptsDTO, error)
+	// UpdateLoginAttempts stores the counters fn derives from the current
+	// ones. Concurrent updates of the same key are serialized.
+	UpdateLoginAttempts(ctx context.Context, key string, fn func(attempts LoginAttemptsDTO) LoginAttemptsDTO) error
+	DeleteLoginAttempts(ctx context.Context, key string) error
+	// DeleteStaleLoginAttempts removes the counters whose last failure and
+	// lockout both ended before the given time.
+	DeleteStaleLoginAttempts(ctx context.Context, before time.Time) error
+}
+
+// LoginAttemptsDTO counts the failed logins of an account or a client.
+type LoginAttemptsDTO struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// ITwoFactorRepository stores TOTP secrets and hashed recovery codes.
+type ITwoFactorRepository interface {
+	GetTwoFactor(ctx context.Context, userID uint) (TwoFactorDTO, error)
+	// SetPendingTOTPSecret stores the secret of an enrollment waiting for
+	// confirmation. It fails when two-factor authentication is already on.
+	SetPendingTOTPSecret(ctx context.Context, userID uint, secret string) error
+	// EnableTwoFactor turns on the pending secret, records step as used and
+	// replaces the recovery codes.
+	EnableTwoFactor(ctx context.Context, userID uint, step int64, recoveryCodeHashes []string) error
+	// DisableTwoFactor removes the secret and the recovery codes.
+	DisableTwoFactor(ctx context.Context, userID uint) error
+	// UseTOTPStep records step as used. It reports false when a code of
+	// that step or a later one was already accepted.
+	UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+	// UseRecoveryCode marks an unused recovery code as used. It reports
+	// false when no unused code has that hash.
+	UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error)
+}
+
+// TwoFactorDTO is the two-factor state of a user.
+type TwoFactorDTO struct {
+	Secret            string
+	Enabled           bool
+	RecoveryCodesLeft int
+}
+
+// IAccountAuditRepository records changes to user accounts in the audit log.
+type IAccountAuditRepository interface {
+	// Audited runs fn with repositories bound to one transaction and
+	// appends entry in the same transaction: either both are stored or
+	// neither is.
+	Audited(ctx context.Context, entry AuditEntryDTO, fn func(credentials ICredentialRepository, twoFactor ITwoFactorRepository) error) error
+}
+
+// AuditEntryDTO is one audited change. ActorID is who made it;
+// OnBehalfOfID is set when a platform admin impersonated a user.
+type AuditEntryDTO struct {
+	ActorID      uint
+	OnBehalfOfID *uint
+	Action       string
+	TargetType   string
+	TargetID     uint
+	IP           string
+	UserAgent    string
+	RequestID    string
 }
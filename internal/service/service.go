@@ -3,13 +3,37 @@ package service
 import "context"
 
 type IUserService interface {
-	CreateUser(ctx context.Context, name, email string) (uint, error)
+	CreateUser(ctx context.Context, name, email, password string) (uint, error)
 	ListUsers(ctx context.Context) ([]UserDTO, error)
 	GetUserByID(ctx context.Context, id uint) (*UserDTO, error)
+	ImpersonationTarget(ctx context.Context, actorID, subjectID uint) (*UserDTO, error)
+	Impersonate(ctx context.Context, actorID, subjectID uint, issue func(subject *UserDTO) error) (*UserDTO, error)
+}
+
+type IEmailVerificationService interface {
+	SendVerification(ctx context.Context, userID uint) error
+	VerifyEmail(ctx context.Context, token string) (*UserDTO, error)
+}
+
+type ILoginService interface {
+	Login(ctx context.Context, email, password, clientIP string) (*UserDTO, error)
+	ChangePassword(ctx context.Context, userID uint, current, next string) error
+	Unlock(ctx context.Context, userID uint) error
+
+	EnrollTwoFactor(ctx context.Context, userID uint) (secret, uri string, err error)
+	ConfirmTwoFactor(ctx context.Context, userID uint, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID uint, code string) error
+	VerifySecondFactor(ctx context.Context, userID uint, code, clientIP string) (*UserDTO, error)
 }
 
 type UserDTO struct {
 	ID    uint
 	Name  string
 	Email string
+	// EmailVerified is set once the user confirmed they own Email.
+	EmailVerified bool
+	PlatformAdmin bool
+	// TwoFactorEnabled is set when logins need a TOTP code after the
+	// password.
+	TwoFactorEnabled bool
 }
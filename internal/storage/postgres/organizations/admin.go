@@ -0,0 +1,35 @@
+package organizations
+
+import "time"
+
+// OrgSummary is an organization with the number of its active members.
+type OrgSummary struct {
+	OrganizationModel
+	MemberCount int64
+}
+
+// ListOrgSummaries pages through every organization, archived ones
+// included, with member counts.
+func (r *Repository) ListOrgSummaries(offset, limit int) ([]OrgSummary, int64, error) {
+	var total int64
+	if err := r.db.Model(&OrganizationModel{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	members := r.db.Model(&OrgUserModel{}).
+		Select("COUNT(*)").
+		Where("org_user_models.org_id = organization_models.id").
+		Where(activeMembership, time.Now())
+
+	var summaries []OrgSummary
+	err := r.db.Model(&OrganizationModel{}).
+		Select("organization_models.*, (?) AS member_count", members).
+		Order("organization_models.id").
+		Offset(offset).
+		Limit(limit).
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return summaries, total, nil
+}
@@ -0,0 +1,197 @@
+package organizations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Deletion job statuses.
+const (
+	DeletionPending   = "PENDING"
+	DeletionRunning   = "RUNNING"
+	DeletionSucceeded = "SUCCEEDED"
+	DeletionFailed    = "FAILED"
+)
+
+// deletionBatchSize bounds the rows removed per statement, so deleting a
+// large organization never holds long locks.
+const deletionBatchSize = 1000
+
+// OrgDeletionJobModel tracks the asynchronous deletion of an organization.
+// It has no foreign key to the organization, which it outlives.
+type OrgDeletionJobModel struct {
+	ID          uint   `gorm:"primaryKey"`
+	OrgID       uint   `gorm:"not null;index"`
+	OrgName     string `gorm:"not null"`
+	Status      string `gorm:"not null;default:'PENDING';index"`
+	Error       string
+	RequestedBy uint
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+}
+
+// DeletionImpact counts the data attached to an organization.
+type DeletionImpact struct {
+	Members            int64
+	Teams              int64
+	TeamMembers        int64
+	Invitations        int64
+	PendingInvitations int64
+	CustomRoles        int64
+	JoinRequests       int64
+	Domains            int64
+	Settings           int64
+	SlugHistory        int64
+	ExpiryRecords      int64
+	SSOConfigs         int64
+	SSOIdentities      int64
+	Subsidiaries       int64
+}
+
+// GetDeletionImpact counts what deleting an organization would remove.
+// Subsidiaries are not deleted; they are detached from the organization.
+func (r *Repository) GetDeletionImpact(orgID uint) (DeletionImpact, error) {
+	var impact DeletionImpact
+	counts := []struct {
+		query *gorm.DB
+		dest  *int64
+	}{
+		{r.db.Model(&OrgUserModel{}).Where("org_id = ?", orgID), &impact.Members},
+		{r.db.Model(&TeamModel{}).Where("org_id = ?", orgID), &impact.Teams},
+		{r.db.Model(&TeamMemberModel{}).Where("team_id IN (?)", r.db.Model(&TeamModel{}).Select("id").Where("org_id = ?", orgID)), &impact.TeamMembers},
+		{r.db.Model(&InvitationModel{}).Where("org_id = ?", orgID), &impact.Invitations},
+		{r.db.Model(&InvitationModel{}).Where("org_id = ? AND status = ?", orgID, InvitationPending), &impact.PendingInvitations},
+		{r.db.Model(&RoleModel{}).Where("org_id = ?", orgID), &impact.CustomRoles},
+		{r.db.Model(&JoinRequestModel{}).Where("org_id = ?", orgID), &impact.JoinRequests},
+		{r.db.Model(&OrgDomainModel{}).Where("org_id = ?", orgID), &impact.Domains},
+		{r.db.Model(&OrgSettingsModel{}).Where("org_id = ?", orgID), &impact.Settings},
+		{r.db.Model(&OrgSlugHistoryModel{}).Where("org_id = ?", orgID), &impact.SlugHistory},
+		{r.db.Model(&MembershipExpiryModel{}).Where("org_id = ?", orgID), &impact.ExpiryRecords},
+		{r.db.Model(&OrgSSOConfigModel{}).Where("org_id = ?", orgID), &impact.SSOConfigs},
+		{r.db.Model(&OrgSSOIdentityModel{}).Where("org_id = ?", orgID), &impact.SSOIdentities},
+		{r.db.Model(&OrganizationModel{}).Where("parent_id = ?", orgID), &impact.Subsidiaries},
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.dest).Error; err != nil {
+			return impact, err
+		}
+	}
+	return impact, nil
+}
+
+func (r *Repository) CreateDeletionJob(job *OrgDeletionJobModel) error {
+	return r.db.Create(job).Error
+}
+
+func (r *Repository) GetDeletionJob(jobID uint) (*OrgDeletionJobModel, error) {
+	var job OrgDeletionJobModel
+	if err := r.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindActiveDeletionJob returns the pending or running deletion job of an
+// organization.
+func (r *Repository) FindActiveDeletionJob(orgID uint) (*OrgDeletionJobModel, error) {
+	var job OrgDeletionJobModel
+	err := r.db.Where("org_id = ? AND status IN ?", orgID, []string{DeletionPending, DeletionRunning}).
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimDeletionJob marks the oldest pending job running and returns it, or
+// gorm.ErrRecordNotFound when there is none. Concurrent workers never claim
+// the same job.
+func (r *Repository) ClaimDeletionJob(now time.Time) (*OrgDeletionJobModel, error) {
+	var job OrgDeletionJobModel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", DeletionPending).
+			Order("id").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		job.Status = DeletionRunning
+		job.StartedAt = &now
+		return tx.Model(&job).Updates(map[string]interface{}{"status": job.Status, "started_at": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RequeueRunningDeletionJobs puts jobs interrupted by a restart back in the
+// queue. Deleting an organization is idempotent, so they can run again.
+func (r *Repository) RequeueRunningDeletionJobs() error {
+	return r.db.Model(&OrgDeletionJobModel{}).
+		Where("status = ?", DeletionRunning).
+		Updates(map[string]interface{}{"status": DeletionPending, "started_at": nil}).
+		Error
+}
+
+func (r *Repository) FinishDeletionJob(jobID uint, status, message string, now time.Time) error {
+	return r.db.Model(&OrgDeletionJobModel{}).
+		Where("id = ?", jobID).
+		Updates(map[string]interface{}{"status": status, "error": message, "finished_at": now}).
+		Error
+}
+
+// DeleteOrgInBatches removes an organization and everything attached to it,
+// a batch of rows at a time, then the organization itself. Subsidiaries are
+// detached rather than deleted.
+func (r *Repository) DeleteOrgInBatches(orgID uint) error {
+	teams := r.db.Model(&TeamModel{}).Select("id").Where("org_id = ?", orgID)
+	batches := []struct {
+		model interface{}
+		where string
+		args  []interface{}
+	}{
+		{&TeamMemberModel{}, "team_id IN (?)", []interface{}{teams}},
+		{&TeamModel{}, "org_id = ?", []interface{}{orgID}},
+		{&OrgUserModel{}, "org_id = ?", []interface{}{orgID}},
+		{&InvitationModel{}, "org_id = ?", []interface{}{orgID}},
+		{&JoinRequestModel{}, "org_id = ?", []interface{}{orgID}},
+		{&MembershipExpiryModel{}, "org_id = ?", []interface{}{orgID}},
+		{&OrgSSOIdentityModel{}, "org_id = ?", []interface{}{orgID}},
+		{&RoleModel{}, "org_id = ?", []interface{}{orgID}},
+	}
+	for _, batch := range batches {
+		if err := r.deleteInBatches(batch.model, batch.where, batch.args...); err != nil {
+			return err
+		}
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&OrganizationModel{}).Where("parent_id = ?", orgID).Update("parent_id", nil).Error
+		if err != nil {
+			return err
+		}
+		// Settings, domains, slug history, the SSO configuration and pending
+		// SSO sign-ins are a handful of rows; the cascade removes them with
+		// the organization.
+		return tx.Delete(&OrganizationModel{}, orgID).Error
+	})
+}
+
+func (r *Repository) deleteInBatches(model interface{}, where string, args ...interface{}) error {
+	for {
+		ids := r.db.Model(model).Select("id").Where(where, args...).Limit(deletionBatchSize)
+		result := r.db.Where("id IN (?)", ids).Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < deletionBatchSize {
+			return nil
+		}
+	}
+}
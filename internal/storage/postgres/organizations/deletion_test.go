@@ -0,0 +1,174 @@
+package organizations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+
+	"gorm.io/gorm"
+)
+
+// seedOrgData attaches one row of every kind an organization owns, plus a
+// subsidiary, and returns the subsidiary.
+func seedOrgData(t *testing.T, repo *Repository, orgID uint) uint {
+	t.Helper()
+	now := time.Now()
+	if err := repo.AddUserToOrg(orgID, 2, dto.PermissionRead, nil, nil); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	mustCreateTeam(t, repo, orgID, "devs", string(dto.PermissionWrite), 1, 2)
+
+	subsidiaryID := mustCreateOrg(t, repo, "acme-br", 1)
+	if err := repo.db.Model(&OrganizationModel{}).Where("id = ?", subsidiaryID).Update("parent_id", orgID).Error; err != nil {
+		t.Fatalf("attach subsidiary: %v", err)
+	}
+
+	rows := []interface{}{
+		&InvitationModel{OrgID: orgID, Email: "pending@acme.test", Nonce: "a", InvitedBy: 1, ExpiresAt: now.Add(time.Hour), LastSentAt: now},
+		&InvitationModel{OrgID: orgID, Email: "accepted@acme.test", Status: InvitationAccepted, Nonce: "b", InvitedBy: 1, ExpiresAt: now.Add(time.Hour), LastSentAt: now},
+		&RoleModel{OrgID: &orgID, Name: "auditor"},
+		&JoinRequestModel{OrgID: orgID, UserID: 3},
+		&OrgDomainModel{OrgID: orgID, Domain: "acme.test", Token: "token"},
+		&OrgSettingsModel{OrgID: orgID, Overrides: SettingValues{}},
+		&OrgSlugHistoryModel{OrgID: orgID, Slug: "acme-old"},
+		&MembershipExpiryModel{OrgID: orgID, UserID: 4, Action: ExpiryActionRemove, PreviousPermission: string(dto.PermissionRead), ExpiredAt: now, ProcessedAt: now},
+		&OrgSSOConfigModel{OrgID: orgID, Issuer: "https://idp.acme.test", ClientID: "client", RedirectURL: "https://app.acme.test/callback"},
+		&OrgSSOIdentityModel{OrgID: orgID, Issuer: "https://idp.acme.test", Subject: "subject", UserID: 2},
+		&OrgSSOLoginModel{State: "state", OrgID: orgID, Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(time.Minute)},
+	}
+	for _, row := range rows {
+		if err := repo.db.Create(row).Error; err != nil {
+			t.Fatalf("seed %T: %v", row, err)
+		}
+	}
+	return subsidiaryID
+}
+
+func TestGetDeletionImpact(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+	seedOrgData(t, repo, orgID)
+
+	impact, err := repo.GetDeletionImpact(orgID)
+	if err != nil {
+		t.Fatalf("impact: %v", err)
+	}
+	want := DeletionImpact{
+		Members:            2,
+		Teams:              1,
+		TeamMembers:        2,
+		Invitations:        2,
+		PendingInvitations: 1,
+		CustomRoles:        1,
+		JoinRequests:       1,
+		Domains:            1,
+		Settings:           1,
+		SlugHistory:        1,
+		ExpiryRecords:      1,
+		SSOConfigs:         1,
+		SSOIdentities:      1,
+		Subsidiaries:       1,
+	}
+	if impact != want {
+		t.Fatalf("impact = %+v, want %+v", impact, want)
+	}
+}
+
+func TestClaimDeletionJob(t *testing.T) {
+	repo := openRepository(t)
+	first := OrgDeletionJobModel{OrgID: 1, OrgName: "acme", Status: DeletionPending}
+	second := OrgDeletionJobModel{OrgID: 2, OrgName: "globex", Status: DeletionPending}
+	for _, job := range []*OrgDeletionJobModel{&first, &second} {
+		if err := repo.CreateDeletionJob(job); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, want := range []uint{first.ID, second.ID} {
+		job, err := repo.ClaimDeletionJob(now)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+		if job.ID != want || job.Status != DeletionRunning || job.StartedAt == nil {
+			t.Fatalf("claimed %+v, want job %d running", job, want)
+		}
+	}
+
+	if _, err := repo.ClaimDeletionJob(now); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("claim with no pending job = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestRequeueRunningDeletionJobs(t *testing.T) {
+	repo := openRepository(t)
+	running := OrgDeletionJobModel{OrgID: 1, OrgName: "acme", Status: DeletionPending}
+	if err := repo.CreateDeletionJob(&running); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if _, err := repo.ClaimDeletionJob(time.Now().UTC()); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	finished := OrgDeletionJobModel{OrgID: 2, OrgName: "globex", Status: DeletionSucceeded}
+	if err := repo.CreateDeletionJob(&finished); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	if err := repo.RequeueRunningDeletionJobs(); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+
+	job, err := repo.GetDeletionJob(running.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if job.Status != DeletionPending || job.StartedAt != nil {
+		t.Fatalf("requeued job = %+v, want pending", job)
+	}
+	job, err = repo.GetDeletionJob(finished.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if job.Status != DeletionSucceeded {
+		t.Fatalf("finished job = %+v, want it left alone", job)
+	}
+}
+
+func TestDeleteOrgInBatches(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+	subsidiaryID := seedOrgData(t, repo, orgID)
+	otherID := mustCreateOrg(t, repo, "globex", 1)
+
+	if err := repo.DeleteOrgInBatches(orgID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if _, err := repo.GetOrg(orgID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("deleted org = %v, want ErrRecordNotFound", err)
+	}
+	impact, err := repo.GetDeletionImpact(orgID)
+	if err != nil {
+		t.Fatalf("impact: %v", err)
+	}
+	if impact != (DeletionImpact{}) {
+		t.Fatalf("left behind %+v", impact)
+	}
+	var logins int64
+	if err := repo.db.Model(&OrgSSOLoginModel{}).Where("org_id = ?", orgID).Count(&logins).Error; err != nil || logins != 0 {
+		t.Fatalf("pending SSO sign-ins = %d, %v", logins, err)
+	}
+
+	subsidiary, err := repo.GetOrg(subsidiaryID)
+	if err != nil {
+		t.Fatalf("subsidiary: %v", err)
+	}
+	if subsidiary.ParentID != nil {
+		t.Fatalf("subsidiary parent = %d, want detached", *subsidiary.ParentID)
+	}
+	if _, err := repo.GetMembership(otherID, 1); err != nil {
+		t.Fatalf("other org membership: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package organizations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrgDomainModel is an organization's claim on an email domain. Several
+// organizations may claim a domain, but only one claim per domain can be
+// verified.
+type OrgDomainModel struct {
+	ID         uint   `gorm:"primaryKey"`
+	OrgID      uint   `gorm:"not null;uniqueIndex:idx_org_domain"`
+	Domain     string `gorm:"not null;size:253;uniqueIndex:idx_org_domain;uniqueIndex:idx_verified_domain,where:verified_at IS NOT NULL"`
+	Token      string `gorm:"not null"`
+	VerifiedAt *time.Time
+	CreatedAt  time.Time
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+func (r *Repository) CreateDomainClaim(claim *OrgDomainModel) error {
+	return r.db.Create(claim).Error
+}
+
+func (r *Repository) GetDomainClaim(orgID, claimID uint) (*OrgDomainModel, error) {
+	var claim OrgDomainModel
+	if err := r.db.Where("org_id = ? AND id = ?", orgID, claimID).First(&claim).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *Repository) ListDomainClaims(orgID uint) ([]OrgDomainModel, error) {
+	var claims []OrgDomainModel
+	if err := r.db.Where("org_id = ?", orgID).Order("domain").Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// FindVerifiedDomain returns the verified claim on a domain, if any.
+func (r *Repository) FindVerifiedDomain(domain string) (*OrgDomainModel, error) {
+	var claim OrgDomainModel
+	err := r.db.Where("domain = ? AND verified_at IS NOT NULL", domain).First(&claim).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// MarkDomainVerified verifies a claim. The unique index on verified domains
+// makes it fail with gorm.ErrDuplicatedKey when another organization
+// verified the domain first.
+func (r *Repository) MarkDomainVerified(claimID uint, verifiedAt time.Time) error {
+	return r.db.Model(&OrgDomainModel{}).Where("id = ?", claimID).Update("verified_at", verifiedAt).Error
+}
+
+func (r *Repository) DeleteDomainClaim(orgID, claimID uint) error {
+	result := r.db.Delete(&OrgDomainModel{}, "org_id = ? AND id = ?", orgID, claimID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
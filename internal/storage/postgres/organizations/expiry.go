@@ -0,0 +1,116 @@
+package organizations
+
+import (
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+
+	"gorm.io/gorm"
+)
+
+// Actions taken on expired memberships.
+const (
+	ExpiryActionRemove    = "REMOVE"
+	ExpiryActionDowngrade = "DOWNGRADE"
+)
+
+// MembershipExpiryModel records what the expiry sweeper did to a membership.
+type MembershipExpiryModel struct {
+	ID                 uint   `gorm:"primaryKey"`
+	OrgID              uint   `gorm:"not null;index"`
+	UserID             uint   `gorm:"not null;index"`
+	Action             string `gorm:"not null"`
+	PreviousPermission string `gorm:"not null"`
+	PreviousRoleID     *uint
+	ExpiredAt          time.Time `gorm:"not null"`
+	ProcessedAt        time.Time `gorm:"not null"`
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+// SetMembershipExpiry changes or clears the expiry of a membership.
+func (r *Repository) SetMembershipExpiry(orgID, userID uint, expiresAt *time.Time) error {
+	result := r.db.Model(&OrgUserModel{}).
+		Where("org_id = ? AND user_id = ?", orgID, userID).
+		Update("expires_at", expiresAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListExpiringMemberships returns the active memberships of an organization
+// that expire before the given time, soonest first.
+func (r *Repository) ListExpiringMemberships(orgID uint, before time.Time) ([]OrgUserModel, error) {
+	var memberships []OrgUserModel
+	err := r.db.Preload("Role").
+		Where("org_id = ? AND expires_at > ? AND expires_at <= ?", orgID, time.Now(), before).
+		Order("expires_at").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// ListExpiredMemberships returns up to limit memberships that expired at or
+// before now, across all organizations.
+func (r *Repository) ListExpiredMemberships(now time.Time, limit int) ([]OrgUserModel, error) {
+	var memberships []OrgUserModel
+	err := r.db.Where("expires_at <= ?", now).
+		Order("expires_at, id").
+		Limit(limit).
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// ExpireMembership removes an expired membership, with the user's places in
+// the organization's teams, or downgrades it to a permanent READ membership,
+// and records the action. A membership whose
+// expiry was extended in the meantime is left alone and false is returned.
+func (r *Repository) ExpireMembership(membership OrgUserModel, action string, now time.Time) (bool, error) {
+	expired := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&OrgUserModel{}).Where("id = ? AND expires_at <= ?", membership.ID, now)
+
+		var result *gorm.DB
+		if action == ExpiryActionDowngrade {
+			result = query.Updates(map[string]interface{}{
+				"permission": string(dto.PermissionRead),
+				"role_id":    nil,
+				"expires_at": nil,
+			})
+		} else {
+			result = query.Delete(&OrgUserModel{})
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if action != ExpiryActionDowngrade {
+			if err := deleteOrgTeamMemberships(tx, membership.OrgID, membership.UserID); err != nil {
+				return err
+			}
+		}
+
+		expired = true
+		return tx.Create(&MembershipExpiryModel{
+			OrgID:              membership.OrgID,
+			UserID:             membership.UserID,
+			Action:             action,
+			PreviousPermission: membership.Permission,
+			PreviousRoleID:     membership.RoleID,
+			ExpiredAt:          *membership.ExpiresAt,
+			ProcessedAt:        now,
+		}).Error
+	})
+	return expired, err
+}
@@ -0,0 +1,101 @@
+package organizations
+
+import (
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func TestListExpiredMemberships(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+
+	now := time.Now()
+	expiredAt := now.Add(-time.Minute)
+	laterAt := now.Add(time.Hour)
+	if err := repo.AddUserToOrg(orgID, 2, dto.PermissionRead, nil, &expiredAt); err != nil {
+		t.Fatalf("add expired member: %v", err)
+	}
+	if err := repo.AddUserToOrg(orgID, 3, dto.PermissionRead, nil, &laterAt); err != nil {
+		t.Fatalf("add expiring member: %v", err)
+	}
+
+	expired, err := repo.ListExpiredMemberships(now, 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(expired) != 1 || expired[0].UserID != 2 {
+		t.Fatalf("expired = %+v, want only user 2", expired)
+	}
+}
+
+func TestExpireMembership_Downgrade(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+
+	now := time.Now()
+	expiredAt := now.Add(-time.Minute)
+	if err := repo.AddUserToOrg(orgID, 2, dto.PermissionWrite, nil, &expiredAt); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	mustCreateTeam(t, repo, orgID, "devs", string(dto.PermissionWrite), 2)
+
+	expired, err := repo.ListExpiredMemberships(now, 10)
+	if err != nil || len(expired) != 1 {
+		t.Fatalf("expired memberships = %v, %v", expired, err)
+	}
+	if ok, err := repo.ExpireMembership(expired[0], ExpiryActionDowngrade, now); err != nil || !ok {
+		t.Fatalf("expire = %v, %v", ok, err)
+	}
+
+	membership, err := repo.GetMembership(orgID, 2)
+	if err != nil {
+		t.Fatalf("downgraded membership: %v", err)
+	}
+	if membership.Permission != string(dto.PermissionRead) || membership.ExpiresAt != nil {
+		t.Fatalf("membership = %+v, want a permanent READ membership", membership)
+	}
+	if count := countTeamMemberships(t, repo, 2); count != 1 {
+		t.Fatalf("team memberships = %d, a downgrade keeps them", count)
+	}
+
+	var records []MembershipExpiryModel
+	if err := repo.db.Find(&records).Error; err != nil {
+		t.Fatalf("load expiry records: %v", err)
+	}
+	if len(records) != 1 || records[0].Action != ExpiryActionDowngrade || records[0].PreviousPermission != string(dto.PermissionWrite) {
+		t.Fatalf("expiry records = %+v", records)
+	}
+
+	// A second sweep over the same listing finds nothing left to do.
+	if ok, err := repo.ExpireMembership(expired[0], ExpiryActionDowngrade, now); err != nil || ok {
+		t.Fatalf("second expire = %v, %v, want false", ok, err)
+	}
+}
+
+func TestExpireMembership_SkipsExtended(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+
+	now := time.Now()
+	expiredAt := now.Add(-time.Minute)
+	if err := repo.AddUserToOrg(orgID, 2, dto.PermissionRead, nil, &expiredAt); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	expired, err := repo.ListExpiredMemberships(now, 10)
+	if err != nil || len(expired) != 1 {
+		t.Fatalf("expired memberships = %v, %v", expired, err)
+	}
+
+	laterAt := now.Add(time.Hour)
+	if err := repo.SetMembershipExpiry(orgID, 2, &laterAt); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if ok, err := repo.ExpireMembership(expired[0], ExpiryActionRemove, now); err != nil || ok {
+		t.Fatalf("expire = %v, %v, want false", ok, err)
+	}
+	if member, _ := repo.IsOrgMember(orgID, 2); !member {
+		t.Fatal("extended membership was removed")
+	}
+}
@@ -0,0 +1,157 @@
+package organizations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invitation statuses.
+const (
+	InvitationPending  = "PENDING"
+	InvitationAccepted = "ACCEPTED"
+	InvitationDeclined = "DECLINED"
+	InvitationRevoked  = "REVOKED"
+)
+
+type InvitationModel struct {
+	ID         uint      `gorm:"primaryKey"`
+	OrgID      uint      `gorm:"not null;index"`
+	Email      string    `gorm:"not null;index"`
+	Permission string    `gorm:"not null;default:'READ'"`
+	Status     string    `gorm:"not null;default:'PENDING'"`
+	Nonce      string    `gorm:"not null"`
+	InvitedBy  uint      `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	LastSentAt time.Time `gorm:"not null"`
+	SendCount  int       `gorm:"not null;default:1"`
+	CreatedAt  time.Time
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+// MigrateInvitations allows one pending invitation per organization and
+// email, compared case-insensitively. Older duplicates left by earlier
+// versions are revoked first, keeping the most recent one.
+func (r *Repository) MigrateInvitations() error {
+	statements := []string{
+		`UPDATE invitation_models SET status = 'REVOKED'
+WHERE status = 'PENDING' AND EXISTS (
+	SELECT 1 FROM invitation_models newer
+	WHERE newer.org_id = invitation_models.org_id
+	AND LOWER(newer.email) = LOWER(invitation_models.email)
+	AND newer.status = 'PENDING'
+	AND newer.id > invitation_models.id
+)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_invitation_pending_email
+ON invitation_models (org_id, LOWER(email)) WHERE status = 'PENDING'`,
+	}
+	for _, statement := range statements {
+		if err := r.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateInvitation stores a new invitation.
+func (r *Repository) CreateInvitation(invitation *InvitationModel) error {
+	return r.db.Create(invitation).Error
+}
+
+func (r *Repository) GetInvitation(invitationID uint) (*InvitationModel, error) {
+	var invitation InvitationModel
+	if err := r.db.First(&invitation, invitationID).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindPendingInvitation returns the pending invitation for an email in an
+// organization, matching the email case-insensitively.
+func (r *Repository) FindPendingInvitation(orgID uint, email string) (*InvitationModel, error) {
+	var invitation InvitationModel
+	err := r.db.
+		Where("org_id = ? AND LOWER(email) = LOWER(?) AND status = ?", orgID, email, InvitationPending).
+		First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *Repository) ListPendingInvitations(orgID uint) ([]InvitationModel, error) {
+	var invitations []InvitationModel
+	err := r.db.
+		Where("org_id = ? AND status = ?", orgID, InvitationPending).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// UpdateInvitationStatus moves a pending invitation to a final status.
+func (r *Repository) UpdateInvitationStatus(invitationID uint, status string) error {
+	result := r.db.Model(&InvitationModel{}).
+		Where("id = ? AND status = ?", invitationID, InvitationPending).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RecordInvitationResend rotates the invitation nonce and expiry, which
+// invalidates every token issued before.
+func (r *Repository) RecordInvitationResend(invitationID uint, nonce string, expiresAt, sentAt time.Time) error {
+	return r.db.Model(&InvitationModel{}).
+		Where("id = ?", invitationID).
+		Updates(map[string]interface{}{
+			"nonce":        nonce,
+			"expires_at":   expiresAt,
+			"last_sent_at": sentAt,
+			"send_count":   gorm.Expr("send_count + 1"),
+		}).Error
+}
+
+// AcceptInvitation marks the invitation accepted and creates the membership
+// in a single transaction. It fails with gorm.ErrDuplicatedKey when the user
+// is already a member.
+func (r *Repository) AcceptInvitation(invitationID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var invitation InvitationModel
+		if err := tx.First(&invitation, invitationID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&InvitationModel{}).
+			Where("id = ? AND status = ?", invitationID, InvitationPending).
+			Update("status", InvitationAccepted)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Create(&OrgUserModel{
+			OrgID:      invitation.OrgID,
+			UserID:     userID,
+			Permission: invitation.Permission,
+		}).Error
+	})
+}
+
+// IsOrgMember reports whether a user has a membership in the organization.
+func (r *Repository) IsOrgMember(orgID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&OrgUserModel{}).
+		Where("org_id = ? AND user_id = ?", orgID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,47 @@
+package organizations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestMigrateInvitations_OnePendingPerEmail(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+	otherID := mustCreateOrg(t, repo, "globex", 1)
+
+	now := time.Now()
+	invite := func(orgID uint, email, status string) *InvitationModel {
+		return &InvitationModel{OrgID: orgID, Email: email, Status: status, Nonce: "n", InvitedBy: 1, ExpiresAt: now.Add(time.Hour), LastSentAt: now}
+	}
+
+	// Duplicates stored before the index existed: only the newest stays
+	// pending.
+	older, newer := invite(orgID, "a@acme.test", InvitationPending), invite(orgID, "A@acme.test", InvitationPending)
+	for _, invitation := range []*InvitationModel{older, newer} {
+		if err := repo.CreateInvitation(invitation); err != nil {
+			t.Fatalf("create invitation: %v", err)
+		}
+	}
+	if err := repo.MigrateInvitations(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if pending, err := repo.FindPendingInvitation(orgID, "a@acme.test"); err != nil || pending.ID != newer.ID {
+		t.Fatalf("pending = %+v, %v, want the newest invitation", pending, err)
+	}
+
+	if err := repo.CreateInvitation(invite(orgID, "a@ACME.test", InvitationPending)); !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("duplicate pending invitation = %v, want ErrDuplicatedKey", err)
+	}
+	for _, allowed := range []*InvitationModel{
+		invite(orgID, "a@acme.test", InvitationRevoked),
+		invite(otherID, "a@acme.test", InvitationPending),
+	} {
+		if err := repo.CreateInvitation(allowed); err != nil {
+			t.Fatalf("create %+v: %v", allowed, err)
+		}
+	}
+}
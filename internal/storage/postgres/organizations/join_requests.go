@@ -0,0 +1,116 @@
+package organizations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Join request statuses.
+const (
+	JoinRequestPending  = "PENDING"
+	JoinRequestApproved = "APPROVED"
+	JoinRequestRejected = "REJECTED"
+)
+
+// JoinRequestModel is a user's request to become a member of an
+// organization. A user has at most one pending request per organization.
+type JoinRequestModel struct {
+	ID         uint   `gorm:"primaryKey"`
+	OrgID      uint   `gorm:"not null;index;uniqueIndex:idx_join_request_pending,where:status = 'PENDING'"`
+	UserID     uint   `gorm:"not null;index;uniqueIndex:idx_join_request_pending,where:status = 'PENDING'"`
+	Message    string `gorm:"size:500"`
+	Status     string `gorm:"not null;default:'PENDING'"`
+	Permission string
+	DecidedBy  *uint
+	DecidedAt  *time.Time
+	CreatedAt  time.Time
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+func (r *Repository) CreateJoinRequest(request *JoinRequestModel) error {
+	return r.db.Create(request).Error
+}
+
+// GetJoinRequest returns a join request of an organization.
+func (r *Repository) GetJoinRequest(orgID, requestID uint) (*JoinRequestModel, error) {
+	var request JoinRequestModel
+	if err := r.db.Where("org_id = ? AND id = ?", orgID, requestID).First(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *Repository) FindPendingJoinRequest(orgID, userID uint) (*JoinRequestModel, error) {
+	var request JoinRequestModel
+	err := r.db.
+		Where("org_id = ? AND user_id = ? AND status = ?", orgID, userID, JoinRequestPending).
+		First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ListJoinRequests returns the join requests of an organization, oldest
+// first, optionally restricted to one status.
+func (r *Repository) ListJoinRequests(orgID uint, status string) ([]JoinRequestModel, error) {
+	query := r.db.Where("org_id = ?", orgID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requests []JoinRequestModel
+	if err := query.Order("created_at, id").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// ApproveJoinRequest marks a pending request approved and creates the
+// membership in a single transaction. It returns gorm.ErrRecordNotFound when
+// the request was already decided and gorm.ErrDuplicatedKey when the
+// applicant is already a member.
+func (r *Repository) ApproveJoinRequest(requestID, deciderID uint, permission string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var request JoinRequestModel
+		if err := tx.First(&request, requestID).Error; err != nil {
+			return err
+		}
+
+		if err := decideJoinRequest(tx, requestID, JoinRequestApproved, deciderID, permission); err != nil {
+			return err
+		}
+
+		return tx.Create(&OrgUserModel{
+			OrgID:      request.OrgID,
+			UserID:     request.UserID,
+			Permission: permission,
+		}).Error
+	})
+}
+
+// RejectJoinRequest marks a pending request rejected. It returns
+// gorm.ErrRecordNotFound when the request was already decided.
+func (r *Repository) RejectJoinRequest(requestID, deciderID uint) error {
+	return decideJoinRequest(r.db, requestID, JoinRequestRejected, deciderID, "")
+}
+
+func decideJoinRequest(db *gorm.DB, requestID uint, status string, deciderID uint, permission string) error {
+	result := db.Model(&JoinRequestModel{}).
+		Where("id = ? AND status = ?", requestID, JoinRequestPending).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"permission": permission,
+			"decided_by": deciderID,
+			"decided_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
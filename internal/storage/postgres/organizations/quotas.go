@@ -0,0 +1,86 @@
+package organizations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LockOrg runs fn in a transaction that holds a row lock on the
+// organization. Operations that check a quota before writing run inside it,
+// so concurrent writers for the same organization are serialized and cannot
+// overshoot the quota. fn receives a repository bound to the transaction.
+func (r *Repository) LockOrg(orgID uint, fn func(tx *Repository, org *OrganizationModel) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var org OrganizationModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&org, orgID).Error; err != nil {
+			return err
+		}
+		return fn(&Repository{db: tx}, &org)
+	})
+}
+
+// ListActiveMembers returns the active memberships of an organization with
+// their roles.
+func (r *Repository) ListActiveMembers(orgID uint) ([]OrgUserModel, error) {
+	var memberships []OrgUserModel
+	err := r.db.Preload("Role").
+		Where("org_id = ?", orgID).
+		Where(activeMembership, time.Now()).
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// ListOrgTeamPermissions returns the permissions the organization's teams
+// grant, keyed by user. Only active members are included.
+func (r *Repository) ListOrgTeamPermissions(orgID uint) (map[uint][]string, error) {
+	var rows []struct {
+		UserID     uint
+		Permission string
+	}
+	err := r.db.Model(&TeamModel{}).
+		Select("DISTINCT team_member_models.user_id, team_models.permission").
+		Joins("JOIN team_member_models ON team_member_models.team_id = team_models.id").
+		Joins(activeTeamMembership, time.Now()).
+		Where("team_models.org_id = ?", orgID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[uint][]string)
+	for _, row := range rows {
+		permissions[row.UserID] = append(permissions[row.UserID], row.Permission)
+	}
+	return permissions, nil
+}
+
+// ListTeamMemberIDs returns every user in one of the organization's teams,
+// whether or not they still hold a membership.
+func (r *Repository) ListTeamMemberIDs(orgID uint) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.Model(&TeamMemberModel{}).
+		Joins("JOIN team_models ON team_models.id = team_member_models.team_id").
+		Where("team_models.org_id = ?", orgID).
+		Distinct().
+		Pluck("team_member_models.user_id", &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// CountTeams counts the teams of an organization.
+func (r *Repository) CountTeams(orgID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&TeamModel{}).Where("org_id = ?", orgID).Count(&count).Error
+	return count, err
+}
+
+func (r *Repository) SetOrgPlan(orgID uint, plan string) error {
+	return r.db.Model(&OrganizationModel{}).Where("id = ?", orgID).Update("plan", plan).Error
+}
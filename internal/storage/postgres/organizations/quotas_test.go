@@ -0,0 +1,53 @@
+package organizations
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func TestUsageQueries_SkipExpiredMembers(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	if err := repo.AddUserToOrg(orgID, 2, dto.PermissionRead, nil, &expiredAt); err != nil {
+		t.Fatalf("add expired member: %v", err)
+	}
+	if err := repo.AddUserToOrg(orgID, 3, dto.PermissionRead, nil, nil); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	mustCreateTeam(t, repo, orgID, "admins", string(dto.PermissionRoot), 2, 3)
+
+	members, err := repo.ListActiveMembers(orgID)
+	if err != nil {
+		t.Fatalf("list members: %v", err)
+	}
+	var memberIDs []uint
+	for _, membership := range members {
+		memberIDs = append(memberIDs, membership.UserID)
+	}
+	slices.Sort(memberIDs)
+	if !slices.Equal(memberIDs, []uint{1, 3}) {
+		t.Fatalf("active members = %v, want [1 3]", memberIDs)
+	}
+
+	permissions, err := repo.ListOrgTeamPermissions(orgID)
+	if err != nil {
+		t.Fatalf("team permissions: %v", err)
+	}
+	if _, ok := permissions[2]; ok || len(permissions[3]) != 1 {
+		t.Fatalf("team permissions = %v, want only user 3", permissions)
+	}
+
+	teamMemberIDs, err := repo.ListTeamMemberIDs(orgID)
+	if err != nil {
+		t.Fatalf("team members: %v", err)
+	}
+	slices.Sort(teamMemberIDs)
+	if !slices.Equal(teamMemberIDs, []uint{2, 3}) {
+		t.Fatalf("team members = %v, want [2 3]", teamMemberIDs)
+	}
+}
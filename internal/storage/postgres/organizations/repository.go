@@ -2,6 +2,8 @@
 package organizations
 
 import (
+	"time"
+
 	"meu-treino-golang/users-crud/dto"
 
 	"gorm.io/gorm"
@@ -10,16 +12,61 @@ import (
 type OrganizationModel struct {
 	ID    uint   `gorm:"primaryKey"`
 	Name  string `gorm:"not null"`
+	Slug  string `gorm:"uniqueIndex;size:64"`
 	Users []OrgUserModel
+
+	// ParentID links a subsidiary to its parent organization. When
+	// InheritParentAccess is set, ROOT members of the parent are ROOT here too.
+	ParentID            *uint              `gorm:"index"`
+	InheritParentAccess bool               `gorm:"not null;default:true"`
+	Parent              *OrganizationModel `gorm:"foreignKey:ParentID;constraint:OnDelete:SET NULL"`
+
+	// ArchivedAt marks a read-only organization.
+	ArchivedAt *time.Time `gorm:"index"`
+
+	// Plan names the plan whose quotas apply to the organization.
+	Plan string `gorm:"not null;size:32;default:'free'"`
 }
 
 type OrgUserModel struct {
 	ID         uint   `gorm:"primaryKey"`
-	OrgID      uint   `gorm:"not null"`
-	UserID     uint   `gorm:"not null"`
+	OrgID      uint   `gorm:"not null;uniqueIndex:idx_org_user"`
+	UserID     uint   `gorm:"not null;index;uniqueIndex:idx_org_user"`
 	Permission string `gorm:"not null;default:'READ'"`
+	RoleID     *uint  `gorm:"index"`
+
+	// ExpiresAt ends a time-bound membership. Expired memberships grant
+	// nothing and are cleaned up by the expiry sweeper.
+	ExpiresAt *time.Time `gorm:"index"`
 
 	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+	Role         *RoleModel        `gorm:"foreignKey:RoleID;constraint:OnDelete:SET NULL"`
+}
+
+// activeMembership restricts a membership query to memberships that have not
+// expired.
+const activeMembership = "(expires_at IS NULL OR expires_at > ?)"
+
+// Models lists the tables of the organization storage, in migration order.
+func Models() []interface{} {
+	return []interface{}{
+		&OrganizationModel{},
+		&RoleModel{},
+		&OrgUserModel{},
+		&InvitationModel{},
+		&OrgSlugHistoryModel{},
+		&TeamModel{},
+		&TeamMemberModel{},
+		&OrgSettingsModel{},
+		&JoinRequestModel{},
+		&MembershipExpiryModel{},
+		&OrgDomainModel{},
+		&OrgSSOConfigModel{},
+		&OrgSSOLoginModel{},
+		&OrgSSOIdentityModel{},
+		&OrgDeletionJobModel{},
+		&AuditLogModel{},
+	}
 }
 
 type Repository struct {
@@ -30,10 +77,21 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// CreateOrg creates a new organization.
-func (r *Repository) CreateOrg(orgName string) (uint, error) {
-	org := OrganizationModel{Name: orgName}
-	if err := r.db.Create(&org).Error; err != nil {
+// CreateOrg creates a new organization and grants ROOT to its creator in a
+// single transaction, so an organization never exists without a manager.
+func (r *Repository) CreateOrg(orgName, slug string, creatorID uint) (uint, error) {
+	org := OrganizationModel{Name: orgName, Slug: slug}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&OrgUserModel{
+			OrgID:      org.ID,
+			UserID:     creatorID,
+			Permission: string(dto.PermissionRoot),
+		}).Error
+	})
+	if err != nil {
 		return 0, err
 	}
 	return org.ID, nil
@@ -47,54 +105,141 @@ func (r *Repository) GetOrg(orgID uint) (*OrganizationModel, error) {
 	return &org, nil
 }
 
-func (r *Repository) ListOrgs() ([]OrganizationModel, error) {
+// ListOrgs returns organizations, leaving archived ones out unless asked.
+func (r *Repository) ListOrgs(includeArchived bool) ([]OrganizationModel, error) {
+	query := r.db
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+
 	var orgs []OrganizationModel
-	if err := r.db.Find(&orgs).Error; err != nil {
+	if err := query.Find(&orgs).Error; err != nil {
 		return nil, err
 	}
 	return orgs, nil
 }
 
-func (r *Repository) UpdateOrg(orgID uint, name string) error {
-	return r.db.Model(&OrganizationModel{}).Where("id = ?", orgID).Update("name", name).Error
+// SetArchivedAt archives an organization at the given time, or unarchives it
+// when archivedAt is nil.
+func (r *Repository) SetArchivedAt(orgID uint, archivedAt *time.Time) error {
+	return r.db.Model(&OrganizationModel{}).Where("id = ?", orgID).Update("archived_at", archivedAt).Error
 }
 
-func (r *Repository) DeleteOrg(orgID uint) error {
-	return r.db.Delete(&OrganizationModel{}, orgID).Error
+// UpdateOrg renames an organization. When the slug changes, the previous
+// slug is kept in the history so it still resolves to the organization.
+func (r *Repository) UpdateOrg(orgID uint, name, slug string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var org OrganizationModel
+		if err := tx.First(&org, orgID).Error; err != nil {
+			return err
+		}
+
+		if org.Slug != "" && org.Slug != slug {
+			if err := tx.Create(&OrgSlugHistoryModel{OrgID: orgID, Slug: org.Slug}).Error; err != nil {
+				return err
+			}
+			// Renaming back to a previous name reclaims its slug.
+			if err := tx.Delete(&OrgSlugHistoryModel{}, "org_id = ? AND slug = ?", orgID, slug).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&OrganizationModel{}).
+			Where("id = ?", orgID).
+			Updates(map[string]interface{}{"name": name, "slug": slug}).
+			Error
+	})
 }
 
-// AddUserToOrg adds a user to an organization.
-func (r *Repository) AddUserToOrg(orgID, userID uint, permission dto.PermissionType) error {
+// AddUserToOrg adds a user to an organization, optionally with a custom role
+// and an expiry. It fails with gorm.ErrDuplicatedKey when the user already
+// has a membership, even an expired one the sweeper has not removed yet.
+func (r *Repository) AddUserToOrg(orgID, userID uint, permission dto.PermissionType, roleID *uint, expiresAt *time.Time) error {
 	orgUser := OrgUserModel{
 		OrgID:      orgID,
 		UserID:     userID,
 		Permission: string(permission),
+		RoleID:     roleID,
+		ExpiresAt:  expiresAt,
 	}
 	return r.db.Create(&orgUser).Error
 }
 
 func (r *Repository) GetOrgUsers(orgID uint) ([]OrgUserModel, error) {
 	var users []OrgUserModel
-	if err := r.db.Where("org_id = ?", orgID).Find(&users).Error; err != nil {
+	if err := r.db.Preload("Role").Where("org_id = ?", orgID).Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (r *Repository) UpdateUserPermission(orgID, userID uint, permission dto.PermissionType) error {
+// ListUserOrgs returns a page of a user's active memberships with their
+// organizations.
+func (r *Repository) ListUserOrgs(userID uint, offset, limit int) ([]OrgUserModel, int64, error) {
+	query := r.db.Model(&OrgUserModel{}).Where("user_id = ?", userID).Where(activeMembership, time.Now())
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var memberships []OrgUserModel
+	err := query.
+		Preload("Organization").
+		Preload("Role").
+		Order("org_id").
+		Offset(offset).
+		Limit(limit).
+		Find(&memberships).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return memberships, total, nil
+}
+
+// ListUserMemberships returns every active membership of a user with its
+// organization and role.
+func (r *Repository) ListUserMemberships(userID uint) ([]OrgUserModel, error) {
+	var memberships []OrgUserModel
+	err := r.db.Where("user_id = ?", userID).
+		Where(activeMembership, time.Now()).
+		Preload("Organization").
+		Preload("Role").
+		Order("org_id").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (r *Repository) UpdateUserPermission(orgID, userID uint, permission dto.PermissionType, roleID *uint) error {
 	return r.db.Model(&OrgUserModel{}).
 		Where("org_id = ? AND user_id = ?", orgID, userID).
-		Update("permission", string(permission)).
+		Updates(map[string]interface{}{
+			"permission": string(permission),
+			"role_id":    roleID,
+		}).
 		Error
 }
 
+// RemoveUserFromOrg ends a membership and takes the user out of the
+// organization's teams.
 func (r *Repository) RemoveUserFromOrg(orgID, userID uint) error {
-	return r.db.Delete(&OrgUserModel{}, "org_id = ? AND user_id = ?", orgID, userID).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteOrgTeamMemberships(tx, orgID, userID); err != nil {
+			return err
+		}
+		return tx.Delete(&OrgUserModel{}, "org_id = ? AND user_id = ?", orgID, userID).Error
+	})
 }
 
 func (r *Repository) GetUserPermissionInOrg(orgID, userID uint) (dto.PermissionType, error) {
 	var user OrgUserModel
-	if err := r.db.Where("org_id = ? AND user_id = ?", orgID, userID).First(&user).Error; err != nil {
+	err := r.db.Where("org_id = ? AND user_id = ?", orgID, userID).
+		Where(activeMembership, time.Now()).
+		First(&user).Error
+	if err != nil {
 		return "", err
 	}
 	return dto.PermissionType(user.Permission), nil
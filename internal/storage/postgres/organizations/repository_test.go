@@ -0,0 +1,70 @@
+package organizations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+
+	"meu-treino-golang/users-crud/internal/storage/postgres/pgtest"
+
+	"gorm.io/gorm"
+)
+
+// openRepository returns a repository over a fresh schema. The test is
+// skipped when TEST_DATABASE_URL is not set.
+func openRepository(t *testing.T) *Repository {
+	t.Helper()
+	return NewRepository(pgtest.Open(t, Models()...))
+}
+
+// mustCreateOrg creates an organization with creatorID as ROOT.
+func mustCreateOrg(t *testing.T, repo *Repository, name string, creatorID uint) uint {
+	t.Helper()
+	orgID, err := repo.CreateOrg(name, name, creatorID)
+	if err != nil {
+		t.Fatalf("create org %s: %v", name, err)
+	}
+	return orgID
+}
+
+// mustCreateTeam creates a team holding permission with the given members.
+func mustCreateTeam(t *testing.T, repo *Repository, orgID uint, name, permission string, members ...uint) uint {
+	t.Helper()
+	team, err := repo.CreateTeam(orgID, name, permission)
+	if err != nil {
+		t.Fatalf("create team %s: %v", name, err)
+	}
+	for _, userID := range members {
+		if err := repo.AddTeamMember(team.ID, userID); err != nil {
+			t.Fatalf("add %d to team %s: %v", userID, name, err)
+		}
+	}
+	return team.ID
+}
+
+func countTeamMemberships(t *testing.T, repo *Repository, userID uint) int64 {
+	t.Helper()
+	var count int64
+	if err := repo.db.Model(&TeamMemberModel{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		t.Fatalf("count team memberships: %v", err)
+	}
+	return count
+}
+
+func TestAddUserToOrg_RejectsDuplicates(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	if err := repo.AddUserToOrg(orgID, 2, dto.PermissionRead, nil, &expiredAt); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if err := repo.AddUserToOrg(orgID, 2, dto.PermissionWrite, nil, nil); !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("duplicate add = %v, want gorm.ErrDuplicatedKey", err)
+	}
+	if err := repo.AddUserToOrg(orgID, 1, dto.PermissionRead, nil, nil); !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("adding the creator = %v, want gorm.ErrDuplicatedKey", err)
+	}
+}
@@ -0,0 +1,117 @@
+package organizations
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RoleModel is a named set of capabilities. System roles have no OrgID and
+// are shared by every organization.
+type RoleModel struct {
+	ID           uint   `gorm:"primaryKey"`
+	OrgID        *uint  `gorm:"uniqueIndex:idx_role_org_name"`
+	Name         string `gorm:"not null;uniqueIndex:idx_role_org_name"`
+	Capabilities string `gorm:"not null;default:''"`
+	System       bool   `gorm:"not null;default:false"`
+
+	Organization *OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+// CapabilityList splits the stored capabilities.
+func (r RoleModel) CapabilityList() []string {
+	if r.Capabilities == "" {
+		return nil
+	}
+	return strings.Split(r.Capabilities, ",")
+}
+
+// SeedSystemRoles creates or refreshes the built-in roles.
+func (r *Repository) SeedSystemRoles(roles map[string][]string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for name, capabilities := range roles {
+			var role RoleModel
+			err := tx.Where("org_id IS NULL AND name = ?", name).First(&role).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+
+			role.Name = name
+			role.System = true
+			role.Capabilities = strings.Join(capabilities, ",")
+			if err := tx.Save(&role).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateRole stores a custom role for an organization.
+func (r *Repository) CreateRole(orgID uint, name string, capabilities []string) (*RoleModel, error) {
+	role := RoleModel{
+		OrgID:        &orgID,
+		Name:         name,
+		Capabilities: strings.Join(capabilities, ","),
+	}
+	if err := r.db.Create(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *Repository) GetRole(roleID uint) (*RoleModel, error) {
+	var role RoleModel
+	if err := r.db.First(&role, roleID).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListRoles returns the system roles followed by the organization's own roles.
+func (r *Repository) ListRoles(orgID uint) ([]RoleModel, error) {
+	var roles []RoleModel
+	err := r.db.
+		Where("org_id IS NULL OR org_id = ?", orgID).
+		Order("system DESC, id").
+		Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *Repository) UpdateRole(roleID uint, name string, capabilities []string) error {
+	return r.db.Model(&RoleModel{}).
+		Where("id = ? AND system = ?", roleID, false).
+		Updates(map[string]interface{}{
+			"name":         name,
+			"capabilities": strings.Join(capabilities, ","),
+		}).Error
+}
+
+func (r *Repository) DeleteRole(roleID uint) error {
+	return r.db.Delete(&RoleModel{}, "id = ? AND system = ?", roleID, false).Error
+}
+
+// CountRoleMembers counts memberships assigned to a role.
+func (r *Repository) CountRoleMembers(roleID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&OrgUserModel{}).Where("role_id = ?", roleID).Count(&count).Error
+	return count, err
+}
+
+// GetMembership returns a user's active membership in an organization with its
+// custom role, if any.
+func (r *Repository) GetMembership(orgID, userID uint) (*OrgUserModel, error) {
+	var membership OrgUserModel
+	err := r.db.Preload("Role").
+		Where("org_id = ? AND user_id = ?", orgID, userID).
+		Where(activeMembership, time.Now()).
+		First(&membership).Error
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
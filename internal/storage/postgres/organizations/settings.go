@@ -0,0 +1,112 @@
+package organizations
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SettingValues holds the settings an organization has overridden, keyed by
+// setting name. It is stored as a JSONB object.
+type SettingValues map[string]json.RawMessage
+
+func (v SettingValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]json.RawMessage(v))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (v *SettingValues) Scan(src interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		*v = SettingValues{}
+		return nil
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return fmt.Errorf("cannot scan %T into SettingValues", src)
+	}
+	values := SettingValues{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*v = values
+	return nil
+}
+
+// OrgSettingsModel stores the overridden settings of one organization.
+// Settings that are not present fall back to their defaults.
+type OrgSettingsModel struct {
+	OrgID     uint          `gorm:"primaryKey;autoIncrement:false"`
+	Overrides SettingValues `gorm:"type:jsonb;not null;default:'{}'"`
+	UpdatedAt time.Time
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+// GetOrgSettings returns the settings an organization has overridden, or an
+// empty set when it never changed any.
+func (r *Repository) GetOrgSettings(orgID uint) (SettingValues, error) {
+	var settings OrgSettingsModel
+	err := r.db.Where("org_id = ?", orgID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return SettingValues{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings.Overrides, nil
+}
+
+// PatchOrgSettings stores the values in set and drops the keys in unset,
+// leaving every other setting untouched. The row is locked for the duration
+// of the change so concurrent patches do not overwrite each other.
+func (r *Repository) PatchOrgSettings(orgID uint, set SettingValues, unset []string) (SettingValues, error) {
+	var settings OrgSettingsModel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&OrgSettingsModel{OrgID: orgID, Overrides: SettingValues{}}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("org_id = ?", orgID).
+			First(&settings).Error
+		if err != nil {
+			return err
+		}
+
+		if settings.Overrides == nil {
+			settings.Overrides = SettingValues{}
+		}
+		for key, value := range set {
+			settings.Overrides[key] = value
+		}
+		for _, key := range unset {
+			delete(settings.Overrides, key)
+		}
+
+		return tx.Model(&OrgSettingsModel{}).
+			Where("org_id = ?", orgID).
+			Updates(map[string]interface{}{"overrides": settings.Overrides, "updated_at": time.Now()}).
+			Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return settings.Overrides, nil
+}
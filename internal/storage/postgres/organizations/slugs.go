@@ -0,0 +1,68 @@
+package organizations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrgSlugHistoryModel keeps slugs an organization used before a rename.
+type OrgSlugHistoryModel struct {
+	ID        uint   `gorm:"primaryKey"`
+	OrgID     uint   `gorm:"not null;index"`
+	Slug      string `gorm:"not null;uniqueIndex;size:64"`
+	CreatedAt time.Time
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+// GetOrgBySlug finds an organization by its current slug.
+func (r *Repository) GetOrgBySlug(slug string) (*OrganizationModel, error) {
+	var org OrganizationModel
+	if err := r.db.Where("slug = ?", slug).First(&org).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetOrgBySlugHistory finds the organization that previously used a slug.
+func (r *Repository) GetOrgBySlugHistory(slug string) (*OrganizationModel, error) {
+	var history OrgSlugHistoryModel
+	if err := r.db.Preload("Organization").Where("slug = ?", slug).First(&history).Error; err != nil {
+		return nil, err
+	}
+	return &history.Organization, nil
+}
+
+// SlugOwner returns the ID of the organization that currently uses or
+// previously used a slug, or zero when the slug is free.
+func (r *Repository) SlugOwner(slug string) (uint, error) {
+	if org, err := r.GetOrgBySlug(slug); err == nil {
+		return org.ID, nil
+	} else if err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	var history OrgSlugHistoryModel
+	err := r.db.Where("slug = ?", slug).First(&history).Error
+	if err == gorm.ErrRecordNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return history.OrgID, nil
+}
+
+// ListOrgsWithoutSlug returns organizations created before slugs existed.
+func (r *Repository) ListOrgsWithoutSlug() ([]OrganizationModel, error) {
+	var orgs []OrganizationModel
+	if err := r.db.Where("slug IS NULL OR slug = ''").Order("id").Find(&orgs).Error; err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+func (r *Repository) SetOrgSlug(orgID uint, slug string) error {
+	return r.db.Model(&OrganizationModel{}).Where("id = ?", orgID).Update("slug", slug).Error
+}
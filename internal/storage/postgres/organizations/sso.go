@@ -0,0 +1,160 @@
+package organizations
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GroupPermissions maps identity provider groups to the permission their
+// members get. It is stored as a JSONB object.
+type GroupPermissions map[string]string
+
+func (g GroupPermissions) Value() (driver.Value, error) {
+	if g == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]string(g))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (g *GroupPermissions) Scan(src interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		*g = GroupPermissions{}
+		return nil
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return fmt.Errorf("cannot scan %T into GroupPermissions", src)
+	}
+	permissions := GroupPermissions{}
+	if err := json.Unmarshal(data, &permissions); err != nil {
+		return err
+	}
+	*g = permissions
+	return nil
+}
+
+// OrgSSOConfigModel is an organization's OpenID Connect identity provider.
+type OrgSSOConfigModel struct {
+	OrgID        uint   `gorm:"primaryKey;autoIncrement:false"`
+	Issuer       string `gorm:"not null;size:255"`
+	ClientID     string `gorm:"not null;size:255"`
+	ClientSecret string `gorm:"not null;default:''"`
+	RedirectURL  string `gorm:"not null;size:2048"`
+	// GroupsClaim names the ID token claim that lists the user's groups.
+	GroupsClaim      string           `gorm:"not null;size:64;default:'groups'"`
+	GroupPermissions GroupPermissions `gorm:"type:jsonb;not null;default:'{}'"`
+	Enabled          bool             `gorm:"not null;default:true"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+// OrgSSOLoginModel is a sign-in waiting for the identity provider to
+// redirect back. It is deleted when the redirect arrives, so each state is
+// used at most once.
+type OrgSSOLoginModel struct {
+	State        string    `gorm:"primaryKey;size:64"`
+	OrgID        uint      `gorm:"not null"`
+	Nonce        string    `gorm:"not null;size:64"`
+	CodeVerifier string    `gorm:"not null;size:128"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+// OrgSSOIdentityModel links an identity provider account to a user.
+type OrgSSOIdentityModel struct {
+	ID        uint   `gorm:"primaryKey"`
+	OrgID     uint   `gorm:"not null;uniqueIndex:idx_sso_identity"`
+	Issuer    string `gorm:"not null;size:255;uniqueIndex:idx_sso_identity"`
+	Subject   string `gorm:"not null;size:255;uniqueIndex:idx_sso_identity"`
+	UserID    uint   `gorm:"not null;index"`
+	CreatedAt time.Time
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+func (r *Repository) GetSSOConfig(orgID uint) (*OrgSSOConfigModel, error) {
+	var config OrgSSOConfigModel
+	if err := r.db.Where("org_id = ?", orgID).First(&config).Error; err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// SaveSSOConfig creates or replaces an organization's SSO configuration.
+func (r *Repository) SaveSSOConfig(config *OrgSSOConfigModel) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"issuer", "client_id", "client_secret", "redirect_url", "groups_claim", "group_permissions", "enabled", "updated_at"}),
+	}).Create(config).Error
+}
+
+// DeleteSSOConfig removes an organization's SSO configuration along with
+// its pending sign-ins. Linked identities are kept, so users get the same
+// accounts back if SSO is configured again.
+func (r *Repository) DeleteSSOConfig(orgID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&OrgSSOConfigModel{}, "org_id = ?", orgID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Delete(&OrgSSOLoginModel{}, "org_id = ?", orgID).Error
+	})
+}
+
+// CreateSSOLogin stores a pending sign-in and drops the expired ones.
+func (r *Repository) CreateSSOLogin(login *OrgSSOLoginModel) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&OrgSSOLoginModel{}, "expires_at < ?", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(login).Error
+	})
+}
+
+// ConsumeSSOLogin deletes and returns the pending sign-in of state. Of two
+// concurrent calls only one gets it.
+func (r *Repository) ConsumeSSOLogin(state string) (*OrgSSOLoginModel, error) {
+	var logins []OrgSSOLoginModel
+	err := r.db.Clauses(clause.Returning{}).Where("state = ?", state).Delete(&logins).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(logins) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &logins[0], nil
+}
+
+func (r *Repository) FindSSOIdentity(orgID uint, issuer, subject string) (*OrgSSOIdentityModel, error) {
+	var identity OrgSSOIdentityModel
+	err := r.db.Where("org_id = ? AND issuer = ? AND subject = ?", orgID, issuer, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// CreateSSOIdentity links an identity. It fails with gorm.ErrDuplicatedKey
+// when the identity is already linked.
+func (r *Repository) CreateSSOIdentity(identity *OrgSSOIdentityModel) error {
+	return r.db.Create(identity).Error
+}
@@ -0,0 +1,131 @@
+package organizations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TeamModel groups users inside an organization. Every member of a team
+// receives the team's permission in the organization.
+type TeamModel struct {
+	ID         uint   `gorm:"primaryKey"`
+	OrgID      uint   `gorm:"not null;uniqueIndex:idx_team_org_name"`
+	Name       string `gorm:"not null;uniqueIndex:idx_team_org_name"`
+	Permission string `gorm:"not null;default:'READ'"`
+	CreatedAt  time.Time
+	Members    []TeamMemberModel `gorm:"foreignKey:TeamID"`
+
+	Organization OrganizationModel `gorm:"foreignKey:OrgID;constraint:OnDelete:CASCADE"`
+}
+
+type TeamMemberModel struct {
+	ID     uint `gorm:"primaryKey"`
+	TeamID uint `gorm:"not null;uniqueIndex:idx_team_member"`
+	UserID uint `gorm:"not null;uniqueIndex:idx_team_member;index"`
+
+	Team TeamModel `gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE"`
+}
+
+// activeTeamMembership joins the active membership of a team member in the
+// team's organization, dropping members who have none.
+const activeTeamMembership = "JOIN org_user_models ON org_user_models.org_id = team_models.org_id" +
+	" AND org_user_models.user_id = team_member_models.user_id" +
+	" AND (org_user_models.expires_at IS NULL OR org_user_models.expires_at > ?)"
+
+// deleteOrgTeamMemberships removes a user from every team of an
+// organization.
+func deleteOrgTeamMemberships(tx *gorm.DB, orgID, userID uint) error {
+	return tx.Where("user_id = ? AND team_id IN (?)", userID,
+		tx.Model(&TeamModel{}).Select("id").Where("org_id = ?", orgID),
+	).Delete(&TeamMemberModel{}).Error
+}
+
+// CreateTeam creates a team in an organization.
+func (r *Repository) CreateTeam(orgID uint, name, permission string) (*TeamModel, error) {
+	team := TeamModel{OrgID: orgID, Name: name, Permission: permission}
+	if err := r.db.Create(&team).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// GetTeam returns a team of an organization with its members.
+func (r *Repository) GetTeam(orgID, teamID uint) (*TeamModel, error) {
+	var team TeamModel
+	err := r.db.Preload("Members").
+		Where("org_id = ? AND id = ?", orgID, teamID).
+		First(&team).Error
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (r *Repository) ListTeams(orgID uint) ([]TeamModel, error) {
+	var teams []TeamModel
+	if err := r.db.Preload("Members").Where("org_id = ?", orgID).Order("name").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+func (r *Repository) UpdateTeam(orgID, teamID uint, name, permission string) error {
+	return r.db.Model(&TeamModel{}).
+		Where("org_id = ? AND id = ?", orgID, teamID).
+		Updates(map[string]interface{}{"name": name, "permission": permission}).
+		Error
+}
+
+func (r *Repository) DeleteTeam(orgID, teamID uint) error {
+	return r.db.Delete(&TeamModel{}, "org_id = ? AND id = ?", orgID, teamID).Error
+}
+
+func (r *Repository) AddTeamMember(teamID, userID uint) error {
+	return r.db.Create(&TeamMemberModel{TeamID: teamID, UserID: userID}).Error
+}
+
+func (r *Repository) RemoveTeamMember(teamID, userID uint) error {
+	return r.db.Delete(&TeamMemberModel{}, "team_id = ? AND user_id = ?", teamID, userID).Error
+}
+
+// ListUserTeamPermissions returns the permissions granted to a user through
+// teams, keyed by organization.
+func (r *Repository) ListUserTeamPermissions(userID uint) (map[uint][]string, error) {
+	var rows []struct {
+		OrgID      uint
+		Permission string
+	}
+	err := r.db.Model(&TeamModel{}).
+		Select("DISTINCT team_models.org_id, team_models.permission").
+		Joins("JOIN team_member_models ON team_member_models.team_id = team_models.id").
+		Joins(activeTeamMembership, time.Now()).
+		Where("team_member_models.user_id = ?", userID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[uint][]string)
+	for _, row := range rows {
+		permissions[row.OrgID] = append(permissions[row.OrgID], row.Permission)
+	}
+	return permissions, nil
+}
+
+// GetUserTeamPermissions returns the permissions granted to a user through
+// the teams they belong to in an organization. Teams grant nothing to users
+// without an active membership.
+func (r *Repository) GetUserTeamPermissions(orgID, userID uint) ([]string, error) {
+	var permissions []string
+	err := r.db.Model(&TeamModel{}).
+		Joins("JOIN team_member_models ON team_member_models.team_id = team_models.id").
+		Joins(activeTeamMembership, time.Now()).
+		Where("team_models.org_id = ? AND team_member_models.user_id = ?", orgID, userID).
+		Distinct().
+		Pluck("team_models.permission", &permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
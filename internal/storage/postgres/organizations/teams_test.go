@@ -0,0 +1,92 @@
+package organizations
+
+import (
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+)
+
+func TestRemoveUserFromOrg_LeavesOrgTeams(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+	otherID := mustCreateOrg(t, repo, "other", 1)
+
+	for _, id := range []uint{orgID, otherID} {
+		if err := repo.AddUserToOrg(id, 2, dto.PermissionRead, nil, nil); err != nil {
+			t.Fatalf("add member: %v", err)
+		}
+	}
+	mustCreateTeam(t, repo, orgID, "admins", string(dto.PermissionRoot), 2)
+	mustCreateTeam(t, repo, otherID, "admins", string(dto.PermissionRoot), 2)
+
+	if err := repo.RemoveUserFromOrg(orgID, 2); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	if count := countTeamMemberships(t, repo, 2); count != 1 {
+		t.Fatalf("team memberships = %d, want only the one in the other organization", count)
+	}
+	permissions, err := repo.GetUserTeamPermissions(otherID, 2)
+	if err != nil || len(permissions) != 1 {
+		t.Fatalf("other organization team permissions = %v, %v", permissions, err)
+	}
+}
+
+func TestExpireMembership_RemoveLeavesTeams(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+
+	now := time.Now()
+	expiresAt := now.Add(-time.Minute)
+	if err := repo.AddUserToOrg(orgID, 2, dto.PermissionRead, nil, &expiresAt); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	mustCreateTeam(t, repo, orgID, "admins", string(dto.PermissionRoot), 2)
+
+	expired, err := repo.ListExpiredMemberships(now, 10)
+	if err != nil || len(expired) != 1 {
+		t.Fatalf("expired memberships = %v, %v", expired, err)
+	}
+	if ok, err := repo.ExpireMembership(expired[0], ExpiryActionRemove, now); err != nil || !ok {
+		t.Fatalf("expire = %v, %v", ok, err)
+	}
+
+	if count := countTeamMemberships(t, repo, 2); count != 0 {
+		t.Fatalf("team memberships = %d, want 0", count)
+	}
+}
+
+func TestGetUserTeamPermissions_RequiresActiveMembership(t *testing.T) {
+	repo := openRepository(t)
+	orgID := mustCreateOrg(t, repo, "acme", 1)
+
+	expiresAt := time.Now().Add(-time.Minute)
+	if err := repo.AddUserToOrg(orgID, 2, dto.PermissionRead, nil, &expiresAt); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if err := repo.AddUserToOrg(orgID, 3, dto.PermissionRead, nil, nil); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	// User 4 is left in the team without any membership.
+	mustCreateTeam(t, repo, orgID, "admins", string(dto.PermissionRoot), 2, 3, 4)
+
+	cases := map[uint]int{2: 0, 3: 1, 4: 0}
+	for userID, want := range cases {
+		permissions, err := repo.GetUserTeamPermissions(orgID, userID)
+		if err != nil {
+			t.Fatalf("user %d: %v", userID, err)
+		}
+		if len(permissions) != want {
+			t.Errorf("user %d: team permissions = %v, want %d", userID, permissions, want)
+		}
+
+		byOrg, err := repo.ListUserTeamPermissions(userID)
+		if err != nil {
+			t.Fatalf("user %d: %v", userID, err)
+		}
+		if len(byOrg[orgID]) != want {
+			t.Errorf("user %d: listed team permissions = %v, want %d", userID, byOrg[orgID], want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package pgtest gives integration tests a private Postgres schema.
+//
+// Tests using it run only when TEST_DATABASE_URL points at a database the
+// tests may create schemas in; otherwise they are skipped.
+package pgtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open creates a fresh schema, migrates models into it and returns a
+// connection that uses it. The schema is dropped when the test ends.
+func Open(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	config := &gorm.Config{TranslateError: true, Logger: logger.Discard}
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	schema := "test_" + randomSuffix(t)
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(withSearchPath(dsn, schema)), config)
+	if err != nil {
+		t.Fatalf("connect to schema: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// withSearchPath makes every connection of dsn use schema, for both URL and
+// key=value connection strings.
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		parsed, err := url.Parse(dsn)
+		if err == nil {
+			query := parsed.Query()
+			query.Set("search_path", schema)
+			parsed.RawQuery = query.Encode()
+			return parsed.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+func randomSuffix(t *testing.T) string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatalf("random schema name: %v", err)
+	}
+	return hex.EncodeToString(buf)
+}
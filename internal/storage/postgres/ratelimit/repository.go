@@ -0,0 +1,75 @@
+// Package ratelimit provides database storage for rate limit buckets, so
+// that several instances share the same limits.
+package ratelimit
+
+import (
+	"errors"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/ratelimit"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BucketModel is the state of one token bucket. ExpiresAt is when the
+// bucket is full again; past it the row carries no information and can be
+// deleted.
+type BucketModel struct {
+	Key       string    `gorm:"primaryKey;size:255"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;autoUpdateTime:false"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// UpdateBucket locks the bucket named key and stores the state fn returns
+// for it. fn receives nil when the bucket does not exist yet; it may be
+// called twice when another request creates the bucket concurrently, and
+// only the state of the last call is stored. Concurrent updates of the same
+// bucket, from any instance, are serialized.
+func (r *Repository) UpdateBucket(key string, fn func(bucket *ratelimit.Bucket) (ratelimit.Bucket, time.Time)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		bucket, err := lockBucket(tx, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			state, expiresAt := fn(nil)
+			created := BucketModel{Key: key, Tokens: state.Tokens, UpdatedAt: state.UpdatedAt, ExpiresAt: expiresAt}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&created)
+			if result.Error != nil || result.RowsAffected == 1 {
+				return result.Error
+			}
+			// Another request created the bucket first; take from it instead.
+			bucket, err = lockBucket(tx, key)
+		}
+		if err != nil {
+			return err
+		}
+
+		updated, expiresAt := fn(&ratelimit.Bucket{Tokens: bucket.Tokens, UpdatedAt: bucket.UpdatedAt})
+		return tx.Model(&BucketModel{}).Where("key = ?", key).Updates(map[string]interface{}{
+			"tokens":     updated.Tokens,
+			"updated_at": updated.UpdatedAt,
+			"expires_at": expiresAt,
+		}).Error
+	})
+}
+
+func lockBucket(tx *gorm.DB, key string) (*BucketModel, error) {
+	var bucket BucketModel
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&bucket).Error; err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+// DeleteExpiredBuckets removes the buckets that are full again at now.
+func (r *Repository) DeleteExpiredBuckets(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&BucketModel{}).Error
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/ratelimit"
+	"meu-treino-golang/users-crud/internal/storage/postgres/pgtest"
+)
+
+var _ ratelimit.BucketRepository = (*Repository)(nil)
+
+func TestPostgresStore_ConcurrentTakes(t *testing.T) {
+	repo := NewRepository(pgtest.Open(t, &BucketModel{}))
+	store := ratelimit.NewPostgresStore(repo)
+	limit := ratelimit.Limit{Burst: 10, Period: time.Hour}
+	now := time.Now().UTC()
+
+	const takers = 25
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+		errs    []error
+	)
+	for range takers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := store.Take(context.Background(), "ip:192.0.2.1", limit, now)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			} else if result.Allowed {
+				allowed++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		t.Fatalf("take: %v", errs)
+	}
+	if allowed != limit.Burst {
+		t.Fatalf("allowed %d of %d takes, want exactly the burst of %d", allowed, takers, limit.Burst)
+	}
+
+	var bucket BucketModel
+	if err := repo.db.Where("key = ?", "ip:192.0.2.1").First(&bucket).Error; err != nil {
+		t.Fatalf("load bucket: %v", err)
+	}
+	if bucket.Tokens >= 1 {
+		t.Fatalf("bucket left with %.2f tokens, want it drained", bucket.Tokens)
+	}
+}
@@ -3,4 +3,8 @@ package users
 
 import "meu-treino-golang/users-crud/internal/service"
 
-var _ service.IUserRepository = (*Repository)(nil)
+var (
+	_ service.IUserRepository       = (*Repository)(nil)
+	_ service.ICredentialRepository = (*Repository)(nil)
+	_ service.ITwoFactorRepository  = (*Repository)(nil)
+)
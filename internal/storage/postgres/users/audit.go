@@ -0,0 +1,32 @@
+package users
+
+import (
+	"context"
+
+	"meu-treino-golang/users-crud/internal/service"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+
+	"gorm.io/gorm"
+)
+
+// Audited runs fn on a repository bound to a transaction and appends entry
+// to the audit log kept with the organization changes, in the same
+// transaction.
+func (r *Repository) Audited(ctx context.Context, entry service.AuditEntryDTO, fn func(credentials service.ICredentialRepository, twoFactor service.ITwoFactorRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := &Repository{db: tx}
+		if err := fn(repo, repo); err != nil {
+			return err
+		}
+		return tx.Create(&organizations.AuditLogModel{
+			ActorID:      entry.ActorID,
+			OnBehalfOfID: entry.OnBehalfOfID,
+			Action:       entry.Action,
+			TargetType:   entry.TargetType,
+			TargetID:     entry.TargetID,
+			IP:           entry.IP,
+			UserAgent:    entry.UserAgent,
+			RequestID:    entry.RequestID,
+		}).Error
+	})
+}
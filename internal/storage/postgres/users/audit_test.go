@@ -0,0 +1,70 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/service"
+	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+	"meu-treino-golang/users-crud/internal/storage/postgres/pgtest"
+)
+
+func TestAudited(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.Open(t, &UserModel{}, &LoginAttemptModel{}, &RecoveryCodeModel{}, &organizations.AuditLogModel{})
+	repo := NewRepository(db)
+	userID, err := repo.Create(ctx, "Alice", "alice@acme.test", "old-hash")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	entry := service.AuditEntryDTO{
+		ActorID:    userID,
+		Action:     "user.password_changed",
+		TargetType: "user",
+		TargetID:   userID,
+		IP:         "10.0.0.1",
+		UserAgent:  "curl",
+		RequestID:  "req-1",
+	}
+	setHash := func(hash string) func(service.ICredentialRepository, service.ITwoFactorRepository) error {
+		return func(credentials service.ICredentialRepository, _ service.ITwoFactorRepository) error {
+			return credentials.SetPasswordHash(ctx, userID, hash)
+		}
+	}
+
+	failed := errors.New("boom")
+	err = repo.Audited(ctx, entry, func(credentials service.ICredentialRepository, twoFactor service.ITwoFactorRepository) error {
+		if err := setHash("rolled-back")(credentials, twoFactor); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("failing change: err = %v", err)
+	}
+	if hash, _ := repo.GetPasswordHash(ctx, userID); hash != "old-hash" {
+		t.Fatalf("hash = %q, want the failed change rolled back", hash)
+	}
+
+	if err := repo.Audited(ctx, entry, setHash("new-hash")); err != nil {
+		t.Fatalf("audited change: %v", err)
+	}
+	if hash, _ := repo.GetPasswordHash(ctx, userID); hash != "new-hash" {
+		t.Fatalf("hash = %q, want new-hash", hash)
+	}
+
+	// The platform audit log lists every entry, account changes included.
+	entries, total, err := organizations.NewRepository(db).ListAuditLogs(organizations.AuditFilter{}, 0, 10)
+	if err != nil {
+		t.Fatalf("list audit log: %v", err)
+	}
+	if total != 1 {
+		t.Fatalf("total = %d, want only the committed change", total)
+	}
+	got := entries[0]
+	if got.Action != entry.Action || got.ActorID != userID || got.TargetType != "user" || got.TargetID != userID ||
+		got.OrgID != nil || got.IP != "10.0.0.1" || got.UserAgent != "curl" || got.RequestID != "req-1" {
+		t.Fatalf("entry = %+v", got)
+	}
+}
@@ -10,6 +10,10 @@ import (
 
 func TestRepositoryImplementsPort(t *testing.T) {
 	var _ service.IUserRepository = (*Repository)(nil)
+	var _ service.ICredentialRepository = (*Repository)(nil)
+	var _ service.ITwoFactorRepository = (*Repository)(nil)
+	var _ service.IEmailVerificationRepository = (*Repository)(nil)
+	var _ service.IAccountAuditRepository = (*Repository)(nil)
 }
 
 func TestRepositoryInstantiation(t *testing.T) {
@@ -0,0 +1,116 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptModel persists the failed login counters of one key, an
+// account or a client address, so lockouts survive restarts.
+type LoginAttemptModel struct {
+	Key           string    `gorm:"primaryKey;size:320"`
+	Failures      int       `gorm:"not null"`
+	LastFailureAt time.Time `gorm:"not null;index"`
+	LockedUntil   time.Time `gorm:"not null"`
+}
+
+func (r *Repository) GetPasswordHash(ctx context.Context, userID uint) (string, error) {
+	var user UserModel
+	if err := r.db.WithContext(ctx).Select("id", "password_hash").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", common.ErrUserNotFound
+		}
+		return "", err
+	}
+	return user.PasswordHash, nil
+}
+
+func (r *Repository) SetPasswordHash(ctx context.Context, userID uint, hash string) error {
+	result := r.db.WithContext(ctx).Model(&UserModel{}).Where("id = ?", userID).Update("password_hash", hash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetLoginAttempts(ctx context.Context, key string) (service.LoginAttemptsDTO, error) {
+	var attempts LoginAttemptModel
+	err := r.db.WithContext(ctx).Where("key = ?", key).First(&attempts).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return service.LoginAttemptsDTO{}, nil
+	}
+	if err != nil {
+		return service.LoginAttemptsDTO{}, err
+	}
+	return toLoginAttemptsDTO(attempts), nil
+}
+
+func (r *Repository) UpdateLoginAttempts(ctx context.Context, key string, fn func(attempts service.LoginAttemptsDTO) service.LoginAttemptsDTO) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attempts, err := lockLoginAttempts(tx, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			created := toLoginAttemptModel(key, fn(service.LoginAttemptsDTO{}))
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&created)
+			if result.Error != nil || result.RowsAffected == 1 {
+				return result.Error
+			}
+			// Another request created the row first; update it instead.
+			attempts, err = lockLoginAttempts(tx, key)
+		}
+		if err != nil {
+			return err
+		}
+
+		updated := fn(toLoginAttemptsDTO(*attempts))
+		return tx.Model(&LoginAttemptModel{}).Where("key = ?", key).Updates(map[string]interface{}{
+			"failures":        updated.Failures,
+			"last_failure_at": updated.LastFailureAt,
+			"locked_until":    updated.LockedUntil,
+		}).Error
+	})
+}
+
+func lockLoginAttempts(tx *gorm.DB, key string) (*LoginAttemptModel, error) {
+	var attempts LoginAttemptModel
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
+
+func (r *Repository) DeleteLoginAttempts(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&LoginAttemptModel{}).Error
+}
+
+func (r *Repository) DeleteStaleLoginAttempts(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("last_failure_at < ? AND locked_until < ?", before, before).
+		Delete(&LoginAttemptModel{}).Error
+}
+
+func toLoginAttemptsDTO(attempts LoginAttemptModel) service.LoginAttemptsDTO {
+	return service.LoginAttemptsDTO{
+		Failures:      attempts.Failures,
+		LastFailureAt: attempts.LastFailureAt,
+		LockedUntil:   attempts.LockedUntil,
+	}
+}
+
+func toLoginAttemptModel(key string, attempts service.LoginAttemptsDTO) LoginAttemptModel {
+	return LoginAttemptModel{
+		Key:           key,
+		Failures:      attempts.Failures,
+		LastFailureAt: attempts.LastFailureAt,
+		LockedUntil:   attempts.LockedUntil,
+	}
+}
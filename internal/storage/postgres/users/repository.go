@@ -3,6 +3,7 @@ package users
 
 import (
 	"context"
+	"time"
 
 	"meu-treino-golang/users-crud/internal/common"
 	"meu-treino-golang/users-crud/internal/service"
@@ -14,6 +15,29 @@ type UserModel struct {
 	ID    uint   `gorm:"primaryKey"`
 	Name  string `gorm:"not null"`
 	Email string `gorm:"uniqueIndex;not null"`
+
+	// EmailVerifiedAt is set once the user proves they own Email.
+	EmailVerifiedAt *time.Time
+	// EmailVerificationHash is the SHA-256 hash of the pending confirmation
+	// token, empty when none is pending.
+	EmailVerificationHash      string `gorm:"size:64;index;not null;default:''"`
+	EmailVerificationExpiresAt *time.Time
+
+	// PlatformAdmin marks operations staff, who may act on any
+	// organization without being a member. It is only set in the database.
+	PlatformAdmin bool `gorm:"not null;default:false"`
+
+	// PasswordHash is a bcrypt hash; empty disables password login.
+	PasswordHash string `gorm:"not null;default:''"`
+
+	// TOTPSecret is the base32 TOTP secret, set at enrollment and kept while
+	// two-factor authentication is on.
+	TOTPSecret string `gorm:"column:totp_secret;not null;default:''"`
+	// TwoFactorEnabled is set once the user confirms the enrollment.
+	TwoFactorEnabled bool `gorm:"not null;default:false"`
+	// TOTPLastStep is the time step of the last accepted code, so that no
+	// code is accepted twice.
+	TOTPLastStep int64 `gorm:"column:totp_last_step;not null;default:0"`
 }
 
 type Repository struct {
@@ -24,8 +48,8 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Create(ctx context.Context, name, email string) (uint, error) {
-	user := UserModel{Name: name, Email: email}
+func (r *Repository) Create(ctx context.Context, name, email, passwordHash string) (uint, error) {
+	user := UserModel{Name: name, Email: email, PasswordHash: passwordHash}
 	if err := r.db.WithContext(ctx).Create(&user).Error; err != nil {
 		return 0, err
 	}
@@ -40,11 +64,7 @@ func (r *Repository) List(ctx context.Context) ([]service.UserDTO, error) {
 
 	users := make([]service.UserDTO, 0, len(models))
 	for _, m := range models {
-		users = append(users, service.UserDTO{
-			ID:    m.ID,
-			Name:  m.Name,
-			Email: m.Email,
-		})
+		users = append(users, toUserDTO(m))
 	}
 
 	return users, nil
@@ -57,10 +77,29 @@ func (r *Repository) GetByID(ctx context.Context, id uint) (*service.UserDTO, er
 		}
 		return nil, err
 	}
-	dto := &service.UserDTO{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+	dto := toUserDTO(user)
+	return &dto, nil
+}
+
+func (r *Repository) GetByEmail(ctx context.Context, email string) (*service.UserDTO, error) {
+	var user UserModel
+	if err := r.db.WithContext(ctx).Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, common.ErrUserNotFound
+		}
+		return nil, err
 	}
-	return dto, nil
-}
\ No newline at end of file
+	dto := toUserDTO(user)
+	return &dto, nil
+}
+
+func toUserDTO(user UserModel) service.UserDTO {
+	return service.UserDTO{
+		ID:               user.ID,
+		Name:             user.Name,
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerifiedAt != nil,
+		PlatformAdmin:    user.PlatformAdmin,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+	}
+}
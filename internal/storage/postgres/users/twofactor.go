@@ -0,0 +1,113 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeModel is a one-time code that replaces a TOTP code, stored as
+// a SHA-256 hash.
+type RecoveryCodeModel struct {
+	ID       uint   `gorm:"primaryKey"`
+	UserID   uint   `gorm:"not null;index"`
+	CodeHash string `gorm:"size:64;not null"`
+	UsedAt   *time.Time
+}
+
+func (r *Repository) GetTwoFactor(ctx context.Context, userID uint) (service.TwoFactorDTO, error) {
+	var user UserModel
+	err := r.db.WithContext(ctx).Select("id", "totp_secret", "two_factor_enabled").First(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return service.TwoFactorDTO{}, common.ErrUserNotFound
+	}
+	if err != nil {
+		return service.TwoFactorDTO{}, err
+	}
+
+	var left int64
+	err = r.db.WithContext(ctx).Model(&RecoveryCodeModel{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&left).Error
+	if err != nil {
+		return service.TwoFactorDTO{}, err
+	}
+
+	return service.TwoFactorDTO{
+		Secret:            user.TOTPSecret,
+		Enabled:           user.TwoFactorEnabled,
+		RecoveryCodesLeft: int(left),
+	}, nil
+}
+
+func (r *Repository) SetPendingTOTPSecret(ctx context.Context, userID uint, secret string) error {
+	result := r.db.WithContext(ctx).Model(&UserModel{}).
+		Where("id = ? AND two_factor_enabled = ?", userID, false).
+		Update("totp_secret", secret)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(ctx, userID); err != nil {
+			return err
+		}
+		return common.ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+func (r *Repository) EnableTwoFactor(ctx context.Context, userID uint, step int64, recoveryCodeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&UserModel{}).
+			Where("id = ? AND two_factor_enabled = ? AND totp_secret <> ''", userID, false).
+			Updates(map[string]interface{}{"two_factor_enabled": true, "totp_last_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return common.ErrTwoFactorNotPending
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCodeModel{}).Error; err != nil {
+			return err
+		}
+		codes := make([]RecoveryCodeModel, 0, len(recoveryCodeHashes))
+		for _, hash := range recoveryCodeHashes {
+			codes = append(codes, RecoveryCodeModel{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *Repository) DisableTwoFactor(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&UserModel{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_secret":        "",
+			"two_factor_enabled": false,
+			"totp_last_step":     0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&RecoveryCodeModel{}).Error
+	})
+}
+
+func (r *Repository) UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&UserModel{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&RecoveryCodeModel{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
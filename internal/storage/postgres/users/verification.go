@@ -0,0 +1,70 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
+
+	"gorm.io/gorm"
+)
+
+func (r *Repository) SetEmailVerification(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error {
+	result := r.db.WithContext(ctx).Model(&UserModel{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"email_verification_hash":       tokenHash,
+			"email_verification_expires_at": expiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *Repository) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (*service.UserDTO, error) {
+	if tokenHash == "" {
+		return nil, common.ErrInvalidEmailVerification
+	}
+
+	var user UserModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("email_verification_hash = ? AND email_verification_expires_at > ?", tokenHash, now).
+			First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrInvalidEmailVerification
+		}
+		if err != nil {
+			return err
+		}
+
+		// The hash is cleared in the same statement, so a token is used once
+		// even when two requests race.
+		result := tx.Model(&UserModel{}).
+			Where("id = ? AND email_verification_hash = ?", user.ID, tokenHash).
+			Updates(map[string]interface{}{
+				"email_verified_at":             now,
+				"email_verification_hash":       "",
+				"email_verification_expires_at": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return common.ErrInvalidEmailVerification
+		}
+		user.EmailVerifiedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dto := toUserDTO(user)
+	return &dto, nil
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 
+	"meu-treino-golang/users-crud/internal/auth"
 	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/ratelimit"
+	orgDomain "meu-treino-golang/users-crud/internal/service/domain/organizations"
 	"meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+	rateLimitStorage "meu-treino-golang/users-crud/internal/storage/postgres/ratelimit"
 	"meu-treino-golang/users-crud/internal/storage/postgres/users"
+	orgHandler "meu-treino-golang/users-crud/pkg/handler/organizations"
+	"meu-treino-golang/users-crud/pkg/middleware"
 	"meu-treino-golang/users-crud/routes"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +23,12 @@ import (
 )
 
 func main() {
+	// 0. Configurar autenticação (AUTH_MODE) antes de qualquer conexão
+	authenticator, err := auth.New(auth.ConfigFromEnv())
+	if err != nil {
+		log.Fatal("Failed to configure authentication:", err)
+	}
+
 	// 1. Conectar ao banco de dados PostgreSQL
 	dsn := os.Getenv("DATABASE_URL")
 	if dsn == "" {
@@ -23,34 +37,73 @@ func main() {
 		log.Println("DATABASE_URL not set. Using default DSN for local development.")
 	}
 
-	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal("Failed to connect to PostgreSQL database:", err)
 	}
 
-	// 2. AutoMigrate UserModel
-	if err := database.AutoMigrate(&users.UserModel{}); err != nil {
+	// 2. AutoMigrate UserModel, login lockouts and 2FA recovery codes
+	if err := database.AutoMigrate(&users.UserModel{}, &users.LoginAttemptModel{}, &users.RecoveryCodeModel{}); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
 	// 2a. AutoMigrate Organization Models
-	if err := database.AutoMigrate(&organizations.OrganizationModel{}, &organizations.OrgUserModel{}); err != nil {
+	if err := database.AutoMigrate(organizations.Models()...); err != nil {
 		log.Fatal("Failed to migrate organization models:", err)
 	}
 
+	// 2b. AutoMigrate rate limit buckets (RATE_LIMIT_STORE=postgres)
+	if err := database.AutoMigrate(&rateLimitStorage.BucketModel{}); err != nil {
+		log.Fatal("Failed to migrate rate limit buckets:", err)
+	}
+
+	// 2c. Seed built-in roles (READ, WRITE, ROOT), backfill organization slugs
+	// and make the audit log append-only
+	if err := orgDomain.Migrate(context.Background(), organizations.NewRepository(database)); err != nil {
+		log.Fatal("Failed to prepare organization data:", err)
+	}
+
 	// 3. Inicializar dependências
+	rateLimitStore, err := rateLimitStoreFromEnv(database)
+	if err != nil {
+		log.Fatal("Failed to configure rate limiting:", err)
+	}
 	deps := &common.Dependencies{
-		DB: database,
+		DB:             database,
+		Authenticator:  authenticator,
+		RateLimitStore: rateLimitStore,
+		Environment:    os.Getenv("APP_ENV"),
+		TrustedProxies: middleware.TrustedProxiesFromEnv(),
 	}
 
 	// 4. Inicializar Gin
 	router := gin.Default()
 
 	// 5. Registrar rotas
-	routes.RegisterRoutes(router, deps)
+	if err := routes.RegisterRoutes(router, deps); err != nil {
+		log.Fatal("Failed to register routes:", err)
+	}
+
+	// 5a. Iniciar tarefas em segundo plano (expiração de vínculos)
+	if err := orgHandler.StartJobs(context.Background(), deps); err != nil {
+		log.Fatal("Failed to start background jobs:", err)
+	}
 
 	// 6. Iniciar servidor
 	if err := router.Run(":8080"); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// rateLimitStoreFromEnv builds the store named by RATE_LIMIT_STORE, memory
+// by default.
+func rateLimitStoreFromEnv(db *gorm.DB) (ratelimit.Store, error) {
+	switch name := os.Getenv("RATE_LIMIT_STORE"); name {
+	case "", ratelimit.StoreMemory:
+		return ratelimit.NewMemoryStore(), nil
+	case ratelimit.StorePostgres:
+		return ratelimit.NewPostgresStore(rateLimitStorage.NewRepository(db)), nil
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q", name)
+	}
+}
@@ -0,0 +1,76 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IsPlatformAdmin is used by the route policy middleware.
+func (h *Handler) IsPlatformAdmin(c *gin.Context) bool {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return false
+	}
+	admin, err := h.orgService.IsPlatformAdmin(c.Request.Context(), userID)
+	return err == nil && admin
+}
+
+// ListAllOrgs lists every organization, archived ones included, with member
+// counts. It is reserved to platform admins.
+func (h *Handler) ListAllOrgs(c *gin.Context) {
+	page, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	orgs, page, err := h.orgService.ListAllOrgs(c.Request.Context(), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]dto.AdminOrgResponse, 0, len(orgs))
+	for _, org := range orgs {
+		response = append(response, dto.AdminOrgResponse{
+			ID:          org.ID,
+			Name:        org.Name,
+			Slug:        org.Slug,
+			ParentID:    org.ParentID,
+			Plan:        org.Plan,
+			ArchivedAt:  org.ArchivedAt,
+			MemberCount: org.MemberCount,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response, "pagination": page})
+}
+
+// SetOrgPlan moves an organization to another plan.
+func (h *Handler) SetOrgPlan(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.orgService.SetPlan(c.Request.Context(), orgID, req.Plan); err != nil {
+		status := orgErrorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, orgService.ErrUnknownPlan) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "organization plan updated successfully"})
+}
@@ -0,0 +1,56 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ArchiveOrg makes an organization read-only.
+func (h *Handler) ArchiveOrg(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.ArchiveOrg(c.Request.Context(), orgID); err != nil {
+		c.JSON(orgErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "organization archived successfully"})
+}
+
+func (h *Handler) UnarchiveOrg(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.UnarchiveOrg(c.Request.Context(), orgID); err != nil {
+		c.JSON(orgErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "organization unarchived successfully"})
+}
+
+// orgErrorStatus maps the errors shared by every organization operation to a
+// status code, falling back to fallback for anything else.
+func orgErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orgService.ErrCapabilityNotHeld), errors.Is(err, orgService.ErrEmailDomainNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, orgService.ErrOrgArchived), errors.Is(err, orgService.ErrOrgNotArchived),
+		errors.Is(err, orgService.ErrQuotaExceeded), errors.Is(err, orgService.ErrLastRoot):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
+}
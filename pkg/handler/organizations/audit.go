@@ -0,0 +1,131 @@
+package organizations
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListOrgAuditLog lists the audit log of one organization, newest first.
+// It accepts the filters actor_id, action, target_type, target_id, since
+// and until (RFC 3339) and the usual pagination.
+func (h *Handler) ListOrgAuditLog(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	filter, err := parseAuditFilter(c, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.OrgID = &orgID
+	h.listAuditLog(c, filter)
+}
+
+// ListAuditLog lists the audit log of the whole platform. On top of the
+// organization filters it accepts org_id. It is reserved to platform admins.
+func (h *Handler) ListAuditLog(c *gin.Context) {
+	filter, err := parseAuditFilter(c, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.listAuditLog(c, filter)
+}
+
+func (h *Handler) listAuditLog(c *gin.Context, filter orgService.AuditFilter) {
+	page, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	entries, page, err := h.orgService.ListAuditLog(c.Request.Context(), filter, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]dto.AuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, dto.AuditEntryResponse{
+			ID:           entry.ID,
+			ActorID:      entry.ActorID,
+			OnBehalfOfID: entry.OnBehalfOfID,
+			Action:       entry.Action,
+			TargetType:   entry.TargetType,
+			TargetID:     entry.TargetID,
+			OrgID:        entry.OrgID,
+			Before:       entry.Before,
+			After:        entry.After,
+			IP:           entry.IP,
+			UserAgent:    entry.UserAgent,
+			RequestID:    entry.RequestID,
+			CreatedAt:    entry.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response, "pagination": page})
+}
+
+// parseAuditFilter reads the audit log filters from the query string.
+// org_id is only read when allowOrg is set.
+func parseAuditFilter(c *gin.Context, allowOrg bool) (orgService.AuditFilter, error) {
+	var filter orgService.AuditFilter
+	var err error
+
+	if allowOrg {
+		if filter.OrgID, err = queryID(c, "org_id"); err != nil {
+			return filter, err
+		}
+	}
+	if filter.ActorID, err = queryID(c, "actor_id"); err != nil {
+		return filter, err
+	}
+	if filter.TargetID, err = queryID(c, "target_id"); err != nil {
+		return filter, err
+	}
+	if filter.Since, err = queryTime(c, "since"); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = queryTime(c, "until"); err != nil {
+		return filter, err
+	}
+	filter.Action = c.Query("action")
+	filter.TargetType = c.Query("target_type")
+	return filter, nil
+}
+
+// queryID reads an optional ID from the query string. actor_id=0 is valid
+// and selects changes made by the system.
+func queryID(c *gin.Context, name string) (*uint, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	id := uint(parsed)
+	return &id, nil
+}
+
+func queryTime(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected RFC 3339", name)
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,75 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkUpdateMembers applies a list of add/update/remove operations in one
+// transaction. Either every operation is applied or none is; the response
+// lists the outcome of each.
+func (h *Handler) BulkUpdateMembers(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.BulkMembershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ops := make([]orgService.BulkOperation, 0, len(req.Operations))
+	for _, op := range req.Operations {
+		ops = append(ops, orgService.BulkOperation{
+			Op:         orgService.BulkOp(op.Op),
+			UserID:     op.UserID,
+			Permission: op.Permission,
+			RoleID:     op.RoleID,
+			ExpiresAt:  op.ExpiresAt,
+		})
+	}
+
+	actorID, _ := currentUserID(c)
+	results, err := h.orgService.BulkUpdateMembers(c.Request.Context(), orgID, actorID, ops)
+	if err != nil {
+		if errors.Is(err, orgService.ErrBulkRejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "results": toBulkResults(results, false)})
+			return
+		}
+		respondError(c, orgErrorStatus(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": toBulkResults(results, true)})
+}
+
+// toBulkResults reports each operation as "applied", "failed" or, in a
+// rejected batch, "skipped" when the operation itself was valid.
+func toBulkResults(results []orgService.BulkResult, applied bool) []dto.BulkMembershipResult {
+	response := make([]dto.BulkMembershipResult, 0, len(results))
+	for _, result := range results {
+		item := dto.BulkMembershipResult{
+			Index:  result.Index,
+			Op:     string(result.Op),
+			UserID: result.UserID,
+		}
+		switch {
+		case result.Error != nil:
+			item.Status = "failed"
+			item.Error = result.Error.Error()
+		case applied:
+			item.Status = "applied"
+		default:
+			item.Status = "skipped"
+		}
+		response = append(response, item)
+	}
+	return response
+}
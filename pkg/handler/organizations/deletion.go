@@ -0,0 +1,117 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteOrg queues the deletion of an organization and answers 202 with the
+// job tracking it. With ?dry_run=true it only reports what would be deleted.
+func (h *Handler) DeleteOrg(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dry_run"})
+		return
+	}
+
+	if dryRun {
+		impact, err := h.orgService.GetDeletionImpact(c.Request.Context(), orgID)
+		if err != nil {
+			c.JSON(orgErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, toDeletionImpactResponse(*impact))
+		return
+	}
+
+	requestedBy, _ := currentUserID(c)
+	job, err := h.orgService.RequestOrgDeletion(c.Request.Context(), orgID, requestedBy)
+	if err != nil {
+		c.JSON(deletionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/org-deletions/%d", job.ID))
+	c.JSON(http.StatusAccepted, toDeletionJobResponse(*job))
+}
+
+// GetDeletionJob reports the status of a deletion job. The organization may
+// already be gone, so only the user who requested the deletion can see it.
+func (h *Handler) GetDeletionJob(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("jobId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id"})
+		return
+	}
+
+	job, err := h.orgService.GetDeletionJob(c.Request.Context(), uint(jobID))
+	if err != nil {
+		c.JSON(deletionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if userID, _ := currentUserID(c); userID != job.RequestedBy {
+		c.JSON(http.StatusNotFound, gin.H{"error": orgService.ErrDeletionJobNotFound.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeletionJobResponse(*job))
+}
+
+func deletionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orgService.ErrDeletionJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orgService.ErrDeletionInProgress):
+		return http.StatusConflict
+	default:
+		return orgErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+func toDeletionImpactResponse(impact orgService.DeletionImpact) dto.DeletionImpactResponse {
+	return dto.DeletionImpactResponse{
+		OrgID:              impact.OrgID,
+		Name:               impact.Name,
+		Members:            impact.Members,
+		Teams:              impact.Teams,
+		TeamMembers:        impact.TeamMembers,
+		Invitations:        impact.Invitations,
+		PendingInvitations: impact.PendingInvitations,
+		CustomRoles:        impact.CustomRoles,
+		JoinRequests:       impact.JoinRequests,
+		Domains:            impact.Domains,
+		Settings:           impact.Settings,
+		SlugHistory:        impact.SlugHistory,
+		ExpiryRecords:      impact.ExpiryRecords,
+		SSOConfigs:         impact.SSOConfigs,
+		SSOIdentities:      impact.SSOIdentities,
+		Subsidiaries:       impact.Subsidiaries,
+	}
+}
+
+func toDeletionJobResponse(job orgService.DeletionJobDTO) dto.DeletionJobResponse {
+	return dto.DeletionJobResponse{
+		ID:          job.ID,
+		OrgID:       job.OrgID,
+		OrgName:     job.OrgName,
+		Status:      job.Status,
+		Error:       job.Error,
+		RequestedBy: job.RequestedBy,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+}
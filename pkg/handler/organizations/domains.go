@@ -0,0 +1,127 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClaimDomain starts the claim of an email domain and returns the TXT record
+// to publish.
+func (h *Handler) ClaimDomain(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ClaimDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, err := h.orgService.ClaimDomain(c.Request.Context(), orgID, req.Domain)
+	if err != nil {
+		c.JSON(domainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDomainClaimResponse(*claim))
+}
+
+func (h *Handler) ListDomainClaims(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	claims, err := h.orgService.ListDomainClaims(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]dto.DomainClaimResponse, 0, len(claims))
+	for _, claim := range claims {
+		response = append(response, toDomainClaimResponse(claim))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyDomain checks the claim's TXT record.
+func (h *Handler) VerifyDomain(c *gin.Context) {
+	orgID, claimID, ok := h.parseDomainParams(c)
+	if !ok {
+		return
+	}
+
+	claim, err := h.orgService.VerifyDomain(c.Request.Context(), orgID, claimID)
+	if err != nil {
+		c.JSON(domainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toDomainClaimResponse(*claim))
+}
+
+func (h *Handler) RemoveDomainClaim(c *gin.Context) {
+	orgID, claimID, ok := h.parseDomainParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.RemoveDomainClaim(c.Request.Context(), orgID, claimID); err != nil {
+		c.JSON(domainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "domain claim removed"})
+}
+
+func (h *Handler) parseDomainParams(c *gin.Context) (uint, uint, bool) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	claimID, err := strconv.ParseUint(c.Param("domainId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid domain id"})
+		return 0, 0, false
+	}
+
+	return orgID, uint(claimID), true
+}
+
+func domainErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orgService.ErrDomainClaimNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orgService.ErrDomainTaken), errors.Is(err, orgService.ErrDomainAlreadyClaimed):
+		return http.StatusConflict
+	case errors.Is(err, orgService.ErrDomainNotVerified), errors.Is(err, orgService.ErrInvalidDomain),
+		errors.Is(err, orgService.ErrPublicEmailDomain):
+		return http.StatusUnprocessableEntity
+	default:
+		return orgErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+func toDomainClaimResponse(claim orgService.DomainClaimDTO) dto.DomainClaimResponse {
+	return dto.DomainClaimResponse{
+		ID:          claim.ID,
+		OrgID:       claim.OrgID,
+		Domain:      claim.Domain,
+		RecordName:  claim.RecordName,
+		RecordValue: claim.RecordValue,
+		Verified:    claim.VerifiedAt != nil,
+		VerifiedAt:  claim.VerifiedAt,
+		CreatedAt:   claim.CreatedAt,
+	}
+}
@@ -0,0 +1,74 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultExpiringDays = 7
+
+// SetMembershipExpiry extends or clears the expiry of a membership.
+func (h *Handler) SetMembershipExpiry(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req dto.SetMembershipExpiryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, _ := currentUserID(c)
+	if err := h.orgService.SetMembershipExpiry(c.Request.Context(), orgID, actorID, uint(userID), req.ExpiresAt); err != nil {
+		status := orgErrorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, orgService.ErrInvalidExpiry) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "membership expiry updated successfully"})
+}
+
+// ListExpiringMembers lists the members whose access ends within ?days=
+// (7 by default).
+func (h *Handler) ListExpiringMembers(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultExpiringDays)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+		return
+	}
+
+	users, err := h.orgService.ListExpiringMembers(c.Request.Context(), orgID, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		if errors.Is(err, orgService.ErrInvalidExpiringWindow) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.orgUserResponses(c, users))
+}
@@ -4,6 +4,7 @@ package organizations
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"meu-treino-golang/users-crud/dto"
 	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
@@ -13,15 +14,26 @@ import (
 	"gorm.io/gorm"
 )
 
+// orgIDKey is the context key under which the route policy leaves the
+// resolved organization ID.
+const orgIDKey = "orgID"
+
 type Handler struct {
 	orgService orgService.IOrganizationService
 	db         *gorm.DB
+	sessions   SessionIssuer
+	challenges ChallengeIssuer
+	// secureCookies restricts the cookies the handler sets to https.
+	secureCookies bool
 }
 
-func NewHandler(service orgService.IOrganizationService, db *gorm.DB) *Handler {
+func NewHandler(service orgService.IOrganizationService, db *gorm.DB, sessions SessionIssuer, challenges ChallengeIssuer, secureCookies bool) *Handler {
 	return &Handler{
-		orgService: service,
-		db:         db,
+		orgService:    service,
+		db:            db,
+		sessions:      sessions,
+		challenges:    challenges,
+		secureCookies: secureCookies,
 	}
 }
 
@@ -33,17 +45,37 @@ func (h *Handler) CreateOrg(c *gin.Context) {
 		return
 	}
 
-	id, err := h.orgService.CreateOrg(c.Request.Context(), req.Name)
+	creatorID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	id, err := h.orgService.CreateOrg(c.Request.Context(), req.Name, creatorID)
 	if err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"id": id})
+	response, err := h.orgDetail(c, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
 }
 
+// ListOrgs lists organizations. Archived organizations are left out unless
+// the request asks for them with ?archived=true.
 func (h *Handler) ListOrgs(c *gin.Context) {
-	orgs, err := h.orgService.ListOrgs(c.Request.Context())
+	includeArchived, err := strconv.ParseBool(c.DefaultQuery("archived", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid archived filter"})
+		return
+	}
+
+	orgs, err := h.orgService.ListOrgs(c.Request.Context(), includeArchived)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -52,8 +84,11 @@ func (h *Handler) ListOrgs(c *gin.Context) {
 	response := make([]dto.OrganizationResponse, 0, len(orgs))
 	for _, org := range orgs {
 		response = append(response, dto.OrganizationResponse{
-			ID:   org.ID,
-			Name: org.Name,
+			ID:         org.ID,
+			Name:       org.Name,
+			Slug:       org.Slug,
+			ParentID:   org.ParentID,
+			ArchivedAt: org.ArchivedAt,
 		})
 	}
 
@@ -61,13 +96,12 @@ func (h *Handler) ListOrgs(c *gin.Context) {
 }
 
 func (h *Handler) GetOrg(c *gin.Context) {
-	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
 		return
 	}
 
-	org, err := h.orgService.GetOrg(c.Request.Context(), uint(orgID))
+	response, err := h.orgDetail(c, orgID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
@@ -77,42 +111,12 @@ func (h *Handler) GetOrg(c *gin.Context) {
 		return
 	}
 
-	// Get organization users
-	users, err := h.orgService.GetOrgUsers(c.Request.Context(), uint(orgID))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Fetch user details for each org user
-	userResponses := make([]dto.OrgUserResponse, 0, len(users))
-	usersRepo := usersStorage.NewRepository(h.db)
-	for _, user := range users {
-		userModel, err := usersRepo.GetByID(c.Request.Context(), user.UserID)
-		if err == nil && userModel != nil {
-			userResponses = append(userResponses, dto.OrgUserResponse{
-				UserID:     user.UserID,
-				UserName:   userModel.Name,
-				UserEmail:  userModel.Email,
-				OrgID:      user.OrgID,
-				Permission: user.Permission,
-			})
-		}
-	}
-
-	response := dto.OrganizationDetailResponse{
-		ID:    org.ID,
-		Name:  org.Name,
-		Users: userResponses,
-	}
-
 	c.JSON(http.StatusOK, response)
 }
 
 func (h *Handler) UpdateOrg(c *gin.Context) {
-	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
 		return
 	}
 
@@ -122,52 +126,18 @@ func (h *Handler) UpdateOrg(c *gin.Context) {
 		return
 	}
 
-	// Check if user has WRITE permission
-	if !h.hasOrgPermission(c, uint(orgID), []dto.PermissionType{dto.PermissionWrite, dto.PermissionRoot}) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
-		return
-	}
-
-	if err := h.orgService.UpdateOrg(c.Request.Context(), uint(orgID), req.Name); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.orgService.UpdateOrg(c.Request.Context(), orgID, req.Name); err != nil {
+		c.JSON(orgErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "organization updated successfully"})
 }
 
-func (h *Handler) DeleteOrg(c *gin.Context) {
-	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
-		return
-	}
-
-	// Check if user has ROOT permission
-	if !h.hasOrgPermission(c, uint(orgID), []dto.PermissionType{dto.PermissionRoot}) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
-		return
-	}
-
-	if err := h.orgService.DeleteOrg(c.Request.Context(), uint(orgID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "organization deleted successfully"})
-}
-
 // AddUserToOrg adds a user to an organization.
 func (h *Handler) AddUserToOrg(c *gin.Context) {
-	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
-		return
-	}
-
-	// Check if user has ROOT permission
-	if !h.hasOrgPermission(c, uint(orgID), []dto.PermissionType{dto.PermissionRoot}) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
 		return
 	}
 
@@ -177,8 +147,9 @@ func (h *Handler) AddUserToOrg(c *gin.Context) {
 		return
 	}
 
-	if err := h.orgService.AddUserToOrg(c.Request.Context(), uint(orgID), req.UserID, req.Permission); err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	actorID, _ := currentUserID(c)
+	if err := h.orgService.AddUserToOrg(c.Request.Context(), orgID, actorID, req.UserID, req.Permission, req.RoleID, req.ExpiresAt); err != nil {
+		respondError(c, orgErrorStatus(err, http.StatusUnprocessableEntity), err)
 		return
 	}
 
@@ -186,47 +157,23 @@ func (h *Handler) AddUserToOrg(c *gin.Context) {
 }
 
 func (h *Handler) ListOrgUsers(c *gin.Context) {
-	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
 		return
 	}
 
-	// Check if user has READ permission
-	if !h.hasOrgPermission(c, uint(orgID), []dto.PermissionType{dto.PermissionRead, dto.PermissionWrite, dto.PermissionRoot}) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
-		return
-	}
-
-	users, err := h.orgService.GetOrgUsers(c.Request.Context(), uint(orgID))
+	users, err := h.orgService.GetOrgUsers(c.Request.Context(), orgID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Fetch user details for each org user
-	usersRepo := usersStorage.NewRepository(h.db)
-	response := make([]dto.OrgUserResponse, 0, len(users))
-	for _, user := range users {
-		userModel, err := usersRepo.GetByID(c.Request.Context(), user.UserID)
-		if err == nil && userModel != nil {
-			response = append(response, dto.OrgUserResponse{
-				UserID:     user.UserID,
-				UserName:   userModel.Name,
-				UserEmail:  userModel.Email,
-				OrgID:      user.OrgID,
-				Permission: user.Permission,
-			})
-		}
-	}
-
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, h.orgUserResponses(c, users))
 }
 
 func (h *Handler) UpdateUserPermission(c *gin.Context) {
-	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
 		return
 	}
 
@@ -236,20 +183,15 @@ func (h *Handler) UpdateUserPermission(c *gin.Context) {
 		return
 	}
 
-	// Check if user has ROOT permission
-	if !h.hasOrgPermission(c, uint(orgID), []dto.PermissionType{dto.PermissionRoot}) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
-		return
-	}
-
 	var req dto.UpdateOrgUserPermissionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.orgService.UpdateUserPermission(c.Request.Context(), uint(orgID), uint(userID), req.Permission); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	actorID, _ := currentUserID(c)
+	if err := h.orgService.UpdateUserPermission(c.Request.Context(), orgID, actorID, uint(userID), req.Permission, req.RoleID); err != nil {
+		respondError(c, orgErrorStatus(err, http.StatusInternalServerError), err)
 		return
 	}
 
@@ -257,9 +199,8 @@ func (h *Handler) UpdateUserPermission(c *gin.Context) {
 }
 
 func (h *Handler) RemoveUserFromOrg(c *gin.Context) {
-	orgID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
 		return
 	}
 
@@ -269,43 +210,124 @@ func (h *Handler) RemoveUserFromOrg(c *gin.Context) {
 		return
 	}
 
-	// Check if user has ROOT permission
-	if !h.hasOrgPermission(c, uint(orgID), []dto.PermissionType{dto.PermissionRoot}) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	actorID, _ := currentUserID(c)
+	if err := h.orgService.RemoveUserFromOrg(c.Request.Context(), orgID, actorID, uint(userID)); err != nil {
+		c.JSON(orgErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.orgService.RemoveUserFromOrg(c.Request.Context(), uint(orgID), uint(userID)); err != nil {
+	c.JSON(http.StatusOK, gin.H{"message": "user removed from organization"})
+}
+
+// Helper methods
+
+// orgDetail loads an organization together with its members.
+func (h *Handler) orgDetail(c *gin.Context, orgID uint) (*dto.OrganizationDetailResponse, error) {
+	org, err := h.orgService.GetOrg(c.Request.Context(), orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := h.orgService.GetOrgUsers(c.Request.Context(), orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.OrganizationDetailResponse{
+		ID:                  org.ID,
+		Name:                org.Name,
+		Slug:                org.Slug,
+		ParentID:            org.ParentID,
+		InheritParentAccess: org.InheritParentAccess,
+		ArchivedAt:          org.ArchivedAt,
+		Users:               h.orgUserResponses(c, users),
+	}, nil
+}
+
+// orgUserResponses fetches user details for each org user.
+func (h *Handler) orgUserResponses(c *gin.Context, users []orgService.OrgUserDTO) []dto.OrgUserResponse {
+	usersRepo := usersStorage.NewRepository(h.db)
+	response := make([]dto.OrgUserResponse, 0, len(users))
+	for _, user := range users {
+		userModel, err := usersRepo.GetByID(c.Request.Context(), user.UserID)
+		if err == nil && userModel != nil {
+			response = append(response, dto.OrgUserResponse{
+				UserID:     user.UserID,
+				UserName:   userModel.Name,
+				UserEmail:  userModel.Email,
+				OrgID:      user.OrgID,
+				Permission: user.Permission,
+				RoleID:     user.RoleID,
+				Role:       user.RoleName,
+				ExpiresAt:  user.ExpiresAt,
+			})
+		}
+	}
+	return response
+}
+
+// parseOrgID resolves the :orgId path parameter, which may be a numeric ID or
+// a slug. A GET through a slug the organization no longer uses is redirected
+// to its current slug.
+func (h *Handler) parseOrgID(c *gin.Context) (uint, bool) {
+	if orgID, ok := c.Get(orgIDKey); ok {
+		return orgID.(uint), true
+	}
+
+	ref := c.Param("orgId")
+	orgID, slug, err := h.orgService.ResolveOrgRef(c.Request.Context(), ref)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			return 0, false
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return 0, false
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "user removed from organization"})
+	if slug != "" && slug != ref && c.Request.Method == http.MethodGet {
+		location := *c.Request.URL
+		location.Path = strings.Replace(location.Path, "/"+ref, "/"+slug, 1)
+		c.Redirect(http.StatusMovedPermanently, location.String())
+		return 0, false
+	}
+
+	return orgID, true
 }
 
-// Helper methods
-func (h *Handler) hasOrgPermission(c *gin.Context, orgID uint, requiredPermissions []dto.PermissionType) bool {
-	// TODO: Extract user ID from context/token
-	// For now, this is a placeholder that should be implemented with proper authentication
-	// In a real scenario, you would get the user ID from JWT token or session
+// currentUserID returns the authenticated user ID stored in the context.
+func currentUserID(c *gin.Context) (uint, bool) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		// Allow all operations if no user context (for development)
-		// In production, this should return false
-		return true
+		return 0, false
 	}
+	id, ok := userID.(uint)
+	return id, ok && id != 0
+}
 
-	permission, err := h.orgService.GetUserPermissionInOrg(c.Request.Context(), orgID, userID.(uint))
-	if err != nil {
-		return false
+// ResolveOrg resolves the :orgId path parameter for the route policy and
+// keeps the result for the handler.
+func (h *Handler) ResolveOrg(c *gin.Context) (uint, bool) {
+	orgID, ok := h.parseOrgID(c)
+	if ok {
+		c.Set(orgIDKey, orgID)
 	}
+	return orgID, ok
+}
 
-	for _, required := range requiredPermissions {
-		if permission == required {
-			return true
-		}
+// Authorize is used by the route policy middleware.
+func (h *Handler) Authorize(c *gin.Context, orgID uint, capability orgService.Capability) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return orgService.ErrForbidden
 	}
-	return false
+	return h.orgService.Authorize(c.Request.Context(), orgID, userID, capability)
+}
+
+// authorize asks the organization policy whether the caller holds capability.
+// Anonymous callers hold nothing.
+func (h *Handler) authorize(c *gin.Context, orgID uint, capability orgService.Capability) bool {
+	return h.Authorize(c, orgID, capability) == nil
 }
 
 func (h *Handler) RegisterRoutes(router *gin.Engine) {
@@ -319,15 +341,111 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 			orgGroup.GET("/:orgId", h.GetOrg)
 			orgGroup.PUT("/:orgId", h.UpdateOrg)
 			orgGroup.DELETE("/:orgId", h.DeleteOrg)
+			orgGroup.POST("/:orgId/archive", h.ArchiveOrg)
+			orgGroup.POST("/:orgId/unarchive", h.UnarchiveOrg)
+
+			orgGroup.GET("/:orgId/usage", h.GetUsage)
+
+			// Organization settings
+			orgGroup.GET("/:orgId/settings", h.GetSettings)
+			orgGroup.PATCH("/:orgId/settings", h.UpdateSettings)
+
+			// Organization hierarchy
+			orgGroup.PUT("/:orgId/parent", h.SetParent)
+			orgGroup.PUT("/:orgId/inherit-access", h.SetInheritParentAccess)
+			orgGroup.GET("/:orgId/ancestors", h.ListAncestors)
+			orgGroup.GET("/:orgId/subtree", h.ListSubtree)
+
+			// Organization audit log
+			orgGroup.GET("/:orgId/audit", h.ListOrgAuditLog)
 
 			// Organization Users
 			usersGroup := orgGroup.Group("/:orgId/users")
 			{
 				usersGroup.POST("", h.AddUserToOrg)
 				usersGroup.GET("", h.ListOrgUsers)
+				usersGroup.GET("/expiring", h.ListExpiringMembers)
+				usersGroup.POST("/bulk", h.BulkUpdateMembers)
 				usersGroup.PUT("/:userId", h.UpdateUserPermission)
 				usersGroup.DELETE("/:userId", h.RemoveUserFromOrg)
+				usersGroup.PUT("/:userId/expiry", h.SetMembershipExpiry)
+			}
+
+			// Organization Invitations
+			invitationsGroup := orgGroup.Group("/:orgId/invitations")
+			{
+				invitationsGroup.POST("", h.CreateInvitation)
+				invitationsGroup.GET("", h.ListInvitations)
+				invitationsGroup.DELETE("/:invitationId", h.RevokeInvitation)
+				invitationsGroup.POST("/:invitationId/resend", h.ResendInvitation)
+			}
+
+			// Organization Join Requests
+			joinRequestsGroup := orgGroup.Group("/:orgId/join-requests")
+			{
+				joinRequestsGroup.POST("", h.CreateJoinRequest)
+				joinRequestsGroup.GET("", h.ListJoinRequests)
+				joinRequestsGroup.POST("/:requestId/approve", h.ApproveJoinRequest)
+				joinRequestsGroup.POST("/:requestId/reject", h.RejectJoinRequest)
+			}
+
+			// Organization Email Domains
+			domainsGroup := orgGroup.Group("/:orgId/domains")
+			{
+				domainsGroup.POST("", h.ClaimDomain)
+				domainsGroup.GET("", h.ListDomainClaims)
+				domainsGroup.POST("/:domainId/verify", h.VerifyDomain)
+				domainsGroup.DELETE("/:domainId", h.RemoveDomainClaim)
+			}
+
+			// Organization single sign-on
+			orgGroup.GET("/:orgId/sso", h.GetSSOConfig)
+			orgGroup.PUT("/:orgId/sso", h.SetSSOConfig)
+			orgGroup.DELETE("/:orgId/sso", h.DeleteSSOConfig)
+
+			// Organization Roles
+			rolesGroup := orgGroup.Group("/:orgId/roles")
+			{
+				rolesGroup.POST("", h.CreateRole)
+				rolesGroup.GET("", h.ListRoles)
+				rolesGroup.PUT("/:roleId", h.UpdateRole)
+				rolesGroup.DELETE("/:roleId", h.DeleteRole)
+			}
+
+			// Organization Teams
+			teamsGroup := orgGroup.Group("/:orgId/teams")
+			{
+				teamsGroup.POST("", h.CreateTeam)
+				teamsGroup.GET("", h.ListTeams)
+				teamsGroup.GET("/:teamId", h.GetTeam)
+				teamsGroup.PUT("/:teamId", h.UpdateTeam)
+				teamsGroup.DELETE("/:teamId", h.DeleteTeam)
+				teamsGroup.POST("/:teamId/members", h.AddTeamMember)
+				teamsGroup.DELETE("/:teamId/members/:userId", h.RemoveTeamMember)
 			}
 		}
+
+		// Single sign-on through an organization's identity provider
+		apiGroup.GET("/auth/sso/:orgId/login", h.StartSSOLogin)
+		apiGroup.GET("/auth/sso/:orgId/callback", h.SSOCallback)
+
+		// Organization deletion jobs
+		apiGroup.GET("/org-deletions/:jobId", h.GetDeletionJob)
+
+		// Platform administration
+		adminGroup := apiGroup.Group("/admin")
+		{
+			adminGroup.GET("/orgs", h.ListAllOrgs)
+			adminGroup.PUT("/orgs/:orgId/plan", h.SetOrgPlan)
+			adminGroup.GET("/audit", h.ListAuditLog)
+		}
+
+		// Memberships of a user
+		apiGroup.GET("/me/orgs", h.ListMyOrgs)
+		apiGroup.GET("/users/:id/orgs", h.ListUserOrgs)
+
+		// Invitation responses
+		apiGroup.POST("/invitations/accept", h.AcceptInvitation)
+		apiGroup.POST("/invitations/decline", h.DeclineInvitation)
 	}
 }
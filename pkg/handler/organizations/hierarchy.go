@@ -0,0 +1,126 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetParent moves an organization under a parent organization. The caller
+// must be allowed to change the hierarchy of both organizations; the route
+// policy checks the child, the parent comes from the body and is checked
+// here.
+func (h *Handler) SetParent(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetParentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.ParentID != nil && !h.authorize(c, *req.ParentID, orgService.CapOrgHierarchy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions on parent organization"})
+		return
+	}
+
+	inherit := true
+	if req.InheritParentAccess != nil {
+		inherit = *req.InheritParentAccess
+	}
+
+	if err := h.orgService.SetParent(c.Request.Context(), orgID, req.ParentID, inherit); err != nil {
+		c.JSON(hierarchyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "organization parent updated successfully"})
+}
+
+// SetInheritParentAccess turns on or off the ROOT access flowing from the
+// parent organization. Unlike SetParent, it needs no permission on the
+// parent: only the organization decides whom it lets in.
+func (h *Handler) SetInheritParentAccess(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetInheritParentAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.orgService.SetInheritParentAccess(c.Request.Context(), orgID, *req.InheritParentAccess); err != nil {
+		c.JSON(hierarchyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "parent access inheritance updated successfully"})
+}
+
+func hierarchyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, orgService.ErrParentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orgService.ErrHierarchyCycle), errors.Is(err, orgService.ErrHierarchyTooDeep),
+		errors.Is(err, orgService.ErrOrgArchived):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *Handler) ListAncestors(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	nodes, err := h.orgService.ListAncestors(c.Request.Context(), orgID)
+	h.respondNodes(c, nodes, err)
+}
+
+func (h *Handler) ListSubtree(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	nodes, err := h.orgService.ListSubtree(c.Request.Context(), orgID)
+	h.respondNodes(c, nodes, err)
+}
+
+func (h *Handler) respondNodes(c *gin.Context, nodes []orgService.OrgNodeDTO, err error) {
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]dto.OrgNodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		response = append(response, dto.OrgNodeResponse{
+			ID:                  node.ID,
+			Name:                node.Name,
+			Slug:                node.Slug,
+			ParentID:            node.ParentID,
+			InheritParentAccess: node.InheritParentAccess,
+			Depth:               node.Depth,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
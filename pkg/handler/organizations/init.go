@@ -2,16 +2,57 @@
 package organizations
 
 import (
+	"context"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/auth"
 	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
 	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
 	orgStorage "meu-treino-golang/users-crud/internal/storage/postgres/organizations"
+	userStorage "meu-treino-golang/users-crud/internal/storage/postgres/users"
 )
 
-func InitHandler(deps *common.Dependencies) *Handler {
-	deps.Load()
+const (
+	// expirySweepInterval is how often expired memberships are cleaned up.
+	expirySweepInterval = time.Minute
+	// deletionPollInterval is how often queued organization deletions are
+	// picked up.
+	deletionPollInterval = 5 * time.Second
+)
 
-	repo := orgStorage.NewRepository(deps.DB)
-	service := orgService.NewService(repo)
+func InitHandler(deps *common.Dependencies) (*Handler, error) {
+	svc, err := newService(deps)
+	if err != nil {
+		return nil, err
+	}
+	return NewHandler(svc, deps.DB, auth.NewSessions(deps.TokenSecret, nil), auth.NewChallenges(deps.TokenSecret), deps.Environment == common.EnvProduction), nil
+}
+
+// StartJobs starts the organization background jobs. They stop when ctx is
+// cancelled.
+func StartJobs(ctx context.Context, deps *common.Dependencies) error {
+	svc, err := newService(deps)
+	if err != nil {
+		return err
+	}
+	svc.StartExpirySweeper(ctx, expirySweepInterval)
+	svc.StartDeletionWorker(ctx, deletionPollInterval)
+	return nil
+}
+
+// DomainAutoJoiner returns the listener that adds users who verified their
+// email to the organization owning its domain.
+func DomainAutoJoiner(deps *common.Dependencies) (service.EmailVerifiedListener, error) {
+	return newService(deps)
+}
 
-	return NewHandler(service, deps.DB)
+func newService(deps *common.Dependencies) (*orgService.Service, error) {
+	if err := deps.Load(); err != nil {
+		return nil, err
+	}
+
+	repo := orgStorage.NewRepository(deps.DB)
+	usersRepo := userStorage.NewRepository(deps.DB)
+	return orgService.NewService(repo, usersRepo, deps.Mailer, deps.Verifier, deps.OIDC, deps.TokenSecret, deps.Environment), nil
 }
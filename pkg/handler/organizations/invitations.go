@@ -0,0 +1,169 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateInvitation invites an email address to join an organization.
+func (h *Handler) CreateInvitation(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inviterID, _ := currentUserID(c)
+	invitation, err := h.orgService.InviteUser(c.Request.Context(), orgID, inviterID, req.Email, req.Permission)
+	if err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toInvitationResponse(*invitation))
+}
+
+func (h *Handler) ListInvitations(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.orgService.ListPendingInvitations(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]dto.InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		response = append(response, toInvitationResponse(invitation))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	orgID, invitationID, ok := h.parseInvitationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.RevokeInvitation(c.Request.Context(), orgID, invitationID); err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invitation revoked"})
+}
+
+func (h *Handler) ResendInvitation(c *gin.Context) {
+	orgID, invitationID, ok := h.parseInvitationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.ResendInvitation(c.Request.Context(), orgID, invitationID); err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invitation resent"})
+}
+
+// AcceptInvitation redeems an invitation token for the authenticated user.
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	var req dto.InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.orgService.AcceptInvitation(c.Request.Context(), req.Token, userID)
+	if err != nil {
+		respondError(c, invitationErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toInvitationResponse(*invitation))
+}
+
+func (h *Handler) DeclineInvitation(c *gin.Context) {
+	var req dto.InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.orgService.DeclineInvitation(c.Request.Context(), req.Token); err != nil {
+		c.JSON(invitationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invitation declined"})
+}
+
+func (h *Handler) parseInvitationParams(c *gin.Context) (uint, uint, bool) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	invitationID, err := strconv.ParseUint(c.Param("invitationId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation id"})
+		return 0, 0, false
+	}
+
+	return orgID, uint(invitationID), true
+}
+
+func invitationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, orgService.ErrInvitationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orgService.ErrInvitationInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, orgService.ErrInvitationExpired):
+		return http.StatusGone
+	case errors.Is(err, orgService.ErrInvitationEmailMismatch):
+		return http.StatusForbidden
+	case errors.Is(err, orgService.ErrAlreadyMember), errors.Is(err, orgService.ErrInvitationPending):
+		return http.StatusConflict
+	case errors.Is(err, orgService.ErrInvitationResendLimit), errors.Is(err, orgService.ErrInvitationResendTooSoon):
+		return http.StatusTooManyRequests
+	default:
+		return orgErrorStatus(err, http.StatusUnprocessableEntity)
+	}
+}
+
+func toInvitationResponse(invitation orgService.InvitationDTO) dto.InvitationResponse {
+	return dto.InvitationResponse{
+		ID:         invitation.ID,
+		OrgID:      invitation.OrgID,
+		Email:      invitation.Email,
+		Permission: invitation.Permission,
+		Status:     invitation.Status,
+		InvitedBy:  invitation.InvitedBy,
+		ExpiresAt:  invitation.ExpiresAt,
+		CreatedAt:  invitation.CreatedAt,
+	}
+}
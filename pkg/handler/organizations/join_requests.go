@@ -0,0 +1,155 @@
+package organizations
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateJoinRequest lets the authenticated user ask to join an organization.
+func (h *Handler) CreateJoinRequest(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	var req dto.CreateJoinRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.orgService.RequestToJoin(c.Request.Context(), orgID, userID, req.Message)
+	if err != nil {
+		c.JSON(joinRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toJoinRequestResponse(*request))
+}
+
+// ListJoinRequests lists the join requests of an organization; ?status=
+// restricts them to PENDING, APPROVED or REJECTED.
+func (h *Handler) ListJoinRequests(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	requests, err := h.orgService.ListJoinRequests(c.Request.Context(), orgID, c.Query("status"))
+	if err != nil {
+		c.JSON(joinRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]dto.JoinRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		response = append(response, toJoinRequestResponse(request))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ApproveJoinRequest adds the applicant with the permission chosen by the
+// approver.
+func (h *Handler) ApproveJoinRequest(c *gin.Context) {
+	orgID, requestID, ok := h.parseJoinRequestParams(c)
+	if !ok {
+		return
+	}
+
+	deciderID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	var req dto.ApproveJoinRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.orgService.ApproveJoinRequest(c.Request.Context(), orgID, requestID, deciderID, req.Permission)
+	if err != nil {
+		respondError(c, joinRequestErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toJoinRequestResponse(*request))
+}
+
+func (h *Handler) RejectJoinRequest(c *gin.Context) {
+	orgID, requestID, ok := h.parseJoinRequestParams(c)
+	if !ok {
+		return
+	}
+
+	deciderID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	request, err := h.orgService.RejectJoinRequest(c.Request.Context(), orgID, requestID, deciderID)
+	if err != nil {
+		c.JSON(joinRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toJoinRequestResponse(*request))
+}
+
+func (h *Handler) parseJoinRequestParams(c *gin.Context) (uint, uint, bool) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	requestID, err := strconv.ParseUint(c.Param("requestId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid join request id"})
+		return 0, 0, false
+	}
+
+	return orgID, uint(requestID), true
+}
+
+func joinRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orgService.ErrJoinRequestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orgService.ErrJoinRequestsDisabled):
+		return http.StatusForbidden
+	case errors.Is(err, orgService.ErrAlreadyMember), errors.Is(err, orgService.ErrJoinRequestPending):
+		return http.StatusConflict
+	default:
+		return orgErrorStatus(err, http.StatusUnprocessableEntity)
+	}
+}
+
+func toJoinRequestResponse(request orgService.JoinRequestDTO) dto.JoinRequestResponse {
+	return dto.JoinRequestResponse{
+		ID:         request.ID,
+		OrgID:      request.OrgID,
+		UserID:     request.UserID,
+		Message:    request.Message,
+		Status:     request.Status,
+		Permission: request.Permission,
+		DecidedBy:  request.DecidedBy,
+		DecidedAt:  request.DecidedAt,
+		CreatedAt:  request.CreatedAt,
+	}
+}
@@ -0,0 +1,138 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateRole defines a custom role for an organization.
+func (h *Handler) CreateRole(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, _ := currentUserID(c)
+	role, err := h.orgService.CreateRole(c.Request.Context(), orgID, actorID, req.Name, toCapabilities(req.Capabilities))
+	if err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toRoleResponse(*role))
+}
+
+func (h *Handler) ListRoles(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.orgService.ListRoles(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]dto.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, toRoleResponse(role))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) UpdateRole(c *gin.Context) {
+	orgID, roleID, ok := h.parseRoleParams(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, _ := currentUserID(c)
+	if err := h.orgService.UpdateRole(c.Request.Context(), orgID, actorID, roleID, req.Name, toCapabilities(req.Capabilities)); err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role updated successfully"})
+}
+
+func (h *Handler) DeleteRole(c *gin.Context) {
+	orgID, roleID, ok := h.parseRoleParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.DeleteRole(c.Request.Context(), orgID, roleID); err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted successfully"})
+}
+
+func (h *Handler) parseRoleParams(c *gin.Context) (uint, uint, bool) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	roleID, err := strconv.ParseUint(c.Param("roleId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return 0, 0, false
+	}
+
+	return orgID, uint(roleID), true
+}
+
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orgService.ErrRoleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orgService.ErrRoleInUse), errors.Is(err, orgService.ErrSystemRole):
+		return http.StatusConflict
+	default:
+		return orgErrorStatus(err, http.StatusUnprocessableEntity)
+	}
+}
+
+func toCapabilities(values []string) []orgService.Capability {
+	capabilities := make([]orgService.Capability, 0, len(values))
+	for _, value := range values {
+		capabilities = append(capabilities, orgService.Capability(value))
+	}
+	return capabilities
+}
+
+func toRoleResponse(role orgService.RoleDTO) dto.RoleResponse {
+	capabilities := make([]string, 0, len(role.Capabilities))
+	for _, capability := range role.Capabilities {
+		capabilities = append(capabilities, string(capability))
+	}
+	return dto.RoleResponse{
+		ID:           role.ID,
+		OrgID:        role.OrgID,
+		Name:         role.Name,
+		Capabilities: capabilities,
+		System:       role.System,
+	}
+}
@@ -0,0 +1,54 @@
+package organizations
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSettings returns the effective settings of an organization.
+func (h *Handler) GetSettings(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.orgService.GetSettings(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(orgErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings changes some settings of an organization. Keys left out of
+// the body keep their value; a null value resets a setting to its default.
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.orgService.UpdateSettings(c.Request.Context(), orgID, patch)
+	if err != nil {
+		status := orgErrorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, orgService.ErrUnknownSetting) || errors.Is(err, orgService.ErrInvalidSetting) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,219 @@
+package organizations
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/auth"
+	"meu-treino-golang/users-crud/internal/oidc"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ssoStateCookie holds the hash of the state of the sign-in the browser
+	// started, which the callback must match.
+	ssoStateCookie = "sso_state"
+	ssoCookiePath  = "/api/auth/sso/"
+)
+
+// SessionIssuer signs the session tokens returned by a completed sign-in.
+type SessionIssuer interface {
+	Issue(userID uint, ttl time.Duration) (string, time.Time, error)
+}
+
+// ChallengeIssuer signs the tokens that carry a sign-in to the second
+// factor.
+type ChallengeIssuer interface {
+	Issue(userID uint, ttl time.Duration) (string, time.Time, error)
+}
+
+func (h *Handler) GetSSOConfig(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	config, err := h.orgService.GetSSOConfig(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toSSOConfigResponse(*config))
+}
+
+// SetSSOConfig creates or replaces the organization's identity provider.
+func (h *Handler) SetSSOConfig(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SSOConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enabled := req.Enabled == nil || *req.Enabled
+	config, err := h.orgService.SetSSOConfig(c.Request.Context(), orgID, orgService.SSOConfigInput{
+		Issuer:           req.Issuer,
+		ClientID:         req.ClientID,
+		ClientSecret:     req.ClientSecret,
+		RedirectURL:      req.RedirectURL,
+		GroupsClaim:      req.GroupsClaim,
+		GroupPermissions: req.GroupPermissions,
+		Enabled:          enabled,
+	})
+	if err != nil {
+		c.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toSSOConfigResponse(*config))
+}
+
+func (h *Handler) DeleteSSOConfig(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.DeleteSSOConfig(c.Request.Context(), orgID); err != nil {
+		c.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "single sign-on removed"})
+}
+
+// StartSSOLogin redirects the browser to the organization's identity
+// provider, and binds the sign-in to it with the state cookie.
+func (h *Handler) StartSSOLogin(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	authURL, binding, err := h.orgService.StartSSOLogin(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.setSSOStateCookie(c, binding, int(orgService.SSOLoginTTL.Seconds()))
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// SSOCallback completes a sign-in when the identity provider redirects
+// back, and answers like password login: with a session token, or with a
+// challenge when the user has two-factor authentication.
+func (h *Handler) SSOCallback(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	binding, _ := c.Cookie(ssoStateCookie)
+	h.setSSOStateCookie(c, "", -1)
+
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "identity provider refused the sign-in: " + providerError})
+		return
+	}
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state and code are required"})
+		return
+	}
+
+	user, err := h.orgService.FinishSSOLogin(c.Request.Context(), orgID, state, binding, code)
+	if err != nil {
+		if errors.Is(err, oidc.ErrProvider) || errors.Is(err, oidc.ErrInvalidIDToken) {
+			log.Printf("sso: sign-in to organization %d failed: %v", orgID, err)
+		}
+		c.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if user.TwoFactorEnabled {
+		token, expiresAt, err := h.challenges.Issue(user.ID, auth.ChallengeTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, dto.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    token,
+			ExpiresAt:         expiresAt,
+		})
+		return
+	}
+
+	token, expiresAt, err := h.sessions.Issue(user.ID, auth.SessionTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dto.LoginResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: expiresAt,
+		UserID:    user.ID,
+	})
+}
+
+// setSSOStateCookie stores binding for maxAge seconds; a negative maxAge
+// deletes the cookie.
+func (h *Handler) setSSOStateCookie(c *gin.Context, binding string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    binding,
+		Path:     ssoCookiePath,
+		MaxAge:   maxAge,
+		Secure:   h.secureCookies,
+		HttpOnly: true,
+		// Lax, not Strict: the identity provider sends the browser back
+		// with a cross-site redirect.
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func ssoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orgService.ErrSSONotConfigured):
+		return http.StatusNotFound
+	case errors.Is(err, orgService.ErrInvalidSSOConfig):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, orgService.ErrSSOLoginExpired), errors.Is(err, orgService.ErrSSOStateMismatch), errors.Is(err, orgService.ErrSSOEmailNotVerified),
+		errors.Is(err, orgService.ErrSSODomainNotOwned), errors.Is(err, oidc.ErrInvalidIDToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, orgService.ErrSSOAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, oidc.ErrProvider):
+		return http.StatusBadGateway
+	default:
+		return orgErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+func toSSOConfigResponse(config orgService.SSOConfigDTO) dto.SSOConfigResponse {
+	return dto.SSOConfigResponse{
+		OrgID:            config.OrgID,
+		Issuer:           config.Issuer,
+		ClientID:         config.ClientID,
+		ClientSecretSet:  config.ClientSecretSet,
+		RedirectURL:      config.RedirectURL,
+		GroupsClaim:      config.GroupsClaim,
+		GroupPermissions: config.GroupPermissions,
+		Enabled:          config.Enabled,
+		UpdatedAt:        config.UpdatedAt,
+	}
+}
@@ -0,0 +1,182 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTeam creates a team inside an organization.
+func (h *Handler) CreateTeam(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, _ := currentUserID(c)
+	team, err := h.orgService.CreateTeam(c.Request.Context(), orgID, actorID, req.Name, req.Permission)
+	if err != nil {
+		respondError(c, teamErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toTeamResponse(*team))
+}
+
+func (h *Handler) ListTeams(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	teams, err := h.orgService.ListTeams(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]dto.TeamResponse, 0, len(teams))
+	for _, team := range teams {
+		response = append(response, toTeamResponse(team))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) GetTeam(c *gin.Context) {
+	orgID, teamID, ok := h.parseTeamParams(c)
+	if !ok {
+		return
+	}
+
+	team, err := h.orgService.GetTeam(c.Request.Context(), orgID, teamID)
+	if err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toTeamResponse(*team))
+}
+
+func (h *Handler) UpdateTeam(c *gin.Context) {
+	orgID, teamID, ok := h.parseTeamParams(c)
+	if !ok {
+		return
+	}
+
+	var req dto.TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, _ := currentUserID(c)
+	if err := h.orgService.UpdateTeam(c.Request.Context(), orgID, actorID, teamID, req.Name, req.Permission); err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "team updated successfully"})
+}
+
+func (h *Handler) DeleteTeam(c *gin.Context) {
+	orgID, teamID, ok := h.parseTeamParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.DeleteTeam(c.Request.Context(), orgID, teamID); err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "team deleted successfully"})
+}
+
+// AddTeamMember adds a user to a team.
+func (h *Handler) AddTeamMember(c *gin.Context) {
+	orgID, teamID, ok := h.parseTeamParams(c)
+	if !ok {
+		return
+	}
+
+	var req dto.AddTeamMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID, _ := currentUserID(c)
+	if err := h.orgService.AddTeamMember(c.Request.Context(), orgID, actorID, teamID, req.UserID); err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "user added to team"})
+}
+
+func (h *Handler) RemoveTeamMember(c *gin.Context) {
+	orgID, teamID, ok := h.parseTeamParams(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.orgService.RemoveTeamMember(c.Request.Context(), orgID, teamID, uint(userID)); err != nil {
+		c.JSON(teamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user removed from team"})
+}
+
+func (h *Handler) parseTeamParams(c *gin.Context) (uint, uint, bool) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	teamID, err := strconv.ParseUint(c.Param("teamId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team id"})
+		return 0, 0, false
+	}
+
+	return orgID, uint(teamID), true
+}
+
+func teamErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orgService.ErrTeamNotFound), errors.Is(err, common.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return orgErrorStatus(err, http.StatusUnprocessableEntity)
+	}
+}
+
+func toTeamResponse(team orgService.TeamDTO) dto.TeamResponse {
+	return dto.TeamResponse{
+		ID:         team.ID,
+		OrgID:      team.OrgID,
+		Name:       team.Name,
+		Permission: team.Permission,
+		MemberIDs:  team.MemberIDs,
+	}
+}
@@ -0,0 +1,51 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUsage reports how much of its plan an organization consumes.
+func (h *Handler) GetUsage(c *gin.Context) {
+	orgID, ok := h.parseOrgID(c)
+	if !ok {
+		return
+	}
+
+	usage, err := h.orgService.GetUsage(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(orgErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toUsageResponse(*usage))
+}
+
+// respondError writes err with status. Quota errors also carry the usage of
+// the organization so clients can tell which limit was hit.
+func respondError(c *gin.Context, status int, err error) {
+	var quotaErr *orgService.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		c.JSON(status, gin.H{
+			"error":    err.Error(),
+			"resource": quotaErr.Resource,
+			"usage":    toUsageResponse(quotaErr.Usage),
+		})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+func toUsageResponse(usage orgService.Usage) dto.UsageResponse {
+	return dto.UsageResponse{
+		Plan:    usage.Plan,
+		Members: dto.ResourceUsageResponse{Used: usage.Members.Used, Limit: usage.Members.Limit},
+		Roots:   dto.ResourceUsageResponse{Used: usage.Roots.Used, Limit: usage.Roots.Limit},
+		Teams:   dto.ResourceUsageResponse{Used: usage.Teams.Used, Limit: usage.Teams.Limit},
+	}
+}
@@ -0,0 +1,104 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListMyOrgs lists the organizations the authenticated user belongs to.
+func (h *Handler) ListMyOrgs(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	h.listUserOrgs(c, userID)
+}
+
+// ListUserOrgs lists the organizations of a given user. Users may only list
+// their own memberships.
+func (h *Handler) ListUserOrgs(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if callerID, _ := currentUserID(c); callerID != uint(userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	h.listUserOrgs(c, uint(userID))
+}
+
+func (h *Handler) listUserOrgs(c *gin.Context, userID uint) {
+	page, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	orgs, page, err := h.orgService.ListUserOrgs(
+		c.Request.Context(),
+		userID,
+		dto.PermissionType(c.Query("min_permission")),
+		page,
+	)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, orgService.ErrInvalidPermission) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]dto.UserOrgResponse, 0, len(orgs))
+	for _, org := range orgs {
+		response = append(response, dto.UserOrgResponse{
+			ID:         org.OrgID,
+			Name:       org.Name,
+			Slug:       org.Slug,
+			Permission: org.Permission,
+			RoleID:     org.RoleID,
+			Role:       org.RoleName,
+			ExpiresAt:  org.ExpiresAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response, "pagination": page})
+}
+
+// parsePagination reads the page and limit query parameters.
+func parsePagination(c *gin.Context) (common.Pagination, bool) {
+	var page common.Pagination
+
+	if value := c.Query("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+			return page, false
+		}
+		page.Page = parsed
+	}
+
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return page, false
+		}
+		page.Limit = parsed
+	}
+
+	page.Normalize()
+	return page, true
+}
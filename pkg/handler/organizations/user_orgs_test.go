@@ -0,0 +1,56 @@
+package organizations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/common"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubUserOrgs answers ListUserOrgs and records whose organizations were
+// listed. Every other method panics through the nil embedded interface.
+type stubUserOrgs struct {
+	orgService.IOrganizationService
+	listed []uint
+}
+
+func (s *stubUserOrgs) ListUserOrgs(ctx context.Context, userID uint, minPermission dto.PermissionType, page common.Pagination) ([]orgService.UserOrgDTO, common.Pagination, error) {
+	s.listed = append(s.listed, userID)
+	return nil, page, nil
+}
+
+func TestListUserOrgs_OnlyOwnMemberships(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := &stubUserOrgs{}
+	handler := NewHandler(service, nil, nil, nil, false)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("userID", uint(1)) })
+	router.GET("/api/users/:id/orgs", handler.ListUserOrgs)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/users/1/orgs", http.StatusOK},
+		{"/api/users/2/orgs", http.StatusForbidden},
+		{"/api/users/abc/orgs", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, recorder.Code, tt.want)
+		}
+	}
+
+	if len(service.listed) != 1 || service.listed[0] != 1 {
+		t.Fatalf("listed = %v, want only user 1", service.listed)
+	}
+}
@@ -1,22 +1,50 @@
 package users
 
 import (
+	"errors"
+	"expvar"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"meu-treino-golang/users-crud/dto"
+	"meu-treino-golang/users-crud/internal/auth"
 	"meu-treino-golang/users-crud/internal/common"
 	"meu-treino-golang/users-crud/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ImpersonationIssuer signs tokens that let actorID act as subjectID.
+type ImpersonationIssuer interface {
+	Issue(actorID, subjectID uint, ttl time.Duration) (string, time.Time, error)
+}
+
+// SessionIssuer signs the session tokens returned by login.
+type SessionIssuer interface {
+	Issue(userID uint, ttl time.Duration) (string, time.Time, error)
+}
+
+// ChallengeIssuer signs and checks the tokens that carry a login from the
+// password to the second factor.
+type ChallengeIssuer interface {
+	Issue(userID uint, ttl time.Duration) (string, time.Time, error)
+	Verify(token string) (uint, error)
+}
+
 type Handler struct {
-	service service.IUserService
+	service       service.IUserService
+	login         service.ILoginService
+	verification  service.IEmailVerificationService
+	sessions      SessionIssuer
+	challenges    ChallengeIssuer
+	impersonation ImpersonationIssuer
 }
 
-func NewHandler(svc service.IUserService) *Handler {
-	return &Handler{service: svc}
+func NewHandler(svc service.IUserService, login service.ILoginService, verification service.IEmailVerificationService, sessions SessionIssuer, challenges ChallengeIssuer, impersonation ImpersonationIssuer) *Handler {
+	return &Handler{service: svc, login: login, verification: verification, sessions: sessions, challenges: challenges, impersonation: impersonation}
 }
 
 func (h *Handler) Create(c *gin.Context) {
@@ -26,7 +54,7 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	id, err := h.service.CreateUser(c.Request.Context(), req.Name, req.Email)
+	id, err := h.service.CreateUser(c.Request.Context(), req.Name, req.Email, req.Password)
 	if err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
@@ -73,11 +101,292 @@ func (h *Handler) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// Impersonate issues a short-lived token that lets the calling platform
+// admin see the API as another user.
+func (h *Handler) Impersonate(c *gin.Context) {
+	subjectID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	actorID := c.GetUint("userID")
+
+	var token string
+	var expiresAt time.Time
+	subject, err := h.service.Impersonate(c.Request.Context(), actorID, uint(subjectID), func(subject *service.UserDTO) (err error) {
+		token, expiresAt, err = h.impersonation.Issue(actorID, subject.ID, auth.ImpersonationTTL)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, common.ErrImpersonateSelf), errors.Is(err, common.ErrImpersonateAdmin):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	log.Printf("impersonation: user %d started acting as user %d until %s", actorID, subject.ID, expiresAt.Format(time.RFC3339))
+	c.JSON(http.StatusCreated, dto.ImpersonationResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: expiresAt,
+		ActorID:   actorID,
+		SubjectID: subject.ID,
+	})
+}
+
+// Login exchanges an email and password for a session token. Unknown
+// emails and wrong passwords get the same answer, and so do their lockouts.
+// Users with two-factor authentication get a challenge for SecondFactor
+// instead.
+func (h *Handler) Login(c *gin.Context) {
+	var req dto.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.login.Login(c.Request.Context(), req.Email, req.Password, c.ClientIP())
+	if err != nil {
+		respondLoginError(c, err, "invalid email or password")
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		token, expiresAt, err := h.challenges.Issue(user.ID, auth.ChallengeTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, dto.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    token,
+			ExpiresAt:         expiresAt,
+		})
+		return
+	}
+
+	h.startSession(c, user.ID)
+}
+
+// SecondFactor completes a login started with a password.
+func (h *Handler) SecondFactor(c *gin.Context) {
+	var req dto.SecondFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := h.challenges.Verify(req.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired challenge"})
+		return
+	}
+
+	user, err := h.login.VerifySecondFactor(c.Request.Context(), userID, req.Code, c.ClientIP())
+	if err != nil {
+		respondLoginError(c, err, common.ErrInvalidTwoFactor.Error())
+		return
+	}
+
+	h.startSession(c, user.ID)
+}
+
+func respondLoginError(c *gin.Context, err error, invalid string) {
+	var throttled *common.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": common.ErrLoginThrottled.Error()})
+	case errors.Is(err, common.ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": invalid})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// startSession answers a completed login with a session token.
+func (h *Handler) startSession(c *gin.Context, userID uint) {
+	token, expiresAt, err := h.sessions.Issue(userID, auth.SessionTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.LoginResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: expiresAt,
+		UserID:    userID,
+	})
+}
+
+// EnrollTwoFactor starts a TOTP enrollment for the caller.
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	secret, uri, err := h.login.EnrollTwoFactor(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.TwoFactorEnrollmentResponse{Secret: secret, ProvisioningURI: uri})
+}
+
+// ConfirmTwoFactor turns on two-factor authentication with a first code
+// and returns the recovery codes.
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.login.ConfirmTwoFactor(c.Request.Context(), c.GetUint("userID"), req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns two-factor authentication off for the caller.
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.login.DisableTwoFactor(c.Request.Context(), c.GetUint("userID"), req.Code); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, common.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, common.ErrTwoFactorEnabled), errors.Is(err, common.ErrTwoFactorNotEnabled), errors.Is(err, common.ErrTwoFactorNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, common.ErrInvalidTwoFactor):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ChangePassword sets the caller's password.
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.login.ChangePassword(c.Request.Context(), c.GetUint("userID"), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidCredentials):
+			c.JSON(http.StatusForbidden, gin.H{"error": "current password is incorrect"})
+		case errors.Is(err, common.ErrInvalidInput):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, common.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyEmail confirms the email of the user a mailed token was sent to.
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.verification.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, common.ErrInvalidEmailVerification) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.UserResponse{ID: user.ID, Name: user.Name, Email: user.Email})
+}
+
+// ResendEmailVerification mails the caller a new confirmation token.
+func (h *Handler) ResendEmailVerification(c *gin.Context) {
+	if err := h.verification.SendVerification(c.Request.Context(), c.GetUint("userID")); err != nil {
+		switch {
+		case errors.Is(err, common.ErrEmailAlreadyVerified):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, common.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// Unlock lifts the login lockout of a user's account.
+func (h *Handler) Unlock(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.login.Unlock(c.Request.Context(), uint(userID)); err != nil {
+		if errors.Is(err, common.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("login: user %d unlocked the account of user %d", c.GetUint("userID"), userID)
+	c.Status(http.StatusNoContent)
+}
+
 func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	usersGroup := router.Group("/api/users")
 	{
 		usersGroup.POST("", h.Create)
 		usersGroup.GET("", h.List)
 		usersGroup.GET("/:id", h.Get)
+		usersGroup.POST("/verify-email", h.VerifyEmail)
 	}
+
+	router.POST("/api/auth/login", h.Login)
+	router.POST("/api/auth/login/2fa", h.SecondFactor)
+	router.PUT("/api/me/password", h.ChangePassword)
+	router.POST("/api/me/email/verification", h.ResendEmailVerification)
+	router.POST("/api/me/2fa/enroll", h.EnrollTwoFactor)
+	router.POST("/api/me/2fa/confirm", h.ConfirmTwoFactor)
+	router.POST("/api/me/2fa/disable", h.DisableTwoFactor)
+
+	router.POST("/api/admin/impersonate/:userId", h.Impersonate)
+	router.POST("/api/admin/users/:userId/unlock", h.Unlock)
+	// Counters published with expvar, including the login security metrics.
+	router.GET("/api/admin/metrics", gin.WrapH(expvar.Handler()))
 }
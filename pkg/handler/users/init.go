@@ -1,16 +1,25 @@
 package users
 
 import (
+	"meu-treino-golang/users-crud/internal/auth"
 	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/service"
 	userService "meu-treino-golang/users-crud/internal/service/domain/users"
 	userStorage "meu-treino-golang/users-crud/internal/storage/postgres/users"
 )
 
-func InitHandler(deps *common.Dependencies) *Handler {
-	deps.Load()
+// InitHandler wires the users handler. listeners are notified of every
+// email verified through it.
+func InitHandler(deps *common.Dependencies, listeners ...service.EmailVerifiedListener) (*Handler, error) {
+	if err := deps.Load(); err != nil {
+		return nil, err
+	}
 
 	repo := userStorage.NewRepository(deps.DB)
-	svc := userService.NewService(repo)
+	verification := userService.NewEmailVerificationService(repo, repo, deps.Mailer, listeners...)
+	svc := userService.NewService(repo, repo, verification)
 
-	return NewHandler(svc)
+	login := userService.NewLoginService(repo, repo, repo, repo, deps.Mailer)
+
+	return NewHandler(svc, login, verification, auth.NewSessions(deps.TokenSecret, nil), auth.NewChallenges(deps.TokenSecret), auth.NewImpersonation(deps.TokenSecret, nil)), nil
 }
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"meu-treino-golang/users-crud/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate identifies the caller with authenticator. The identity is
+// stored in the gin context under "userID" and in the request context.
+// Requests without credentials continue anonymously; bad credentials are
+// rejected with 401. Impersonated requests are logged with both identities.
+func Authenticate(authenticator common.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := authenticator.Authenticate(c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": common.ErrInvalidCredentials.Error()})
+			return
+		}
+
+		if identity == nil {
+			c.Next()
+			return
+		}
+
+		c.Set("userID", identity.UserID)
+		c.Request = c.Request.WithContext(common.WithIdentity(c.Request.Context(), *identity))
+		c.Next()
+
+		if identity.Impersonated() {
+			log.Printf("impersonation: user %d as user %d: %s %s -> %d",
+				identity.ActorID, identity.UserID, c.Request.Method, c.Request.URL.Path, c.Writer.Status())
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/auth"
+	"meu-treino-golang/users-crud/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Authenticate(auth.HeaderAuthenticator{}))
+	router.GET("/", func(c *gin.Context) {
+		identity, ok := common.IdentityFrom(c.Request.Context())
+		if !ok {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		if userID, _ := c.Get("userID"); userID != identity.UserID {
+			t.Errorf("gin context has %v, request context has %d", userID, identity.UserID)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	cases := map[string]int{
+		"":      http.StatusNoContent,
+		"5":     http.StatusOK,
+		"bogus": http.StatusUnauthorized,
+	}
+	for header, want := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			r.Header.Set("X-User-ID", header)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+		if recorder.Code != want {
+			t.Fatalf("X-User-ID %q: status = %d, want %d", header, recorder.Code, want)
+		}
+	}
+}
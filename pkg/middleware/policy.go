@@ -0,0 +1,186 @@
+// Package middleware holds the gin middleware shared by every handler.
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"meu-treino-golang/users-crud/internal/common"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Access says who may call a route before any handler runs.
+type Access int
+
+const (
+	// AccessPublic lets anyone call the route.
+	AccessPublic Access = iota
+	// AccessAuthenticated requires an identity in the context.
+	AccessAuthenticated
+	// AccessOrg requires an identity holding every listed capability in the
+	// organization named by the :orgId path parameter.
+	AccessOrg
+	// AccessPlatformAdmin requires a platform admin. Every call is logged.
+	AccessPlatformAdmin
+)
+
+// Policy is the authorization rule of one route.
+type Policy struct {
+	Access       Access
+	Capabilities []orgService.Capability
+	// Destructive routes are refused to impersonated requests.
+	Destructive bool
+}
+
+func Public() Policy {
+	return Policy{Access: AccessPublic}
+}
+
+func Authenticated() Policy {
+	return Policy{Access: AccessAuthenticated}
+}
+
+// Org requires the capabilities in the organization the route targets.
+func Org(capabilities ...orgService.Capability) Policy {
+	return Policy{Access: AccessOrg, Capabilities: capabilities}
+}
+
+func PlatformAdmin() Policy {
+	return Policy{Access: AccessPlatformAdmin}
+}
+
+// Destructive marks a policy's route as one that impersonation may not use.
+func Destructive(policy Policy) Policy {
+	policy.Destructive = true
+	return policy
+}
+
+// Policies maps "METHOD /path", with the path as registered in gin, to the
+// policy of that route.
+type Policies map[string]Policy
+
+// Authorizer resolves the organization a request targets and checks
+// capabilities in it. ResolveOrg writes the error response when it fails;
+// Authorize returns nil when the caller holds the capability.
+type Authorizer interface {
+	ResolveOrg(c *gin.Context) (uint, bool)
+	Authorize(c *gin.Context, orgID uint, capability orgService.Capability) error
+	IsPlatformAdmin(c *gin.Context) bool
+}
+
+// Check reports routes registered without a policy and policies that name
+// no registered route. The application refuses to start when it fails.
+func (p Policies) Check(routes gin.RoutesInfo) error {
+	registered := make(map[string]bool, len(routes))
+	var problems []string
+	for _, route := range routes {
+		key := routeKey(route.Method, route.Path)
+		registered[key] = true
+		if _, ok := p[key]; !ok {
+			problems = append(problems, "no policy for "+key)
+		}
+	}
+	for key, policy := range p {
+		if !registered[key] {
+			problems = append(problems, "policy for unregistered route "+key)
+		}
+		if policy.Access == AccessOrg && len(policy.Capabilities) == 0 {
+			problems = append(problems, "no capabilities in organization policy for "+key)
+		}
+		for _, capability := range policy.Capabilities {
+			if !orgService.IsKnownCapability(capability) {
+				problems = append(problems, fmt.Sprintf("unknown capability %q for %s", capability, key))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("route policies: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Enforce applies the policy of the matched route. A route without a policy
+// is denied; Check keeps that from happening outside of tests.
+func Enforce(policies Policies, authz Authorizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Unmatched requests fall through to gin's 404 and 405 handling.
+		if c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		policy, ok := policies[routeKey(c.Request.Method, c.FullPath())]
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			return
+		}
+
+		if identity, ok := common.IdentityFrom(c.Request.Context()); ok && identity.Impersonated() && policy.Destructive {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": common.ErrImpersonationDenied.Error()})
+			return
+		}
+
+		switch policy.Access {
+		case AccessPublic:
+		case AccessAuthenticated:
+			if !authenticated(c) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+				return
+			}
+		case AccessOrg:
+			if !authenticated(c) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+				return
+			}
+			orgID, ok := authz.ResolveOrg(c)
+			if !ok {
+				c.Abort()
+				return
+			}
+			for _, capability := range policy.Capabilities {
+				if err := authz.Authorize(c, orgID, capability); err != nil {
+					message := "insufficient permissions"
+					// Tell members what to do rather than that they lack access.
+					if errors.Is(err, orgService.ErrTwoFactorRequired) {
+						message = err.Error()
+					}
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": message})
+					return
+				}
+			}
+		case AccessPlatformAdmin:
+			if !authenticated(c) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+				return
+			}
+			if !authz.IsPlatformAdmin(c) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+				return
+			}
+			log.Printf("platform admin: user %v called %s %s", c.MustGet("userID"), c.Request.Method, c.Request.URL.Path)
+		}
+
+		c.Next()
+	}
+}
+
+func authenticated(c *gin.Context) bool {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return false
+	}
+	id, ok := userID.(uint)
+	return ok && id != 0
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/common"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubOrgs grants the capabilities in granted and resolves every org to 1.
+// When err is set, every check fails with it.
+type stubOrgs struct {
+	granted []orgService.Capability
+	checked []orgService.Capability
+	admin   bool
+	err     error
+}
+
+func (s *stubOrgs) ResolveOrg(c *gin.Context) (uint, bool) {
+	return 1, true
+}
+
+func (s *stubOrgs) Authorize(c *gin.Context, orgID uint, capability orgService.Capability) error {
+	s.checked = append(s.checked, capability)
+	if s.err != nil {
+		return s.err
+	}
+	if !orgService.Evaluate(s.granted, capability) {
+		return orgService.ErrForbidden
+	}
+	return nil
+}
+
+func (s *stubOrgs) IsPlatformAdmin(c *gin.Context) bool {
+	return s.admin
+}
+
+func newRouter(policies Policies, orgs Authorizer, userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if userID != 0 {
+		router.Use(func(c *gin.Context) { c.Set("userID", userID) })
+	}
+	router.Use(Enforce(policies, orgs))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/public", ok)
+	router.GET("/me", ok)
+	router.PUT("/org/:orgId", ok)
+	router.GET("/unlisted", ok)
+	router.GET("/admin", ok)
+	router.DELETE("/org/:orgId", ok)
+	return router
+}
+
+func serve(router *gin.Engine, method, path string) int {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder.Code
+}
+
+var testPolicies = Policies{
+	"GET /public":        Public(),
+	"GET /me":            Authenticated(),
+	"PUT /org/:orgId":    Org(orgService.CapOrgUpdate, orgService.CapOrgArchive),
+	"GET /admin":         PlatformAdmin(),
+	"DELETE /org/:orgId": Destructive(Org(orgService.CapOrgDelete)),
+}
+
+func TestCheck_ReportsMissingAndStalePolicies(t *testing.T) {
+	router := newRouter(testPolicies, &stubOrgs{}, 0)
+
+	policies := Policies{"DELETE /gone": Public()}
+	for key, policy := range testPolicies {
+		policies[key] = policy
+	}
+
+	err := policies.Check(router.Routes())
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{"no policy for GET /unlisted", "policy for unregistered route DELETE /gone"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestCheck_RejectsEmptyAndUnknownCapabilities(t *testing.T) {
+	router := gin.New()
+	router.GET("/a", func(c *gin.Context) {})
+	router.GET("/b", func(c *gin.Context) {})
+
+	err := Policies{"GET /a": Org(), "GET /b": Org("org.unknown")}.Check(router.Routes())
+	if err == nil || !strings.Contains(err.Error(), "GET /a") || !strings.Contains(err.Error(), "GET /b") {
+		t.Fatalf("expected both policies to be rejected, got %v", err)
+	}
+}
+
+func TestEnforce(t *testing.T) {
+	cases := []struct {
+		name    string
+		userID  uint
+		granted []orgService.Capability
+		admin   bool
+		method  string
+		path    string
+		want    int
+	}{
+		{"public", 0, nil, false, http.MethodGet, "/public", http.StatusOK},
+		{"authenticated without identity", 0, nil, false, http.MethodGet, "/me", http.StatusUnauthorized},
+		{"authenticated", 7, nil, false, http.MethodGet, "/me", http.StatusOK},
+		{"org without identity", 0, []orgService.Capability{orgService.CapOrgUpdate, orgService.CapOrgArchive}, false, http.MethodPut, "/org/1", http.StatusUnauthorized},
+		{"org with every capability", 7, []orgService.Capability{orgService.CapOrgUpdate, orgService.CapOrgArchive}, false, http.MethodPut, "/org/1", http.StatusOK},
+		{"org missing one capability", 7, []orgService.Capability{orgService.CapOrgUpdate}, false, http.MethodPut, "/org/1", http.StatusForbidden},
+		{"route without policy", 7, nil, false, http.MethodGet, "/unlisted", http.StatusForbidden},
+		{"unknown route", 7, nil, false, http.MethodGet, "/nowhere", http.StatusNotFound},
+		{"admin without identity", 0, nil, true, http.MethodGet, "/admin", http.StatusUnauthorized},
+		{"admin route for regular user", 7, nil, false, http.MethodGet, "/admin", http.StatusForbidden},
+		{"admin route for admin", 7, nil, true, http.MethodGet, "/admin", http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newRouter(testPolicies, &stubOrgs{granted: tc.granted, admin: tc.admin}, tc.userID)
+			if got := serve(router, tc.method, tc.path); got != tc.want {
+				t.Fatalf("status = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEnforce_ExplainsMissingTwoFactor(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{orgService.ErrTwoFactorRequired, orgService.ErrTwoFactorRequired.Error()},
+		{errors.New("database is down"), "insufficient permissions"},
+	} {
+		router := newRouter(testPolicies, &stubOrgs{err: tc.err}, 7)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/org/1", nil))
+		if recorder.Code != http.StatusForbidden || !strings.Contains(recorder.Body.String(), tc.want) {
+			t.Fatalf("%v: status %d, body %s", tc.err, recorder.Code, recorder.Body)
+		}
+	}
+}
+
+func TestEnforce_DestructiveRoutesRefuseImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	orgs := &stubOrgs{granted: []orgService.Capability{orgService.CapOrgUpdate, orgService.CapOrgArchive, orgService.CapOrgDelete}}
+
+	for _, identity := range []common.Identity{{UserID: 7}, {UserID: 7, ActorID: 1}} {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set("userID", identity.UserID)
+			c.Request = c.Request.WithContext(common.WithIdentity(c.Request.Context(), identity))
+		})
+		router.Use(Enforce(testPolicies, orgs))
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		router.PUT("/org/:orgId", ok)
+		router.DELETE("/org/:orgId", ok)
+
+		wantDelete := http.StatusOK
+		if identity.Impersonated() {
+			wantDelete = http.StatusForbidden
+		}
+		if got := serve(router, http.MethodDelete, "/org/1"); got != wantDelete {
+			t.Fatalf("%+v: DELETE status = %d, want %d", identity, got, wantDelete)
+		}
+		if got := serve(router, http.MethodPut, "/org/1"); got != http.StatusOK {
+			t.Fatalf("%+v: PUT status = %d, want %d", identity, got, http.StatusOK)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/auth"
+	"meu-treino-golang/users-crud/internal/common"
+	"meu-treino-golang/users-crud/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimits assigns a limit to each route, keyed like Policies. Routes
+// without an entry share the Default limit; a route with its own entry has
+// its own buckets. PerIP caps every request from one address, whoever it
+// authenticates as.
+type RateLimits struct {
+	Default ratelimit.Limit
+	Routes  map[string]ratelimit.Limit
+	PerIP   ratelimit.Limit
+}
+
+// Check returns an error naming every limited route that is not registered,
+// so that renamed routes do not silently lose their limit.
+func (l RateLimits) Check(routes gin.RoutesInfo) error {
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	var stale []string
+	for key := range l.Routes {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("rate limits for unknown routes: %v", stale)
+	}
+	return nil
+}
+
+// RateLimitIP takes a token for every request from the bucket of its client
+// IP. It must run before Authenticate, so that requests failing
+// authentication are counted too.
+func RateLimitIP(limits RateLimits, store ratelimit.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limits.PerIP.Enabled() || takeToken(c, store, "ip|"+c.ClientIP(), limits.PerIP) {
+			c.Next()
+		}
+	}
+}
+
+// RateLimit takes a token for every request from the bucket of its route and
+// caller, and rejects the request with 429 when the bucket is empty. It must
+// run after Authenticate: callers are told apart by API key, then by user,
+// and anonymous callers by IP. Responses carry the RateLimit-* headers; a
+// failing store lets requests through.
+func RateLimit(limits RateLimits, store ratelimit.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := "default"
+		limit := limits.Default
+		if c.FullPath() != "" {
+			if routeLimit, ok := limits.Routes[c.Request.Method+" "+c.FullPath()]; ok {
+				scope = c.Request.Method + " " + c.FullPath()
+				limit = routeLimit
+			}
+		}
+		if !limit.Enabled() || takeToken(c, store, scope+"|"+rateLimitSubject(c), limit) {
+			c.Next()
+		}
+	}
+}
+
+// takeToken takes a token from the bucket named key and sets the RateLimit-*
+// headers. When the bucket is empty it aborts the request with 429 and
+// returns false.
+func takeToken(c *gin.Context, store ratelimit.Store, key string, limit ratelimit.Limit) bool {
+	result, err := store.Take(c.Request.Context(), key, limit, time.Now())
+	if err != nil {
+		log.Printf("rate limit: %v", err)
+		return true
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", ceilSeconds(result.Reset))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%s", limit.Burst, ceilSeconds(limit.Period)))
+	if !result.Allowed {
+		c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return false
+	}
+	return true
+}
+
+// rateLimitSubject names who a request is counted against. Requests
+// authenticated with an API key are counted per key, so one user's keys do
+// not share a bucket.
+func rateLimitSubject(c *gin.Context) string {
+	identity, ok := common.IdentityFrom(c.Request.Context())
+	if !ok {
+		return "ip:" + c.ClientIP()
+	}
+	if identity.Method == auth.ModeAPIKey {
+		digest := sha256.Sum256([]byte(c.GetHeader(auth.APIKeyHeader)))
+		return "key:" + hex.EncodeToString(digest[:8])
+	}
+	return "user:" + strconv.FormatUint(uint64(identity.UserID), 10)
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
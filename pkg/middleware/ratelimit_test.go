@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"meu-treino-golang/users-crud/internal/auth"
+	"meu-treino-golang/users-crud/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRateLimitedRouter(store ratelimit.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(
+		Authenticate(auth.NewAPIKeyAuthenticator(map[string]uint{"k1": 1, "k2": 1})),
+		RateLimit(RateLimits{
+			Default: ratelimit.Limit{Burst: 2, Period: time.Minute},
+			Routes: map[string]ratelimit.Limit{
+				"POST /signup": {Burst: 1, Period: time.Hour},
+			},
+		}, store),
+	)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/items", ok)
+	router.GET("/other", ok)
+	router.POST("/signup", ok)
+	return router
+}
+
+func call(router *gin.Engine, method, path, apiKey string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	if apiKey != "" {
+		r.Header.Set(auth.APIKeyHeader, apiKey)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, r)
+	return recorder
+}
+
+func TestRateLimit(t *testing.T) {
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore())
+
+	first := call(router, http.MethodGet, "/items", "")
+	if first.Code != http.StatusOK {
+		t.Fatalf("first request: status %d", first.Code)
+	}
+	if first.Header().Get("RateLimit-Limit") != "2" || first.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("unexpected headers %v", first.Header())
+	}
+	if got := first.Header().Get("RateLimit-Policy"); got != "2;w=60" {
+		t.Fatalf("RateLimit-Policy = %q", got)
+	}
+
+	// Routes without their own limit share the default bucket.
+	call(router, http.MethodGet, "/other", "")
+	limited := call(router, http.MethodGet, "/items", "")
+	if limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request: status %d, want 429", limited.Code)
+	}
+	if limited.Header().Get("Retry-After") != "30" {
+		t.Fatalf("Retry-After = %q, want 30", limited.Header().Get("Retry-After"))
+	}
+
+	// A route with its own limit has its own bucket.
+	if code := call(router, http.MethodPost, "/signup", "").Code; code != http.StatusOK {
+		t.Fatalf("signup: status %d", code)
+	}
+	if code := call(router, http.MethodPost, "/signup", "").Code; code != http.StatusTooManyRequests {
+		t.Fatalf("second signup: status %d, want 429", code)
+	}
+
+	// Each API key is counted separately from the IP and from other keys,
+	// even when both keys belong to the same user.
+	if code := call(router, http.MethodGet, "/items", "k1").Code; code != http.StatusOK {
+		t.Fatalf("k1: status %d", code)
+	}
+	call(router, http.MethodGet, "/items", "k1")
+	if code := call(router, http.MethodGet, "/items", "k1").Code; code != http.StatusTooManyRequests {
+		t.Fatalf("k1 third request: status %d, want 429", code)
+	}
+	if code := call(router, http.MethodGet, "/items", "k2").Code; code != http.StatusOK {
+		t.Fatalf("k2: status %d", code)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("database down")
+}
+
+func TestRateLimit_FailsOpen(t *testing.T) {
+	router := newRateLimitedRouter(failingStore{})
+	for i := 0; i < 5; i++ {
+		if code := call(router, http.MethodPost, "/signup", "").Code; code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, code)
+		}
+	}
+}
+
+func TestRateLimitsCheck(t *testing.T) {
+	limits := RateLimits{Routes: map[string]ratelimit.Limit{"POST /gone": {Burst: 1, Period: time.Minute}}}
+	routes := gin.RoutesInfo{{Method: http.MethodPost, Path: "/signup"}}
+	if err := limits.Check(routes); err == nil {
+		t.Fatal("expected an error for a limit on an unknown route")
+	}
+}
+
+func TestRateLimitIP_CountsFailedAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(
+		RateLimitIP(RateLimits{PerIP: ratelimit.Limit{Burst: 2, Period: time.Minute}}, ratelimit.NewMemoryStore()),
+		Authenticate(auth.NewAPIKeyAuthenticator(map[string]uint{"k1": 1})),
+	)
+	router.GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 2; i++ {
+		if code := call(router, http.MethodGet, "/items", "guess").Code; code != http.StatusUnauthorized {
+			t.Fatalf("guess %d: status %d, want 401", i, code)
+		}
+	}
+	if code := call(router, http.MethodGet, "/items", "guess").Code; code != http.StatusTooManyRequests {
+		t.Fatalf("third guess: status %d, want 429", code)
+	}
+	// The bucket is per IP, so a valid key from the same address waits too.
+	if code := call(router, http.MethodGet, "/items", "k1").Code; code != http.StatusTooManyRequests {
+		t.Fatalf("valid key: status %d, want 429", code)
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"meu-treino-golang/users-crud/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestInfo stores the client IP, user agent and request ID in the
+// request context. A request ID sent by the client is kept when it looks
+// sane, otherwise a new one is generated; either way it is echoed back.
+func RequestInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		c.Request = c.Request.WithContext(common.WithRequestInfo(c.Request.Context(), common.RequestInfo{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: requestID,
+		}))
+		c.Next()
+	}
+}
+
+// TrustedProxiesFromEnv lists the proxies in TRUSTED_PROXIES, addresses or
+// CIDR ranges separated by commas. Only they may name the client in
+// X-Forwarded-For; by default no proxy is trusted and the client IP is the
+// address of the connection.
+func TrustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
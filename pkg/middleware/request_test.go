@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestInfo())
+
+	var seen common.RequestInfo
+	router.GET("/", func(c *gin.Context) {
+		seen = common.RequestInfoFrom(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	cases := map[string]bool{
+		"":                       false,
+		"abc-123":                true,
+		"has space":              false,
+		strings.Repeat("x", 200): false,
+	}
+	for header, kept := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", "test-agent")
+		if header != "" {
+			r.Header.Set(RequestIDHeader, header)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+
+		echoed := recorder.Header().Get(RequestIDHeader)
+		if echoed == "" || echoed != seen.RequestID {
+			t.Fatalf("%q: echoed %q, context has %q", header, echoed, seen.RequestID)
+		}
+		if (echoed == header) != kept {
+			t.Fatalf("%q: kept = %v, want %v", header, echoed == header, kept)
+		}
+		if seen.UserAgent != "test-agent" || seen.IP == "" {
+			t.Fatalf("%q: unexpected request info %+v", header, seen)
+		}
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TRUSTED_PROXIES", "")
+	if proxies := TrustedProxiesFromEnv(); proxies != nil {
+		t.Fatalf("default proxies = %v, want none", proxies)
+	}
+	t.Setenv("TRUSTED_PROXIES", " 10.0.0.0/8, ,192.0.2.1 ")
+	if proxies := TrustedProxiesFromEnv(); len(proxies) != 2 || proxies[0] != "10.0.0.0/8" || proxies[1] != "192.0.2.1" {
+		t.Fatalf("proxies = %v", proxies)
+	}
+
+	cases := []struct {
+		proxies []string
+		want    string
+	}{
+		{nil, "203.0.113.9"},
+		{[]string{"203.0.113.0/24"}, "198.51.100.7"},
+	}
+	for _, tc := range cases {
+		router := gin.New()
+		if err := router.SetTrustedProxies(tc.proxies); err != nil {
+			t.Fatalf("set trusted proxies: %v", err)
+		}
+		router.Use(RequestInfo())
+		var seen common.RequestInfo
+		router.GET("/", func(c *gin.Context) {
+			seen = common.RequestInfoFrom(c.Request.Context())
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "203.0.113.9:1234"
+		r.Header.Set("X-Forwarded-For", "198.51.100.7")
+		router.ServeHTTP(httptest.NewRecorder(), r)
+		if seen.IP != tc.want {
+			t.Errorf("trusting %v: client IP = %q, want %q", tc.proxies, seen.IP, tc.want)
+		}
+	}
+}
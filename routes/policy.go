@@ -0,0 +1,116 @@
+package routes
+
+import (
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+	"meu-treino-golang/users-crud/pkg/middleware"
+)
+
+// policies declares who may call each route. Every registered route must
+// appear here or the application refuses to start. Destructive routes,
+// which delete or archive data or remove access, are refused while
+// impersonating.
+var policies = middleware.Policies{
+	// Users
+	"POST /api/users":              middleware.Public(),
+	"GET /api/users":               middleware.Public(),
+	"GET /api/users/:id":           middleware.Public(),
+	"POST /api/users/verify-email": middleware.Public(),
+
+	// Login
+	"POST /api/auth/login":              middleware.Public(),
+	"POST /api/auth/login/2fa":          middleware.Public(),
+	"GET /api/auth/sso/:orgId/login":    middleware.Public(),
+	"GET /api/auth/sso/:orgId/callback": middleware.Public(),
+	// Changing credentials would let an impersonating admin log in as the
+	// user, or lock the user out.
+	"PUT /api/me/password":     middleware.Destructive(middleware.Authenticated()),
+	"POST /api/me/2fa/enroll":  middleware.Destructive(middleware.Authenticated()),
+	"POST /api/me/2fa/confirm": middleware.Destructive(middleware.Authenticated()),
+	"POST /api/me/2fa/disable": middleware.Destructive(middleware.Authenticated()),
+	// Resending only mails the user's own address.
+	"POST /api/me/email/verification": middleware.Authenticated(),
+
+	// Organizations
+	"POST /api/org":                      middleware.Authenticated(),
+	"GET /api/org":                       middleware.Public(),
+	"GET /api/org/:orgId":                middleware.Org(orgService.CapMembersRead),
+	"PUT /api/org/:orgId":                middleware.Org(orgService.CapOrgUpdate),
+	"DELETE /api/org/:orgId":             middleware.Destructive(middleware.Org(orgService.CapOrgDelete)),
+	"POST /api/org/:orgId/archive":       middleware.Destructive(middleware.Org(orgService.CapOrgArchive)),
+	"POST /api/org/:orgId/unarchive":     middleware.Org(orgService.CapOrgArchive),
+	"GET /api/org/:orgId/usage":          middleware.Org(orgService.CapMembersRead),
+	"GET /api/org/:orgId/settings":       middleware.Org(orgService.CapMembersRead),
+	"PATCH /api/org/:orgId/settings":     middleware.Org(orgService.CapSettingsManage),
+	"PUT /api/org/:orgId/parent":         middleware.Org(orgService.CapOrgHierarchy),
+	"PUT /api/org/:orgId/inherit-access": middleware.Org(orgService.CapOrgHierarchy),
+	"GET /api/org/:orgId/ancestors":      middleware.Org(orgService.CapMembersRead),
+	"GET /api/org/:orgId/subtree":        middleware.Org(orgService.CapMembersRead),
+	"GET /api/org/:orgId/audit":          middleware.Org(orgService.CapAuditRead),
+	"GET /api/org-deletions/:jobId":      middleware.Authenticated(),
+	"GET /api/me/orgs":                   middleware.Authenticated(),
+	"GET /api/users/:id/orgs":            middleware.Authenticated(),
+	"POST /api/invitations/accept":       middleware.Authenticated(),
+	"POST /api/invitations/decline":      middleware.Public(),
+	"POST /api/org/:orgId/join-requests": middleware.Authenticated(),
+
+	// Platform administration
+	"GET /api/admin/orgs":                  middleware.PlatformAdmin(),
+	"PUT /api/admin/orgs/:orgId/plan":      middleware.PlatformAdmin(),
+	"POST /api/admin/impersonate/:userId":  middleware.PlatformAdmin(),
+	"GET /api/admin/audit":                 middleware.PlatformAdmin(),
+	"POST /api/admin/users/:userId/unlock": middleware.PlatformAdmin(),
+	"GET /api/admin/metrics":               middleware.PlatformAdmin(),
+
+	// Organization users
+	"POST /api/org/:orgId/users":               middleware.Org(orgService.CapMembersAdd),
+	"GET /api/org/:orgId/users":                middleware.Org(orgService.CapMembersRead),
+	"GET /api/org/:orgId/users/expiring":       middleware.Org(orgService.CapMembersRead),
+	"PUT /api/org/:orgId/users/:userId":        middleware.Org(orgService.CapMembersUpdateRole),
+	"DELETE /api/org/:orgId/users/:userId":     middleware.Destructive(middleware.Org(orgService.CapMembersRemove)),
+	"PUT /api/org/:orgId/users/:userId/expiry": middleware.Org(orgService.CapMembersUpdateRole),
+	// A batch may add, change and remove members, so it needs all three.
+	"POST /api/org/:orgId/users/bulk": middleware.Destructive(middleware.Org(
+		orgService.CapMembersAdd,
+		orgService.CapMembersUpdateRole,
+		orgService.CapMembersRemove,
+	)),
+
+	// Organization invitations
+	"POST /api/org/:orgId/invitations":                      middleware.Org(orgService.CapInvitationsManage),
+	"GET /api/org/:orgId/invitations":                       middleware.Org(orgService.CapInvitationsManage),
+	"DELETE /api/org/:orgId/invitations/:invitationId":      middleware.Destructive(middleware.Org(orgService.CapInvitationsManage)),
+	"POST /api/org/:orgId/invitations/:invitationId/resend": middleware.Org(orgService.CapInvitationsManage),
+
+	// Organization join requests
+	"GET /api/org/:orgId/join-requests":                     middleware.Org(orgService.CapMembersAdd),
+	"POST /api/org/:orgId/join-requests/:requestId/approve": middleware.Org(orgService.CapMembersAdd),
+	"POST /api/org/:orgId/join-requests/:requestId/reject":  middleware.Org(orgService.CapMembersAdd),
+
+	// Organization email domains
+	"POST /api/org/:orgId/domains":                  middleware.Org(orgService.CapDomainsManage),
+	"GET /api/org/:orgId/domains":                   middleware.Org(orgService.CapDomainsManage),
+	"POST /api/org/:orgId/domains/:domainId/verify": middleware.Org(orgService.CapDomainsManage),
+	"DELETE /api/org/:orgId/domains/:domainId":      middleware.Destructive(middleware.Org(orgService.CapDomainsManage)),
+
+	// Organization single sign-on. The identity provider signs users of the
+	// organization's domains in, so changing it is refused while
+	// impersonating, like changing credentials.
+	"GET /api/org/:orgId/sso":    middleware.Org(orgService.CapSSOManage),
+	"PUT /api/org/:orgId/sso":    middleware.Destructive(middleware.Org(orgService.CapSSOManage)),
+	"DELETE /api/org/:orgId/sso": middleware.Destructive(middleware.Org(orgService.CapSSOManage)),
+
+	// Organization roles
+	"POST /api/org/:orgId/roles":           middleware.Org(orgService.CapRolesManage),
+	"GET /api/org/:orgId/roles":            middleware.Org(orgService.CapMembersRead),
+	"PUT /api/org/:orgId/roles/:roleId":    middleware.Org(orgService.CapRolesManage),
+	"DELETE /api/org/:orgId/roles/:roleId": middleware.Destructive(middleware.Org(orgService.CapRolesManage)),
+
+	// Organization teams
+	"POST /api/org/:orgId/teams":                           middleware.Org(orgService.CapTeamsManage),
+	"GET /api/org/:orgId/teams":                            middleware.Org(orgService.CapMembersRead),
+	"GET /api/org/:orgId/teams/:teamId":                    middleware.Org(orgService.CapMembersRead),
+	"PUT /api/org/:orgId/teams/:teamId":                    middleware.Org(orgService.CapTeamsManage),
+	"DELETE /api/org/:orgId/teams/:teamId":                 middleware.Destructive(middleware.Org(orgService.CapTeamsManage)),
+	"POST /api/org/:orgId/teams/:teamId/members":           middleware.Org(orgService.CapTeamsManage),
+	"DELETE /api/org/:orgId/teams/:teamId/members/:userId": middleware.Destructive(middleware.Org(orgService.CapTeamsManage)),
+}
@@ -0,0 +1,130 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+
+	"meu-treino-golang/users-crud/dto"
+	orgService "meu-treino-golang/users-crud/internal/service/domain/organizations"
+	"meu-treino-golang/users-crud/pkg/middleware"
+)
+
+// minimumLevel returns the lowest built-in permission holding every
+// capability, or LevelNone when not even ROOT does.
+func minimumLevel(capabilities []orgService.Capability) orgService.PermissionLevel {
+	for _, permission := range []dto.PermissionType{dto.PermissionRead, dto.PermissionWrite, dto.PermissionRoot} {
+		allowed := true
+		for _, capability := range capabilities {
+			if !orgService.Evaluate(orgService.CapabilitiesFor(permission), capability) {
+				allowed = false
+			}
+		}
+		if allowed {
+			return orgService.LevelOf(permission)
+		}
+	}
+	return orgService.LevelNone
+}
+
+// TestPolicies_OrgRouteLevels walks the organization routes of the policy
+// table and checks the built-in level each one ends up requiring: ROOT can
+// call them all, READ members cannot change anything and only ROOT may call
+// destructive routes.
+func TestPolicies_OrgRouteLevels(t *testing.T) {
+	for route, policy := range policies {
+		if policy.Access != middleware.AccessOrg {
+			continue
+		}
+
+		level := minimumLevel(policy.Capabilities)
+		switch {
+		case level == orgService.LevelNone:
+			t.Errorf("%s: no built-in permission may call it", route)
+		case policy.Destructive && level != orgService.LevelRoot:
+			t.Errorf("%s: destructive, but %v may call it", route, level)
+		case !strings.HasPrefix(route, "GET ") && level < orgService.LevelWrite:
+			t.Errorf("%s: changes data, but %v may call it", route, level)
+		}
+	}
+}
+
+// orgRouteLevels pins the lowest built-in permission that may call each
+// organization route. Changing a route's capabilities so that it needs a
+// different level must be deliberate, here as in routes/policy.go.
+var orgRouteLevels = map[string]dto.PermissionType{
+	"GET /api/org/:orgId":                      dto.PermissionRead,
+	"PUT /api/org/:orgId":                      dto.PermissionWrite,
+	"DELETE /api/org/:orgId":                   dto.PermissionRoot,
+	"POST /api/org/:orgId/archive":             dto.PermissionRoot,
+	"POST /api/org/:orgId/unarchive":           dto.PermissionRoot,
+	"GET /api/org/:orgId/usage":                dto.PermissionRead,
+	"GET /api/org/:orgId/settings":             dto.PermissionRead,
+	"PATCH /api/org/:orgId/settings":           dto.PermissionRoot,
+	"PUT /api/org/:orgId/parent":               dto.PermissionRoot,
+	"PUT /api/org/:orgId/inherit-access":       dto.PermissionRoot,
+	"GET /api/org/:orgId/ancestors":            dto.PermissionRead,
+	"GET /api/org/:orgId/subtree":              dto.PermissionRead,
+	"GET /api/org/:orgId/audit":                dto.PermissionRoot,
+	"POST /api/org/:orgId/users":               dto.PermissionRoot,
+	"GET /api/org/:orgId/users":                dto.PermissionRead,
+	"GET /api/org/:orgId/users/expiring":       dto.PermissionRead,
+	"PUT /api/org/:orgId/users/:userId":        dto.PermissionRoot,
+	"DELETE /api/org/:orgId/users/:userId":     dto.PermissionRoot,
+	"PUT /api/org/:orgId/users/:userId/expiry": dto.PermissionRoot,
+	"POST /api/org/:orgId/users/bulk":          dto.PermissionRoot,
+
+	"POST /api/org/:orgId/invitations":                      dto.PermissionRoot,
+	"GET /api/org/:orgId/invitations":                       dto.PermissionRoot,
+	"DELETE /api/org/:orgId/invitations/:invitationId":      dto.PermissionRoot,
+	"POST /api/org/:orgId/invitations/:invitationId/resend": dto.PermissionRoot,
+
+	"GET /api/org/:orgId/join-requests":                     dto.PermissionRoot,
+	"POST /api/org/:orgId/join-requests/:requestId/approve": dto.PermissionRoot,
+	"POST /api/org/:orgId/join-requests/:requestId/reject":  dto.PermissionRoot,
+
+	"GET /api/org/:orgId/roles":            dto.PermissionRead,
+	"POST /api/org/:orgId/roles":           dto.PermissionRoot,
+	"PUT /api/org/:orgId/roles/:roleId":    dto.PermissionRoot,
+	"DELETE /api/org/:orgId/roles/:roleId": dto.PermissionRoot,
+
+	"GET /api/org/:orgId/teams":                            dto.PermissionRead,
+	"GET /api/org/:orgId/teams/:teamId":                    dto.PermissionRead,
+	"POST /api/org/:orgId/teams":                           dto.PermissionRoot,
+	"PUT /api/org/:orgId/teams/:teamId":                    dto.PermissionRoot,
+	"DELETE /api/org/:orgId/teams/:teamId":                 dto.PermissionRoot,
+	"POST /api/org/:orgId/teams/:teamId/members":           dto.PermissionRoot,
+	"DELETE /api/org/:orgId/teams/:teamId/members/:userId": dto.PermissionRoot,
+
+	"GET /api/org/:orgId/domains":                   dto.PermissionRoot,
+	"POST /api/org/:orgId/domains":                  dto.PermissionRoot,
+	"POST /api/org/:orgId/domains/:domainId/verify": dto.PermissionRoot,
+	"DELETE /api/org/:orgId/domains/:domainId":      dto.PermissionRoot,
+
+	"GET /api/org/:orgId/sso":    dto.PermissionRoot,
+	"PUT /api/org/:orgId/sso":    dto.PermissionRoot,
+	"DELETE /api/org/:orgId/sso": dto.PermissionRoot,
+}
+
+// TestPolicies_PinnedOrgRouteLevels checks every organization route against
+// orgRouteLevels with the capability evaluator.
+func TestPolicies_PinnedOrgRouteLevels(t *testing.T) {
+	for route, policy := range policies {
+		if policy.Access != middleware.AccessOrg {
+			continue
+		}
+		want, ok := orgRouteLevels[route]
+		if !ok {
+			t.Errorf("%s: missing from orgRouteLevels", route)
+			continue
+		}
+		if got := minimumLevel(policy.Capabilities); got != orgService.LevelOf(want) {
+			t.Errorf("%s: requires %v, want %s", route, got, want)
+		}
+	}
+
+	for route := range orgRouteLevels {
+		if policy, ok := policies[route]; !ok || policy.Access != middleware.AccessOrg {
+			t.Errorf("%s: pinned, but not an organization route", route)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"time"
+
+	"meu-treino-golang/users-crud/internal/ratelimit"
+	"meu-treino-golang/users-crud/pkg/middleware"
+)
+
+// rateLimits caps how often one caller may call each route. Routes that
+// create accounts or exchange credentials get their own, stricter limits.
+// The per-IP limit also counts requests that fail authentication.
+var rateLimits = middleware.RateLimits{
+	Default: ratelimit.Limit{Burst: 120, Period: time.Minute},
+	PerIP:   ratelimit.Limit{Burst: 600, Period: time.Minute},
+	Routes: map[string]ratelimit.Limit{
+		"POST /api/users":                     {Burst: 10, Period: time.Hour},
+		"POST /api/users/verify-email":        {Burst: 10, Period: time.Minute},
+		"POST /api/me/email/verification":     {Burst: 5, Period: time.Hour},
+		"POST /api/auth/login":                {Burst: 10, Period: time.Minute},
+		"POST /api/auth/login/2fa":            {Burst: 10, Period: time.Minute},
+		"GET /api/auth/sso/:orgId/login":      {Burst: 10, Period: time.Minute},
+		"GET /api/auth/sso/:orgId/callback":   {Burst: 10, Period: time.Minute},
+		"POST /api/me/2fa/confirm":            {Burst: 10, Period: time.Minute},
+		"POST /api/me/2fa/disable":            {Burst: 10, Period: time.Minute},
+		"PUT /api/me/password":                {Burst: 10, Period: time.Minute},
+		"POST /api/admin/impersonate/:userId": {Burst: 10, Period: time.Minute},
+		"POST /api/invitations/accept":        {Burst: 10, Period: time.Minute},
+		"POST /api/invitations/decline":       {Burst: 10, Period: time.Minute},
+	},
+}
@@ -2,17 +2,60 @@
 package routes
 
 import (
+	"meu-treino-golang/users-crud/internal/auth"
 	"meu-treino-golang/users-crud/internal/common"
 	orgHandler "meu-treino-golang/users-crud/pkg/handler/organizations"
 	usersHandler "meu-treino-golang/users-crud/pkg/handler/users"
+	"meu-treino-golang/users-crud/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(router *gin.Engine, deps *common.Dependencies) {
-	usersHandlerInstance := usersHandler.InitHandler(deps)
-	usersHandlerInstance.RegisterRoutes(router)
+// RegisterRoutes registers every route behind the authentication, rate
+// limiting and route policy middleware.
+// It fails when the dependencies or trusted proxies are invalid, when a
+// registered route has no policy, or when a policy or a rate limit names a
+// route that does not exist.
+func RegisterRoutes(router *gin.Engine, deps *common.Dependencies) error {
+	if err := deps.Load(); err != nil {
+		return err
+	}
+	// The client IP keys rate limits and login throttling, so only the
+	// configured proxies may set it through X-Forwarded-For.
+	if err := router.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		return err
+	}
+
+	// Users join the organization that owns their email domain once they
+	// verify their email.
+	autoJoiner, err := orgHandler.DomainAutoJoiner(deps)
+	if err != nil {
+		return err
+	}
+	usersHandlerInstance, err := usersHandler.InitHandler(deps, autoJoiner)
+	if err != nil {
+		return err
+	}
+	orgsHandlerInstance, err := orgHandler.InitHandler(deps)
+	if err != nil {
+		return err
+	}
+
+	router.Use(
+		middleware.RequestInfo(),
+		middleware.RateLimitIP(rateLimits, deps.RateLimitStore),
+		// Impersonation and login session tokens are accepted whatever the
+		// configured mode.
+		middleware.Authenticate(auth.NewImpersonation(deps.TokenSecret, auth.NewSessions(deps.TokenSecret, deps.Authenticator))),
+		middleware.RateLimit(rateLimits, deps.RateLimitStore),
+		middleware.Enforce(policies, orgsHandlerInstance),
+	)
 
-	orgsHandlerInstance := orgHandler.InitHandler(deps)
+	usersHandlerInstance.RegisterRoutes(router)
 	orgsHandlerInstance.RegisterRoutes(router)
+
+	if err := policies.Check(router.Routes()); err != nil {
+		return err
+	}
+	return rateLimits.Check(router.Routes())
 }
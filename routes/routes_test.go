@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"meu-treino-golang/users-crud/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRegisterRoutes_EveryRouteHasPolicy fails when a route is added
+// without a policy, the same check that stops the application at startup.
+func TestRegisterRoutes_EveryRouteHasPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deps := &common.Dependencies{TokenSecret: []byte("test")}
+
+	if err := RegisterRoutes(gin.New(), deps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterRoutes_RequiresTokenSecretInProduction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TOKEN_SECRET", "")
+	for _, secret := range []string{"", "too-short"} {
+		deps := &common.Dependencies{Environment: common.EnvProduction, TokenSecret: []byte(secret)}
+		if err := RegisterRoutes(gin.New(), deps); err == nil {
+			t.Fatalf("secret %q: expected an error in production", secret)
+		}
+	}
+
+	deps := &common.Dependencies{Environment: common.EnvProduction, TokenSecret: []byte("a-secret-of-at-least-thirty-two-bytes")}
+	if err := RegisterRoutes(gin.New(), deps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRegisterRoutes_TrustsNoProxyByDefault keeps X-Forwarded-For from
+// choosing the IP that rate limits and login throttling are keyed on.
+func TestRegisterRoutes_TrustsNoProxyByDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := RegisterRoutes(router, &common.Dependencies{TokenSecret: []byte("test")}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := gin.CreateTestContextOnly(httptest.NewRecorder(), router)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = "203.0.113.9:1234"
+	c.Request.Header.Set("X-Forwarded-For", "198.51.100.7")
+	if ip := c.ClientIP(); ip != "203.0.113.9" {
+		t.Fatalf("client IP = %q, want the connection address", ip)
+	}
+}
@@ -10,9 +10,41 @@ BASE_URL="http://localhost:8080/api"
 
 echo -e "${YELLOW}========== ORGANIZATION API TESTS ==========${NC}\n"
 
+# Test 0: Create Users for testing
+echo -e "${YELLOW}[TEST 0] Creating test users...${NC}"
+USER1=$(curl -s -X POST "$BASE_URL/users" \
+  -H "Content-Type: application/json" \
+  -d '{"name": "John Doe", "email": "john@example.com"}')
+USER_ID1=$(echo $USER1 | grep -o '"id":[0-9]*' | grep -o '[0-9]*')
+echo -e "${GREEN}User 1 ID: $USER_ID1${NC}"
+
+USER2=$(curl -s -X POST "$BASE_URL/users" \
+  -H "Content-Type: application/json" \
+  -d '{"name": "Jane Smith", "email": "jane@example.com"}')
+USER_ID2=$(echo $USER2 | grep -o '"id":[0-9]*' | grep -o '[0-9]*')
+echo -e "${GREEN}User 2 ID: $USER_ID2${NC}\n"
+
+# Test 0a: Get User by ID (User 1)
+echo -e "${YELLOW}[TEST 0a] Getting user 1 by ID...${NC}"
+curl -s -X GET "$BASE_URL/users/$USER_ID1" \
+  -H "Content-Type: application/json" | jq '.'
+echo ""
+
+# Test 0b: Get User by ID (User 2)
+echo -e "${YELLOW}[TEST 0b] Getting user 2 by ID...${NC}"
+curl -s -X GET "$BASE_URL/users/$USER_ID2" \
+  -H "Content-Type: application/json" | jq '.'
+echo ""
+
+# Requests act as user 1, who creates the organizations and becomes their
+# ROOT. The server must run with AUTH_MODE=header for X-User-ID to be
+# trusted (docker-compose does this).
+AUTH_HEADER="X-User-ID: $USER_ID1"
+
 # Test 1: Create Organization
 echo -e "${YELLOW}[TEST 1] Creating organization...${NC}"
 ORG_RESPONSE=$(curl -s -X POST "$BASE_URL/org" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" \
   -d '{"name": "Tech Company"}')
 echo "Response: $ORG_RESPONSE"
@@ -22,6 +54,7 @@ echo -e "${GREEN}Organization ID: $ORG_ID${NC}\n"
 # Test 2: Create another Organization
 echo -e "${YELLOW}[TEST 2] Creating another organization...${NC}"
 ORG_RESPONSE2=$(curl -s -X POST "$BASE_URL/org" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" \
   -d '{"name": "StartUp Inc"}')
 echo "Response: $ORG_RESPONSE2"
@@ -31,56 +64,29 @@ echo -e "${GREEN}Organization ID: $ORG_ID2${NC}\n"
 # Test 3: List Organizations
 echo -e "${YELLOW}[TEST 3] Listing all organizations...${NC}"
 curl -s -X GET "$BASE_URL/org" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" | jq '.'
 echo ""
 
 # Test 4: Get Organization by ID
 echo -e "${YELLOW}[TEST 4] Getting organization by ID...${NC}"
 curl -s -X GET "$BASE_URL/org/$ORG_ID" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" | jq '.'
 echo ""
 
 # Test 5: Update Organization
 echo -e "${YELLOW}[TEST 5] Updating organization...${NC}"
 curl -s -X PUT "$BASE_URL/org/$ORG_ID" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" \
   -d '{"name": "Tech Company Updated"}' | jq '.'
 echo ""
 
-# Test 6: Create Users for testing
-echo -e "${YELLOW}[TEST 6] Creating test users...${NC}"
-USER1=$(curl -s -X POST "$BASE_URL/users" \
-  -H "Content-Type: application/json" \
-  -d '{"name": "John Doe", "email": "john@example.com"}')
-USER_ID1=$(echo $USER1 | grep -o '"id":[0-9]*' | grep -o '[0-9]*')
-echo -e "${GREEN}User 1 ID: $USER_ID1${NC}"
-
-USER2=$(curl -s -X POST "$BASE_URL/users" \
-  -H "Content-Type: application/json" \
-  -d '{"name": "Jane Smith", "email": "jane@example.com"}')
-USER_ID2=$(echo $USER2 | grep -o '"id":[0-9]*' | grep -o '[0-9]*')
-echo -e "${GREEN}User 2 ID: $USER_ID2${NC}\n"
-
-# Test 6a: Get User by ID (User 1)
-echo -e "${YELLOW}[TEST 6a] Getting user 1 by ID...${NC}"
-curl -s -X GET "$BASE_URL/users/$USER_ID1" \
-  -H "Content-Type: application/json" | jq '.'
-echo ""
-
-# Test 6b: Get User by ID (User 2)
-echo -e "${YELLOW}[TEST 6b] Getting user 2 by ID...${NC}"
-curl -s -X GET "$BASE_URL/users/$USER_ID2" \
-  -H "Content-Type: application/json" | jq '.'
-echo ""
-
 # Test 7: Add User to Organization
-echo -e "${YELLOW}[TEST 7] Adding users to organization...${NC}"
-curl -s -X POST "$BASE_URL/org/$ORG_ID/users" \
-  -H "Content-Type: application/json" \
-  -d "{\"user_id\": $USER_ID1, \"permission\": \"ROOT\"}" | jq '.'
-echo ""
-
+echo -e "${YELLOW}[TEST 7] Adding user 2 to organization...${NC}"
 curl -s -X POST "$BASE_URL/org/$ORG_ID/users" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" \
   -d "{\"user_id\": $USER_ID2, \"permission\": \"WRITE\"}" | jq '.'
 echo ""
@@ -88,12 +94,14 @@ echo ""
 # Test 8: List Organization Users
 echo -e "${YELLOW}[TEST 8] Listing organization users...${NC}"
 curl -s -X GET "$BASE_URL/org/$ORG_ID/users" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" | jq '.'
 echo ""
 
 # Test 9: Update User Permission
 echo -e "${YELLOW}[TEST 9] Updating user permission in organization...${NC}"
 curl -s -X PUT "$BASE_URL/org/$ORG_ID/users/$USER_ID2" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" \
   -d '{"permission": "READ"}' | jq '.'
 echo ""
@@ -101,24 +109,52 @@ echo ""
 # Test 10: Remove User from Organization
 echo -e "${YELLOW}[TEST 10] Removing user from organization...${NC}"
 curl -s -X DELETE "$BASE_URL/org/$ORG_ID/users/$USER_ID2" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" | jq '.'
 echo ""
 
 # Test 11: List Organization Users again
 echo -e "${YELLOW}[TEST 11] Listing organization users after removal...${NC}"
 curl -s -X GET "$BASE_URL/org/$ORG_ID/users" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" | jq '.'
 echo ""
 
-# Test 12: Delete Organization
-echo -e "${YELLOW}[TEST 12] Deleting organization...${NC}"
-curl -s -X DELETE "$BASE_URL/org/$ORG_ID" \
+# Test 12: Audit Log
+echo -e "${YELLOW}[TEST 12] Reading the organization audit log...${NC}"
+curl -s -X GET "$BASE_URL/org/$ORG_ID/audit?target_type=member" \
+  -H "$AUTH_HEADER" \
+  -H "Content-Type: application/json" | jq '.'
+echo ""
+
+# Test 13: Preview Organization Deletion
+echo -e "${YELLOW}[TEST 13] Previewing organization deletion...${NC}"
+curl -s -X DELETE "$BASE_URL/org/$ORG_ID?dry_run=true" \
+  -H "$AUTH_HEADER" \
+  -H "Content-Type: application/json" | jq '.'
+echo ""
+
+# Test 14: Delete Organization
+echo -e "${YELLOW}[TEST 14] Deleting organization...${NC}"
+DELETION_RESPONSE=$(curl -s -X DELETE "$BASE_URL/org/$ORG_ID" \
+  -H "$AUTH_HEADER" \
+  -H "Content-Type: application/json")
+echo "$DELETION_RESPONSE" | jq '.'
+JOB_ID=$(echo "$DELETION_RESPONSE" | jq -r '.id')
+echo ""
+
+# Test 15: Deletion Job Status
+echo -e "${YELLOW}[TEST 15] Waiting for the deletion job...${NC}"
+sleep 6
+curl -s -X GET "$BASE_URL/org-deletions/$JOB_ID" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" | jq '.'
 echo ""
 
-# Test 13: List Organizations after deletion
-echo -e "${YELLOW}[TEST 13] Listing organizations after deletion...${NC}"
+# Test 16: List Organizations after deletion
+echo -e "${YELLOW}[TEST 16] Listing organizations after deletion...${NC}"
 curl -s -X GET "$BASE_URL/org" \
+  -H "$AUTH_HEADER" \
   -H "Content-Type: application/json" | jq '.'
 echo ""
 